@@ -2,6 +2,9 @@ module github.com/soloforge/backend
 
 go 1.22
 
-require github.com/gorilla/websocket v1.5.1
-
-require golang.org/x/net v0.17.0 // indirect
+require (
+	github.com/gorilla/websocket v1.5.1
+	github.com/lib/pq v1.10.9
+	golang.org/x/net v0.17.0
+	golang.org/x/sys v0.20.0
+)
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/soloforge/backend/internal/miner"
+	"github.com/soloforge/backend/internal/stats"
+	"github.com/soloforge/backend/internal/stratum"
+)
+
+// recordCandidate keeps a share at or above the candidate difficulty, and
+// every block solution, with its full header and coinbase rebuilt from the
+// recent job it was mined on. The difficulty is taken from the rebuilt
+// header's hash.
+func (s *Server) recordCandidate(worker string, epoch uint64, jobID, extranonce2, ntime, nonce, versionBits string, block bool) {
+	min := s.cfg.GetCandidateMinDifficulty()
+	if min <= 0 && !block {
+		return
+	}
+
+	header, coinbase, job, err := s.shareHeader(epoch, jobID, extranonce2, ntime, nonce, versionBits)
+	if errors.Is(err, stratum.ErrStaleSession) {
+		return
+	}
+	if err != nil {
+		log.Printf("Candidate %s:%s not kept: %v", jobID, nonce, err)
+		return
+	}
+	fields, err := miner.DecodeHeader(header)
+	if err != nil || (fields.Difficulty < min && !block) {
+		return
+	}
+
+	s.stats.AddCandidate(stats.Candidate{
+		ID:             fields.Hash,
+		Timestamp:      time.Now(),
+		WorkerName:     worker,
+		Pool:           s.stratum.ConnectedPool().Name,
+		JobID:          jobID,
+		Difficulty:     fields.Difficulty,
+		PoolDifficulty: s.stratum.GetDifficulty(),
+		Header:         hex.EncodeToString(header),
+		Coinbase:       hex.EncodeToString(coinbase),
+		MerkleBranch:   job.MerkleBranch,
+	})
+}
+
+// handleCandidates lists the stored block candidates, newest first
+func (s *Server) handleCandidates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := 50
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			limit = parsed
+		}
+	}
+
+	jsonResponse(w, s.stats.GetCandidates(limit))
+}
+
+// handleCandidateByID decodes a stored block candidate: header fields,
+// coinbase, merkle path and the targets its hash met
+func (s *Server) handleCandidateByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.Trim(r.URL.Path[len("/api/candidates/"):], "/")
+	candidate, ok := s.stats.GetCandidate(id)
+	if !ok {
+		http.Error(w, "Candidate not found", http.StatusNotFound)
+		return
+	}
+
+	header, _ := hex.DecodeString(candidate.Header)
+	fields, err := miner.DecodeHeader(header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	coinbase, _ := hex.DecodeString(candidate.Coinbase)
+	txid, path := miner.MerklePath(coinbase, candidate.MerkleBranch)
+
+	// How close the candidate came to a block
+	fraction := 0.0
+	if fields.NetworkDifficulty > 0 {
+		fraction = fields.Difficulty / fields.NetworkDifficulty
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"candidate": candidate,
+		"header":    fields,
+		"coinbase": map[string]interface{}{
+			"txid": txid,
+			"hex":  candidate.Coinbase,
+			"size": len(coinbase),
+		},
+		"merkle_path": path,
+		"met": map[string]interface{}{
+			"pool_difficulty":    candidate.PoolDifficulty,
+			"pool_target":        candidate.PoolDifficulty > 0 && fields.Difficulty >= candidate.PoolDifficulty,
+			"network_difficulty": fields.NetworkDifficulty,
+			"network_target":     fields.MeetsTarget,
+			"network_fraction":   fraction,
+		},
+	})
+}
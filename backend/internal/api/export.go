@@ -0,0 +1,186 @@
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// exportTTL is how long a generated export stays downloadable
+const exportTTL = time.Hour
+
+// maxExports bounds the export files kept on disk, oldest go first
+const maxExports = 4
+
+// exportFile is a stats export written to a temporary file. The file is
+// complete and never changes once listed, so any number of downloads can
+// read it and resume from any offset.
+type exportFile struct {
+	ID        string    `json:"id"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	URL       string    `json:"url"`
+	path      string
+}
+
+// handleExports lists the downloadable exports, or generates a new one
+func (s *Server) handleExports(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.exportMu.Lock()
+		s.pruneExportsLocked(time.Now())
+		list := make([]exportFile, 0, len(s.exports))
+		for _, e := range s.exports {
+			list = append(list, *e)
+		}
+		s.exportMu.Unlock()
+
+		sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.After(list[j].CreatedAt) })
+		jsonResponse(w, list)
+
+	case http.MethodPost:
+		e, err := s.createExport()
+		if err != nil {
+			log.Printf("Export failed: %v", err)
+			http.Error(w, "Export failed: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		reqID := requestID(r)
+		s.recordAction(reqID, "export", r.RemoteAddr, fmt.Sprintf("%s (%d bytes)", e.ID, e.Size))
+		jsonResponse(w, map[string]interface{}{
+			"export":     e,
+			"request_id": reqID,
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleExportByID downloads an export, honouring Range and If-Range so an
+// interrupted download resumes where it stopped, or deletes it
+func (s *Server) handleExportByID(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/export/")
+
+	s.exportMu.Lock()
+	s.pruneExportsLocked(time.Now())
+	e, ok := s.exports[id]
+	if ok && r.Method == http.MethodDelete {
+		delete(s.exports, id)
+	}
+	s.exportMu.Unlock()
+
+	if !ok {
+		http.Error(w, "Export not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		// An open file survives the export being pruned mid-download
+		f, err := os.Open(e.path)
+		if err != nil {
+			http.Error(w, "Export not found", http.StatusNotFound)
+			return
+		}
+		defer f.Close()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="soloforge-export-%s.json"`, e.ID))
+		w.Header().Set("ETag", `"`+e.ID+`"`)
+		http.ServeContent(w, r, "", e.CreatedAt, f)
+
+	case http.MethodDelete:
+		os.Remove(e.path)
+		jsonResponse(w, map[string]string{"status": "deleted"})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// createExport writes the stats to a temporary file and lists it once
+// complete, so downloads never see a partial export
+func (s *Server) createExport() (*exportFile, error) {
+	f, err := os.CreateTemp("", "soloforge-export-*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bufio.NewWriter(f)
+	err = s.stats.Export(buf)
+	if err == nil {
+		err = buf.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return nil, err
+	}
+
+	info, err := os.Stat(f.Name())
+	if err != nil {
+		os.Remove(f.Name())
+		return nil, err
+	}
+
+	now := time.Now()
+	id := newRequestID()
+	e := &exportFile{
+		ID:        id,
+		Size:      info.Size(),
+		CreatedAt: now,
+		ExpiresAt: now.Add(exportTTL),
+		URL:       "/api/export/" + id,
+		path:      f.Name(),
+	}
+
+	s.exportMu.Lock()
+	s.exports[id] = e
+	s.pruneExportsLocked(now)
+	s.exportMu.Unlock()
+
+	return e, nil
+}
+
+// pruneExportsLocked removes expired exports and the oldest beyond
+// maxExports. Caller must hold s.exportMu.
+func (s *Server) pruneExportsLocked(now time.Time) {
+	for id, e := range s.exports {
+		if now.After(e.ExpiresAt) {
+			os.Remove(e.path)
+			delete(s.exports, id)
+		}
+	}
+
+	for len(s.exports) > maxExports {
+		var oldest *exportFile
+		for _, e := range s.exports {
+			if oldest == nil || e.CreatedAt.Before(oldest.CreatedAt) {
+				oldest = e
+			}
+		}
+		os.Remove(oldest.path)
+		delete(s.exports, oldest.ID)
+	}
+}
+
+// removeExports deletes every export file
+func (s *Server) removeExports() {
+	s.exportMu.Lock()
+	defer s.exportMu.Unlock()
+
+	for id, e := range s.exports {
+		os.Remove(e.path)
+		delete(s.exports, id)
+	}
+}
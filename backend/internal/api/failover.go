@@ -0,0 +1,309 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/soloforge/backend/internal/stratum"
+	"github.com/soloforge/backend/internal/tariff"
+)
+
+// handlePoolSwitch notifies clients of failover and failback events
+func (s *Server) handlePoolSwitch(from, to stratum.Pool, reason string) {
+	// A promoted standby means no disconnect follows a manual switch
+	if reason == "standby" {
+		s.mu.Lock()
+		s.manualSwitch = false
+		s.reconnectDelay = 0
+		s.mu.Unlock()
+	}
+
+	s.wsHub.BroadcastEvent("pool_switch", map[string]interface{}{
+		"from":   from,
+		"to":     to,
+		"reason": reason,
+	})
+	s.broadcastLog(fmt.Sprintf("🔀 Pool %s: %s → %s", reason, from.Name, to.Name), "var(--warning)")
+}
+
+// configurePools pushes the configured pool list and failover policy to
+// the stratum client. A non-empty profile names the pool to try first.
+func (s *Server) configurePools(profile string) error {
+	pools, err := s.sessionPools(profile)
+	if err != nil {
+		return err
+	}
+
+	s.stratum.SetPools(pools)
+	s.stratum.SetFailoverPolicy(s.cfg.GetFailoverThreshold(), time.Duration(s.cfg.GetFailbackSeconds())*time.Second)
+	s.stratum.SetStandbyEnabled(s.cfg.GetStandbyEnabled())
+	s.stratum.SetIdleTimeout(time.Duration(s.cfg.GetIdleTimeoutSeconds()) * time.Second)
+	s.stratum.SetJobExpiry(time.Duration(s.cfg.GetJobExpirySeconds()) * time.Second)
+	s.stratum.SetNoJobTimeout(time.Duration(s.cfg.GetNoJobTimeoutSeconds()) * time.Second)
+	return nil
+}
+
+// sessionPools returns the pools to mine on in failover order, starting
+// with the selected profile if any. With the mock pool enabled it is the
+// only pool and is started on a free local port.
+func (s *Server) sessionPools(profile string) ([]stratum.Pool, error) {
+	if s.cfg.GetMockPool() {
+		if err := s.mockPool.Start("127.0.0.1:0"); err != nil {
+			return nil, err
+		}
+		return []stratum.Pool{{Name: "mock", URL: "127.0.0.1", Port: s.mockPool.Port()}}, nil
+	}
+
+	pools := []stratum.Pool{{
+		Name: "primary",
+		URL:  s.cfg.GetPoolURL(),
+		Port: s.cfg.GetPoolPort(),
+	}}
+	for _, p := range s.cfg.GetBackupPools() {
+		pools = append(pools, stratum.Pool{Name: p.Name, URL: p.URL, Port: p.Port})
+	}
+
+	if profile != "" {
+		index := -1
+		for i, p := range pools {
+			if p.Name == profile {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return nil, fmt.Errorf("unknown pool profile %q", profile)
+		}
+
+		// Selected pool first, the rest keep their failover order
+		ordered := append([]stratum.Pool{pools[index]}, pools[:index]...)
+		pools = append(ordered, pools[index+1:]...)
+	}
+
+	return pools, nil
+}
+
+// handleAdminFailover deliberately switches to another pool, by default
+// the next one or back to the primary, to exercise failover with real
+// pools. Staying on a backup, the failback probe returns to the primary.
+// The switch runs in the background, see switchPool.
+func (s *Server) handleAdminFailover(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Pool string `json:"pool"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+
+	if !s.isMining() {
+		http.Error(w, "Not mining", http.StatusConflict)
+		return
+	}
+
+	from, to, err := s.stratum.CheckPoolSwitch(req.Pool)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	s.mu.Lock()
+	if s.switching {
+		s.mu.Unlock()
+		http.Error(w, "Pool switch in progress", http.StatusConflict)
+		return
+	}
+	s.switching = true
+	s.mu.Unlock()
+
+	reqID := requestID(r)
+	s.recordAction(reqID, "failover", r.RemoteAddr, fmt.Sprintf("from=%s to=%s", from.Name, to.Name))
+	go s.switchPool(reqID, req.Pool, from, to, nil)
+
+	jsonResponse(w, map[string]interface{}{
+		"status":     "switching",
+		"from":       from,
+		"to":         to,
+		"request_id": reqID,
+	})
+}
+
+// switchPool moves to another pool without losing shares: workers pause,
+// in-flight submits get switchDrainTimeout to resolve, then the connection
+// is replaced and workers resume once the new pool authorizes. Non-nil
+// pools replace the failover order before switching. Each stage is
+// broadcast as a pool_switch_progress event.
+func (s *Server) switchPool(reqID, name string, from, to stratum.Pool, pools []stratum.Pool) {
+	defer func() {
+		s.mu.Lock()
+		s.switching = false
+		s.mu.Unlock()
+	}()
+
+	progress := func(stage string, extra map[string]interface{}) {
+		event := map[string]interface{}{
+			"request_id": reqID,
+			"stage":      stage,
+			"from":       from.Name,
+			"to":         to.Name,
+		}
+		for k, v := range extra {
+			event[k] = v
+		}
+		s.wsHub.BroadcastEvent("pool_switch_progress", event)
+	}
+
+	// Stop issuing new work, shares already found are still submitted
+	s.manager.StopAll()
+	abandoned := s.stratum.DrainSubmits(switchDrainTimeout, func(pending int) {
+		progress("draining", map[string]interface{}{"pending": pending})
+	})
+	if abandoned > 0 {
+		s.broadcastLog(fmt.Sprintf("⚠️ Pool switch: %d submits unanswered after %s", abandoned, switchDrainTimeout), "var(--warning)")
+	}
+
+	if !s.isMining() {
+		progress("failed", map[string]interface{}{"error": "mining stopped"})
+		return
+	}
+
+	// Flag the switch before the disconnect it causes
+	epoch := s.stratum.SessionEpoch()
+	s.mu.Lock()
+	s.manualSwitch = true
+	s.reconnectDelay = time.Second
+	s.mu.Unlock()
+
+	progress("switching", map[string]interface{}{"abandoned": abandoned})
+	if pools != nil {
+		s.stratum.SetPools(pools)
+	}
+	if _, _, err := s.stratum.SwitchPool(name); err != nil {
+		s.mu.Lock()
+		s.manualSwitch = false
+		s.reconnectDelay = 0
+		s.mu.Unlock()
+		s.resumeAfterSwitch()
+		progress("failed", map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	// A new session epoch means a new connection, standby or reconnected
+	deadline := time.Now().Add(switchResumeTimeout)
+	for s.stratum.SessionEpoch() == epoch || !s.stratum.IsAuthorized() {
+		if !s.isMining() {
+			progress("failed", map[string]interface{}{"error": "mining stopped"})
+			return
+		}
+		if time.Now().After(deadline) {
+			// Resume anyway, the reconnect loop keeps trying
+			s.resumeAfterSwitch()
+			progress("failed", map[string]interface{}{"error": "timed out waiting for the new pool"})
+			return
+		}
+		select {
+		case <-s.shutdown:
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	s.resumeAfterSwitch()
+	progress("resumed", map[string]interface{}{"pool": s.stratum.ConnectedPool().Name})
+}
+
+// handlePoolSwitchRequest moves the running session to another configured
+// pool, which becomes the primary as if mining had been started with it as
+// pool_profile. Unlike /api/admin/failover, failback doesn't return to the
+// previous pool. Body: {"pool": "backup1"}
+func (s *Server) handlePoolSwitchRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Pool string `json:"pool"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Pool == "" {
+		http.Error(w, "pool is required", http.StatusBadRequest)
+		return
+	}
+
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+
+	if !s.isMining() {
+		http.Error(w, "Not mining", http.StatusConflict)
+		return
+	}
+	if s.cfg.GetMockPool() {
+		http.Error(w, "Mining against the mock pool, no other pool to switch to", http.StatusConflict)
+		return
+	}
+
+	pools, err := s.sessionPools(req.Pool)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	from, to := s.stratum.ConnectedPool(), pools[0]
+	if from.URL == to.URL && from.Port == to.Port {
+		http.Error(w, "Already on pool "+from.Name, http.StatusConflict)
+		return
+	}
+	if !s.stratum.IsConnected() {
+		http.Error(w, "not connected", http.StatusConflict)
+		return
+	}
+
+	s.mu.Lock()
+	if s.switching {
+		s.mu.Unlock()
+		http.Error(w, "Pool switch in progress", http.StatusConflict)
+		return
+	}
+	s.switching = true
+	s.mu.Unlock()
+
+	reqID := requestID(r)
+	s.recordAction(reqID, "pool_switch", r.RemoteAddr, fmt.Sprintf("from=%s to=%s", from.Name, to.Name))
+	go s.switchPool(reqID, to.Name, from, to, pools)
+
+	jsonResponse(w, map[string]interface{}{
+		"status":     "switching",
+		"from":       from,
+		"to":         to,
+		"request_id": reqID,
+	})
+}
+
+// resumeAfterSwitch restarts workers paused for a pool switch, unless
+// mining stopped meanwhile or the tariff has them paused
+func (s *Server) resumeAfterSwitch() {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+
+	if !s.isMining() || s.tariff.Current().Action == tariff.ActionPause {
+		return
+	}
+	s.manager.StartAll()
+	if job := s.stratum.GetCurrentJob(); job != nil {
+		s.manager.BroadcastJob(job)
+	}
+}
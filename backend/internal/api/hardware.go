@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+	"runtime"
+
+	"github.com/soloforge/backend/internal/miner"
+)
+
+// handleHardware describes the host for choosing a worker count: the CPU
+// with its hashing features, the backend that will hash, total RAM, the
+// temperature sensors thermal throttling reads and the mining devices
+func (s *Server) handleHardware(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	celsius, ok := s.thermo.ReadCelsius()
+	temperature := map[string]interface{}{
+		"available": ok,
+		"sensors":   s.thermo.Sensors(),
+	}
+	if ok {
+		temperature["celsius"] = celsius
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"os":           runtime.GOOS,
+		"arch":         runtime.GOARCH,
+		"cpu":          miner.DetectCPU(),
+		"memory_bytes": miner.HostMemory(),
+		"hash_backend": miner.SelectedHashBackend(),
+		"temperature":  temperature,
+		"devices":      miner.Devices(),
+		// Hyperthread siblings share a core's SHA units, so one worker
+		// per physical core gets nearly all the hashrate
+		"recommended_workers": miner.AutoWorkerCount(true),
+	})
+}
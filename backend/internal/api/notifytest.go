@@ -0,0 +1,152 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/soloforge/backend/internal/stats"
+)
+
+// testNotifications synthesize each event the server sends, with the same
+// type and shape as the real one plus "test": true, through the same
+// WebSocket, firehose and log delivery
+var testNotifications = map[string]func(s *Server){
+	"block_found": func(s *Server) {
+		s.firehose.Publish("block", map[string]interface{}{
+			"job_id":       "test",
+			"extranonce2":  "00000000",
+			"ntime":        fmt.Sprintf("%08x", time.Now().Unix()),
+			"nonce":        "00000000",
+			"version_bits": "",
+			"fast_path":    true,
+			"test":         true,
+		})
+		s.wsHub.BroadcastEvent("block_found", map[string]interface{}{
+			"worker":             "test",
+			"origin":             stats.ShareOriginLocal,
+			"device":             "",
+			"job_id":             "test",
+			"nonce":              "00000000",
+			"difficulty":         s.stats.GetEffort().NetworkDifficulty,
+			"network_difficulty": s.stats.GetEffort().NetworkDifficulty,
+			"pool":               s.stratum.ConnectedPool().Name,
+			"test":               true,
+		})
+		s.broadcastLog("🧪 [test] Block found notification", "var(--success)")
+	},
+	"rare_share": func(s *Server) {
+		s.wsHub.BroadcastEvent("rare_share", map[string]interface{}{
+			"worker": "test",
+			"job_id": "test",
+			"nonce":  "00000000",
+			"rank": stats.ShareRank{
+				Difficulty:       1e6,
+				Percentile:       99.95,
+				TotalShares:      10000,
+				SharesAtOrAbove:  5,
+				OneIn:            2000,
+				MedianDifficulty: 1000,
+				TimesMedian:      1000,
+				PreviousBest:     5e5,
+				Record:           true,
+				Rare:             true,
+			},
+			"test": true,
+		})
+		s.broadcastLog("🧪 [test] Rare share notification", "var(--success)")
+	},
+	"near_miss": func(s *Server) {
+		network := s.stats.GetEffort().NetworkDifficulty
+		event := map[string]interface{}{
+			"worker":             "test",
+			"origin":             stats.ShareOriginLocal,
+			"device":             "",
+			"job_id":             "test",
+			"nonce":              "00000000",
+			"difficulty":         network * s.cfg.GetNearMissPercent() / 100,
+			"network_difficulty": network,
+			"percent":            s.cfg.GetNearMissPercent(),
+			"threshold_percent":  s.cfg.GetNearMissPercent(),
+			"test":               true,
+		}
+		s.wsHub.BroadcastEvent("near_miss", event)
+		s.firehose.Publish("near_miss", event)
+		s.broadcastLog("🧪 [test] Near miss notification", "var(--success)")
+	},
+	"share_result": func(s *Server) {
+		s.broadcastShareResult(map[string]interface{}{
+			"job_id":     "test",
+			"nonce":      "00000000",
+			"pool":       s.stratum.CurrentPool().Name,
+			"status":     stats.ShareStatusAccepted,
+			"latency_ms": 0,
+			"test":       true,
+		})
+	},
+	"pool_switch": func(s *Server) {
+		pool := s.stratum.CurrentPool()
+		s.wsHub.BroadcastEvent("pool_switch", map[string]interface{}{
+			"from":   pool,
+			"to":     pool,
+			"reason": "test",
+			"test":   true,
+		})
+		s.broadcastLog(fmt.Sprintf("🧪 [test] Pool switch notification: %s → %s", pool.Name, pool.Name), "var(--warning)")
+	},
+	"no_job_watchdog": func(s *Server) {
+		s.wsHub.BroadcastEvent("no_job_watchdog", map[string]interface{}{
+			"pool":           s.stratum.CurrentPool().Name,
+			"silent_seconds": 0,
+			"test":           true,
+		})
+		s.broadcastLog("🧪 [test] No job watchdog notification", "var(--warning)")
+	},
+	"digest": func(s *Server) {
+		s.wsHub.BroadcastEvent("digest", map[string]interface{}{
+			"period": "daily",
+			"test":   true,
+		})
+		s.broadcastLog("🧪 [test] Digest notification", "var(--info)")
+	},
+}
+
+// handleNotificationTest sends a synthetic event, or all of them with
+// event=all, so delivery can be checked before a real one matters:
+// POST /api/notifications/test?event=block_found
+func (s *Server) handleNotificationTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	names := make([]string, 0, len(testNotifications))
+	for name := range testNotifications {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	event := r.URL.Query().Get("event")
+	sent := names
+	if event != "all" {
+		if _, ok := testNotifications[event]; !ok {
+			http.Error(w, fmt.Sprintf("Unknown event %q, expected all or one of %v", event, names), http.StatusBadRequest)
+			return
+		}
+		sent = []string{event}
+	}
+
+	reqID := requestID(r)
+	s.recordAction(reqID, "notification_test", r.RemoteAddr, fmt.Sprintf("%v", sent))
+	for _, name := range sent {
+		testNotifications[name](s)
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"sent":              sent,
+		"websocket_clients": s.wsHub.ClientCount(),
+		"firehose":          s.firehose.GetStatus(),
+		"request_id":        reqID,
+	})
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+
+	"github.com/soloforge/backend/internal/config"
+)
+
+// handleMiningPreset lists the mining intensity presets with the one the
+// config matches (GET) or applies one (POST {"preset": "eco"}), setting
+// worker count, CPU percent and temperature limit in one config update
+func (s *Server) handleMiningPreset(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		jsonResponse(w, map[string]interface{}{
+			"presets": config.MiningPresets(),
+			"current": s.cfg.CurrentPreset(runtime.NumCPU()),
+		})
+
+	case http.MethodPost:
+		var req struct {
+			Preset string `json:"preset"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		req.Preset = strings.ToLower(req.Preset)
+		updates, err := config.PresetUpdates(req.Preset, runtime.NumCPU())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		s.sessionMu.Lock()
+		defer s.sessionMu.Unlock()
+
+		s.cfg.Update(updates)
+		s.applyConfigUpdates(updates)
+
+		// Resize running workers now, stopped ones when mining starts
+		workers := s.configuredWorkerCount()
+		if s.isMining() {
+			s.manager.SetWorkerCount(workers)
+		} else {
+			s.presetWorkers = true
+		}
+
+		detail := fmt.Sprintf("preset=%s workers=%d cpu_percent=%d max_temp_celsius=%g",
+			req.Preset, workers, s.cfg.GetMaxCPUPercent(), s.cfg.GetMaxTempCelsius())
+		s.recordAction(requestID(r), "preset", r.RemoteAddr, detail)
+		s.broadcastLog(fmt.Sprintf("🎚️ Mining preset %s: %d workers at %d%% CPU", req.Preset, workers, s.cfg.GetMaxCPUPercent()), "var(--info)")
+
+		jsonResponse(w, map[string]interface{}{
+			"status":           "applied",
+			"preset":           s.cfg.CurrentPreset(runtime.NumCPU()),
+			"workers":          workers,
+			"cpu_percent":      s.cfg.GetMaxCPUPercent(),
+			"max_temp_celsius": s.cfg.GetMaxTempCelsius(),
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/soloforge/backend/internal/config"
+	"github.com/soloforge/backend/internal/schedule"
+)
+
+// configureSchedule gives configured rules without an ID one and pushes
+// the rules to the scheduler, re-evaluating them at once when it runs
+func (s *Server) configureSchedule() {
+	configured := s.cfg.GetMiningSchedule()
+	rules := make([]schedule.Rule, 0, len(configured))
+	assigned := false
+	for i, r := range configured {
+		if r.ID == "" {
+			configured[i].ID = newRequestID()
+			assigned = true
+		}
+		rules = append(rules, schedule.Rule{
+			ID:    configured[i].ID,
+			Start: r.Start,
+			End:   r.End,
+			Days:  r.Days,
+		})
+	}
+	if assigned {
+		s.cfg.SetMiningSchedule(configured)
+	}
+
+	s.schedule.SetRules(rules)
+	if s.running {
+		go s.schedule.Evaluate(time.Now())
+	}
+}
+
+// applySchedule starts or stops mining as the schedule turns. Starting or
+// stopping by hand in between holds until the next turn.
+func (s *Server) applySchedule(d schedule.Decision) {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+
+	s.wsHub.BroadcastEvent("schedule", d)
+	if d.Mine == s.isMining() {
+		return
+	}
+
+	reqID := newRequestID()
+	s.stratum.SetCorrelationID(reqID)
+	if !d.Mine {
+		s.recordAction(reqID, "stop", "schedule", "")
+		s.stopSession()
+		s.broadcastLog(fmt.Sprintf("⏰ Mining schedule: stopped until %s", d.NextChange.Format("Mon 15:04")), "var(--info)")
+		return
+	}
+
+	s.recordAction(reqID, "start", "schedule", "rule="+d.Rule)
+	if !s.stratum.IsConnected() {
+		err := s.configurePools("")
+		if err == nil {
+			err = s.connectSession()
+		}
+		if err != nil {
+			log.Printf("Scheduled start failed: %v", err)
+			s.broadcastLog(fmt.Sprintf("⚠️ Mining schedule: could not start, %v", err), "var(--error)")
+			return
+		}
+	}
+	s.startSession(0, 0)
+	s.broadcastLog(fmt.Sprintf("⏰ Mining schedule: started until %s", d.NextChange.Format("Mon 15:04")), "var(--success)")
+}
+
+// handleSchedule lists the mining schedule with whether it says to mine
+// now (GET) or adds a rule (POST {"start", "end", "days"})
+func (s *Server) handleSchedule(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		rules := s.schedule.GetRules()
+		now := time.Now()
+		active, mine := schedule.Active(rules, now)
+
+		status := map[string]interface{}{
+			"rules":  rules,
+			"mine":   mine && len(rules) > 0,
+			"rule":   active.ID,
+			"mining": s.isMining(),
+		}
+		if next := schedule.NextChange(rules, now); !next.IsZero() {
+			status["next_change"] = next
+		}
+		jsonResponse(w, status)
+
+	case http.MethodPost:
+		rule, ok := decodeScheduleRule(w, r)
+		if !ok {
+			return
+		}
+		rule.ID = newRequestID()
+
+		s.cfg.SetMiningSchedule(append(s.cfg.GetMiningSchedule(), rule))
+		s.configureSchedule()
+		s.recordAction(requestID(r), "schedule_add", r.RemoteAddr, describeScheduleRule(rule))
+		jsonResponse(w, rule)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleScheduleRule replaces (PUT {"start", "end", "days"}) or removes
+// (DELETE) the rule at /api/schedule/{id}
+func (s *Server) handleScheduleRule(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(r.URL.Path[len("/api/schedule/"):], "/")
+	rules := s.cfg.GetMiningSchedule()
+	index := -1
+	for i, rule := range rules {
+		if rule.ID == id {
+			index = i
+		}
+	}
+	if id == "" || index < 0 {
+		http.Error(w, "Schedule rule not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		rule, ok := decodeScheduleRule(w, r)
+		if !ok {
+			return
+		}
+		rule.ID = id
+		rules[index] = rule
+
+		s.cfg.SetMiningSchedule(rules)
+		s.configureSchedule()
+		s.recordAction(requestID(r), "schedule_update", r.RemoteAddr, describeScheduleRule(rule))
+		jsonResponse(w, rule)
+
+	case http.MethodDelete:
+		removed := rules[index]
+		s.cfg.SetMiningSchedule(append(rules[:index], rules[index+1:]...))
+		s.configureSchedule()
+		s.recordAction(requestID(r), "schedule_delete", r.RemoteAddr, describeScheduleRule(removed))
+		jsonResponse(w, map[string]string{"status": "deleted"})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// decodeScheduleRule reads and validates a rule from the request body,
+// answering 400 when it is invalid
+func decodeScheduleRule(w http.ResponseWriter, r *http.Request) (config.ScheduleRule, bool) {
+	var rule config.ScheduleRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return rule, false
+	}
+
+	err := schedule.Rule{Start: rule.Start, End: rule.End, Days: rule.Days}.Validate()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return rule, false
+	}
+	return rule, true
+}
+
+// describeScheduleRule formats a rule for the audit log
+func describeScheduleRule(rule config.ScheduleRule) string {
+	days := "every day"
+	if len(rule.Days) > 0 {
+		days = strings.Join(rule.Days, ",")
+	}
+	return fmt.Sprintf("%s %s-%s %s", rule.ID, rule.Start, rule.End, days)
+}
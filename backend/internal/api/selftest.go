@@ -0,0 +1,40 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/soloforge/backend/internal/miner"
+)
+
+// handleSelfTest runs the miner's known-block self-test and reports each
+// step, so a user can tell hashing and header building are correct
+// before trusting a long run to them
+func (s *Server) handleSelfTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	steps := miner.SelfTest()
+	var failed []string
+	for _, step := range steps {
+		if !step.Pass {
+			failed = append(failed, step.Name)
+		}
+	}
+
+	detail := fmt.Sprintf("%d/%d passed", len(steps)-len(failed), len(steps))
+	s.recordAction(requestID(r), "selftest", r.RemoteAddr, detail)
+	if len(failed) == 0 {
+		s.broadcastLog(fmt.Sprintf("✅ Self-test passed (%d steps)", len(steps)), "var(--success)")
+	} else {
+		s.broadcastLog(fmt.Sprintf("❌ Self-test failed: %s", strings.Join(failed, ", ")), "var(--error)")
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"passed": len(failed) == 0,
+		"steps":  steps,
+	})
+}
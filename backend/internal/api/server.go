@@ -2,26 +2,133 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"runtime"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/soloforge/backend/internal/bench"
 	"github.com/soloforge/backend/internal/config"
+	"github.com/soloforge/backend/internal/firehose"
+	"github.com/soloforge/backend/internal/importer"
 	"github.com/soloforge/backend/internal/miner"
+	"github.com/soloforge/backend/internal/power"
+	"github.com/soloforge/backend/internal/proxy"
+	"github.com/soloforge/backend/internal/schedule"
 	"github.com/soloforge/backend/internal/stats"
 	"github.com/soloforge/backend/internal/stratum"
+	"github.com/soloforge/backend/internal/stratum/mockpool"
+	"github.com/soloforge/backend/internal/tariff"
+	"github.com/soloforge/backend/internal/timesync"
+	"github.com/soloforge/backend/internal/version"
 )
 
+// jobGapThreshold is how long without a new job counts as an incident
+const jobGapThreshold = 2 * time.Minute
+
+// maxImportSize bounds uploaded share logs
+const maxImportSize = 64 << 20
+
+// suggestMinInterval limits how often a new difficulty is suggested
+const suggestMinInterval = time.Minute
+
+// clockSkewThreshold is the clock offset from NTP time worth an alert
+const clockSkewThreshold = 30 * time.Second
+
+// maxBenchmarkPools bounds the pools one pool benchmark connects to
+const maxBenchmarkPools = 16
+
+// switchDrainTimeout bounds how long a pool switch waits for in-flight
+// submits to be answered before closing the connection
+const switchDrainTimeout = 5 * time.Second
+
+// switchResumeTimeout bounds how long paused workers wait for the new pool
+const switchResumeTimeout = time.Minute
+
 // Server represents the HTTP/WebSocket server
 type Server struct {
+	mu sync.Mutex
+
 	cfg      *config.Config
 	stratum  *stratum.Client
 	manager  *miner.Manager
+	proxy    *proxy.Server
+	mockPool *mockpool.Pool
+	firehose *firehose.Sink
 	stats    *stats.Collector
+	power    *power.Meter
+	thermo   *power.Thermometer
+	tariff   *tariff.Scheduler
+	schedule *schedule.Scheduler
+	clock    *timesync.Checker
 	wsHub    *WSHub
 	mux      *http.ServeMux
 	running  bool
 	shutdown chan struct{}
+
+	// Mining session state, drives automatic reconnection
+	mining       bool
+	reconnecting bool
+	lastJobAt    time.Time
+
+	// Job already reported stale, so each job alerts once
+	staleJobID string
+
+	// Set while the hash check reports unstable hashing, so it alerts once
+	hashCheckAlerted bool
+
+	// Set while job latency is over budget, so it alerts once
+	jobLatencyAlerted bool
+
+	// Set while the clock is off from NTP time, so it alerts once
+	clockSkewAlerted bool
+
+	// Solutions already sent on the fast path, keyed by job ID and nonce,
+	// so the share callback only does the bookkeeping
+	fastSubmitted map[string]bool
+
+	// Local shares awaiting the pool's verdict, keyed by job ID and nonce
+	workerShares map[string]pendingWorkerShare
+
+	// Last share difficulty suggested on the current connection
+	suggestedDifficulty float64
+	suggestedAt         time.Time
+
+	// Set when the pool asked us to reconnect, or an operator switched
+	// pools through /api/admin/failover
+	instructedReconnect bool
+	manualSwitch        bool
+	reconnectDelay      time.Duration
+
+	// Set while a graceful pool switch is draining and reconnecting
+	switching bool
+
+	// Serializes start/stop so session overrides apply atomically
+	sessionMu         sync.Mutex
+	sessionCPUPercent int
+
+	// Set by a preset applied while stopped, so the next start resizes
+	// the existing workers to num_workers
+	presetWorkers bool
+
+	// One benchmark at a time, the last result is kept for GET
+	benchMu       sync.Mutex
+	lastBenchmark *bench.Result
+	lastHashrate  *bench.HashrateResult
+
+	// Recent /api/stats snapshots, oldest first
+	snapshotMu sync.Mutex
+	snapshots  []*statsSnapshot
+
+	// Generated stats exports, by ID
+	exportMu sync.Mutex
+	exports  map[string]*exportFile
 }
 
 // NewServer creates a new API server
@@ -30,13 +137,48 @@ func NewServer(cfg *config.Config, stratumClient *stratum.Client, manager *miner
 		cfg:      cfg,
 		stratum:  stratumClient,
 		manager:  manager,
+		proxy:    proxy.NewServer(stratumClient),
+		mockPool: mockpool.New(mockpool.DefaultOptions()),
+		firehose: firehose.NewSink(),
 		stats:    statsCollector,
+		power:    power.NewMeter(),
+		thermo:   power.NewThermometer(),
+		tariff:   tariff.NewScheduler(),
+		schedule: schedule.NewScheduler(),
+		clock:    timesync.NewChecker(),
 		wsHub:    NewWSHub(),
 		mux:      http.NewServeMux(),
 		shutdown: make(chan struct{}),
+
+		fastSubmitted: make(map[string]bool),
+		workerShares:  make(map[string]pendingWorkerShare),
+		exports:       make(map[string]*exportFile),
 	}
 
 	s.setupRoutes()
+	s.stats.SetShareSampling(cfg.GetShareSampleThreshold(), cfg.GetShareSampleOneIn())
+	if err := s.firehose.Configure(cfg.GetFirehoseURL()); err != nil {
+		log.Printf("Firehose disabled: %v", err)
+	}
+	s.manager.SetShareCallback(s.handleShare)
+	s.manager.SetBlockCallback(s.handleBlock)
+	s.manager.SetErrorCallback(s.handleWorkerError)
+	s.proxy.SetShareCallback(s.handleProxyShare)
+	s.stratum.SetJobCallback(s.handleJob)
+	s.stratum.SetSubmitResultCallback(s.handleSubmitResult)
+	s.stratum.SetQueuedSubmitCallback(s.handleQueuedSubmit)
+	s.stratum.SetNoJobCallback(s.handleNoJob)
+	s.stratum.SetPoolSwitchCallback(s.handlePoolSwitch)
+	s.stratum.SetDisconnectedCallback(s.handleDisconnect)
+	s.stratum.SetExtranonceCallback(s.handleExtranonce)
+	s.stratum.SetReconnectCallback(s.handlePoolReconnect)
+	s.stratum.SetShowMessageCallback(s.handlePoolMessage)
+	s.stratum.SetVersionMaskCallback(s.manager.SetVersionMask)
+	s.stratum.SetDifficultyCallback(s.handleDifficulty)
+	s.tariff.SetDecisionCallback(s.applyTariffDecision)
+	s.schedule.SetChangeCallback(s.applySchedule)
+	s.configureSchedule()
+	s.clock.SetResultCallback(s.handleClockCheck)
 	return s
 }
 
@@ -44,14 +186,52 @@ func NewServer(cfg *config.Config, stratumClient *stratum.Client, manager *miner
 func (s *Server) setupRoutes() {
 	// API routes
 	s.mux.HandleFunc("/api/status", s.handleStatus)
+	s.mux.HandleFunc("/api/version", s.handleVersion)
+	s.mux.HandleFunc("/api/system", s.handleSystem)
+	s.mux.HandleFunc("/api/benchmark", s.handleBenchmark)
 	s.mux.HandleFunc("/api/stats", s.handleStats)
 	s.mux.HandleFunc("/api/history", s.handleHistory)
+	s.mux.HandleFunc("/api/history/import", s.handleHistoryImport)
+	s.mux.HandleFunc("/api/export", s.handleExports)
+	s.mux.HandleFunc("/api/export/", s.handleExportByID)
 	s.mux.HandleFunc("/api/sessions", s.handleSessions)
+	s.mux.HandleFunc("/api/activity", s.handleActivity)
+	s.mux.HandleFunc("/api/digests", s.handleDigests)
+	s.mux.HandleFunc("/api/network", s.handleNetwork)
 	s.mux.HandleFunc("/api/workers", s.handleWorkers)
 	s.mux.HandleFunc("/api/workers/", s.handleWorkerByID)
+	s.mux.HandleFunc("/api/devices", s.handleDevices)
 	s.mux.HandleFunc("/api/config", s.handleConfig)
+	s.mux.HandleFunc("/api/config/preview", s.handleConfigPreview)
 	s.mux.HandleFunc("/api/mining/start", s.handleMiningStart)
 	s.mux.HandleFunc("/api/mining/stop", s.handleMiningStop)
+	s.mux.HandleFunc("/api/mining/preset", s.handleMiningPreset)
+	s.mux.HandleFunc("/api/admin/failover", s.handleAdminFailover)
+	s.mux.HandleFunc("/api/pool/switch", s.handlePoolSwitchRequest)
+	s.mux.HandleFunc("/api/pools", s.handlePools)
+	s.mux.HandleFunc("/api/pools/", s.handlePoolByName)
+	s.mux.HandleFunc("/api/pools/benchmark", s.handlePoolBenchmark)
+	s.mux.HandleFunc("/api/candidates", s.handleCandidates)
+	s.mux.HandleFunc("/api/candidates/", s.handleCandidateByID)
+	s.mux.HandleFunc("/api/proxy", s.handleProxy)
+	s.mux.HandleFunc("/api/messages", s.handleMessages)
+	s.mux.HandleFunc("/api/stratum/trace", s.handleStratumTrace)
+	s.mux.HandleFunc("/api/stratum/stats", s.handleStratumStats)
+	s.mux.HandleFunc("/api/stratum/jobstats", s.handleStratumJobStats)
+	s.mux.HandleFunc("/api/audit", s.handleAudit)
+	s.mux.HandleFunc("/api/notifications/test", s.handleNotificationTest)
+	s.mux.HandleFunc("/api/selftest", s.handleSelfTest)
+	s.mux.HandleFunc("/api/hardware", s.handleHardware)
+	s.mux.HandleFunc("/api/tariff", s.handleTariff)
+	s.mux.HandleFunc("/api/tariff/override", s.handleTariffOverride)
+	s.mux.HandleFunc("/api/schedule", s.handleSchedule)
+	s.mux.HandleFunc("/api/schedule/", s.handleScheduleRule)
+
+	// Getwork bridge for legacy miners
+	getwork := s.getworkHandler()
+	s.mux.Handle("/getwork", getwork)
+	s.mux.Handle("/getwork/longpoll", getwork)
+	s.setupSoakRoutes()
 
 	// WebSocket
 	s.mux.HandleFunc("/ws", s.wsHub.HandleWebSocket)
@@ -59,7 +239,7 @@ func (s *Server) setupRoutes() {
 
 // GetHandler returns the HTTP handler with CORS
 func (s *Server) GetHandler() http.Handler {
-	return corsMiddleware(s.mux)
+	return corsMiddleware(requestIDMiddleware(s.mux))
 }
 
 // GetWSHub returns the WebSocket hub
@@ -67,9 +247,11 @@ func (s *Server) GetWSHub() *WSHub {
 	return s.wsHub
 }
 
-// StartStatsLoop starts broadcasting stats periodically
+// StartStatsLoop starts broadcasting stats periodically and following
+// the mining schedule
 func (s *Server) StartStatsLoop() {
 	s.running = true
+	s.schedule.Start()
 	go func() {
 		ticker := time.NewTicker(1 * time.Second)
 		defer ticker.Stop()
@@ -85,6 +267,14 @@ func (s *Server) StartStatsLoop() {
 
 				// Update hash count in stats
 				s.stats.UpdateHashes(s.manager.GetTotalHashCount())
+				s.manager.SampleHashrates()
+				s.maybeSuggestDifficulty()
+				s.checkJobExpiry()
+				s.checkDigests()
+				s.checkHashes()
+				s.checkJobLatency()
+				s.checkTemperature()
+				s.checkAutoscale()
 
 				// Broadcast stats
 				statsData := s.buildStatsPayload()
@@ -97,312 +287,1968 @@ func (s *Server) StartStatsLoop() {
 // Stop stops the stats loop
 func (s *Server) Stop() {
 	s.running = false
+	s.schedule.Stop()
 	close(s.shutdown)
+	s.removeExports()
 }
 
-// buildStatsPayload builds the stats payload for broadcasting
-func (s *Server) buildStatsPayload() map[string]interface{} {
-	basicStats := s.stats.GetStats()
+// handleJob hands new work to the workers and tracks job gaps
+func (s *Server) handleJob(job *stratum.Job) {
+	now := time.Now()
+	s.mu.Lock()
+	gap := now.Sub(s.lastJobAt)
+	s.lastJobAt = now
+	s.staleJobID = ""
+	s.mu.Unlock()
+
+	if gap > jobGapThreshold {
+		s.stats.RecordPoolJobGap(s.stratum.ConnectedPool().Name, gap)
+	}
 
-	// Add worker-specific data
-	workers := s.manager.GetAllWorkers()
-	workerStats := make([]map[string]interface{}, 0, len(workers))
+	s.stats.SetNetworkDifficulty(miner.NetworkDifficulty(job.NBits))
+	s.manager.BroadcastJob(job)
+	s.proxy.BroadcastJob(job)
 
-	for _, w := range workers {
-		workerStats = append(workerStats, map[string]interface{}{
-			"id":        w.ID,
-			"name":      w.Name,
-			"running":   w.IsRunning(),
-			"hashrate":  w.GetHashrate(),
-			"hashCount": w.GetHashCount(),
-		})
-	}
+	s.wsHub.BroadcastEvent("job", map[string]interface{}{
+		"job_id":     job.ID,
+		"clean_jobs": job.CleanJobs,
+	})
+}
 
-	return map[string]interface{}{
-		"hashrate":        s.manager.GetTotalHashrate(),
-		"total_hashes":    basicStats["total_hashes"],
-		"total_shares":    basicStats["total_shares"],
-		"accepted_shares": basicStats["accepted_shares"],
-		"best_difficulty": basicStats["best_difficulty"],
-		"uptime_seconds":  basicStats["uptime_seconds"],
-		"workers":         workerStats,
-		"connected":       s.stratum.IsConnected(),
-		"authorized":      s.stratum.IsAuthorized(),
-	}
+// handleDifficulty hands a new pool share difficulty to the workers and
+// the proxied miners
+func (s *Server) handleDifficulty(difficulty float64) {
+	s.manager.SetShareDifficulty(difficulty)
+	s.proxy.SetDifficulty(difficulty)
 }
 
-// handleStatus returns the miner status
-func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+// handleShare records a share found by a worker and submits it
+func (s *Server) handleShare(workerID int, epoch uint64, jobID, extranonce2, ntime, nonce, versionBits string, difficulty float64, block bool) {
+	workerName := ""
+	if worker := s.manager.GetWorker(workerID); worker != nil {
+		workerName = worker.Name
 	}
 
-	status := map[string]interface{}{
-		"running":      s.manager.WorkerCount() > 0,
-		"connected":    s.stratum.IsConnected(),
-		"authorized":   s.stratum.IsAuthorized(),
-		"worker_count": s.manager.WorkerCount(),
-		"pool_url":     s.cfg.GetPoolURL(),
-		"pool_port":    s.cfg.GetPoolPort(),
+	if err := s.submitShare(workerID, workerName, "", epoch, jobID, extranonce2, ntime, nonce, versionBits, difficulty, block); err != nil {
+		log.Printf("Share submit failed: %v", err)
 	}
+}
 
-	jsonResponse(w, status)
+// handleWorkerError counts a worker error and reports it to clients
+func (s *Server) handleWorkerError(e miner.WorkerError) {
+	s.stats.RecordWorkerError(e.Kind)
+	s.wsHub.BroadcastEvent("worker_error", e)
+	if e.Kind == miner.WorkerErrorMalformedJob {
+		s.broadcastLog(fmt.Sprintf("⚠️ Worker %d skipped a malformed job: %s", e.WorkerID, e.Error), "var(--warning)")
+	}
 }
 
-// handleStats returns mining statistics
-func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// handleBlock submits a block solution from the worker's goroutine before
+// the submitter hands it to the share callback to record
+func (s *Server) handleBlock(epoch uint64, jobID, extranonce2, ntime, nonce, versionBits string) {
+	err := s.stratum.SubmitBlock(epoch, jobID, extranonce2, ntime, nonce, versionBits)
+	s.firehose.Publish("block", map[string]interface{}{
+		"job_id":       jobID,
+		"extranonce2":  extranonce2,
+		"ntime":        ntime,
+		"nonce":        nonce,
+		"version_bits": versionBits,
+		"fast_path":    err == nil,
+	})
+	if err != nil {
+		// The regular submit path retries and reports the outcome
+		log.Printf("Block fast path failed: %v", err)
 		return
 	}
 
-	jsonResponse(w, s.buildStatsPayload())
+	s.mu.Lock()
+	s.fastSubmitted[jobID+":"+nonce] = true
+	s.mu.Unlock()
 }
 
-// handleHistory returns share/block history
-func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+// handleProxyShare records and submits a share from a miner connected to
+// the Stratum proxy. Proxied miners have no local worker ID, the device
+// address tells them apart. The proxy doesn't hash shares, so whether one
+// solves a block is checked on its rebuilt header.
+func (s *Server) handleProxyShare(worker, device string, epoch uint64, jobID, extranonce2, ntime, nonce, versionBits string, difficulty float64) error {
+	block := false
+	if header, _, job, err := s.shareHeader(epoch, jobID, extranonce2, ntime, nonce, versionBits); err == nil {
+		block = miner.MeetsNetworkTarget(header, job.NBits)
 	}
+	return s.submitShare(0, worker, device, epoch, jobID, extranonce2, ntime, nonce, versionBits, difficulty, block)
+}
 
-	limit := 100
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if parsed, err := strconv.Atoi(l); err == nil {
-			limit = parsed
+// submitShare records a share and submits it, unless it was mined for an
+// extranonce1 the pool no longer recognises. device is set for shares from
+// the Stratum proxy, block for shares meeting the network target.
+func (s *Server) submitShare(workerID int, workerName, device string, epoch uint64, jobID, extranonce2, ntime, nonce, versionBits string, difficulty float64, block bool) error {
+	var rank stats.ShareRank
+	if block {
+		rank = s.stats.AddBlockSolution(workerID, workerName, device, jobID, nonce, difficulty)
+	} else {
+		rank = s.stats.AddShare(workerID, workerName, device, jobID, nonce, difficulty)
+	}
+	origin := stats.ShareOriginLocal
+	if device != "" {
+		origin = stats.ShareOriginProxy
+	}
+	s.firehose.Publish("share", map[string]interface{}{
+		"worker_id":  workerID,
+		"worker":     workerName,
+		"origin":     origin,
+		"device":     device,
+		"job_id":     jobID,
+		"nonce":      nonce,
+		"difficulty": difficulty,
+		"block":      block,
+	})
+	if block {
+		s.notifyBlockFound(workerName, origin, device, jobID, nonce, difficulty)
+	} else {
+		s.checkNearMiss(workerName, origin, device, jobID, nonce, difficulty)
+		if rank.Rare {
+			s.notifyRareShare(workerName, jobID, nonce, rank)
 		}
 	}
+	s.recordCandidate(workerName, epoch, jobID, extranonce2, ntime, nonce, versionBits, block)
+	if workerID > 0 {
+		s.trackWorkerShare(workerID, jobID, nonce)
+	}
 
-	history := map[string]interface{}{
-		"shares": s.stats.GetShareHistory(limit),
-		"blocks": s.stats.GetBlockHistory(limit),
+	key := jobID + ":" + nonce
+	s.mu.Lock()
+	sent := s.fastSubmitted[key]
+	delete(s.fastSubmitted, key)
+	s.mu.Unlock()
+	if sent {
+		return nil
 	}
 
-	jsonResponse(w, history)
+	err := s.stratum.SubmitForEpoch(epoch, s.cfg.GetStratumUsername(), jobID, extranonce2, ntime, nonce, versionBits)
+	if errors.Is(err, stratum.ErrStaleSession) {
+		s.stats.RecordDroppedShare(jobID, nonce)
+		s.creditWorkerShare(jobID, nonce, stats.ShareStatusDropped)
+		s.broadcastShareResult(map[string]interface{}{
+			"job_id": jobID,
+			"nonce":  nonce,
+			"status": stats.ShareStatusDropped,
+			"reason": "previous session",
+		})
+	}
+	if errors.Is(err, stratum.ErrStaleJob) {
+		s.stats.RecordSubmitResult(jobID, nonce, stats.ShareStatusStale, stratum.RejectStale, "job expired")
+		s.creditWorkerShare(jobID, nonce, stats.ShareStatusStale)
+		s.broadcastShareResult(map[string]interface{}{
+			"job_id":   jobID,
+			"nonce":    nonce,
+			"status":   stats.ShareStatusStale,
+			"category": stratum.RejectStale,
+			"reason":   "job expired",
+		})
+	}
+	if errors.Is(err, stratum.ErrSubmitQueued) {
+		// Still pending, handleQueuedSubmit reports what becomes of it
+		return nil
+	}
+	return err
 }
 
-// handleSessions returns session history
-func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// notifyBlockFound tells clients a share solved a block, ahead of and
+// louder than any routine share event
+func (s *Server) notifyBlockFound(worker, origin, device, jobID, nonce string, difficulty float64) {
+	s.wsHub.BroadcastEvent("block_found", map[string]interface{}{
+		"worker":             worker,
+		"origin":             origin,
+		"device":             device,
+		"job_id":             jobID,
+		"nonce":              nonce,
+		"difficulty":         difficulty,
+		"network_difficulty": s.stats.GetEffort().NetworkDifficulty,
+		"pool":               s.stratum.ConnectedPool().Name,
+	})
+	log.Printf("BLOCK FOUND by %s: job %s nonce %s difficulty %.2f", worker, jobID, nonce, difficulty)
+	s.broadcastLog(fmt.Sprintf("🏆 BLOCK FOUND by %s! Difficulty %.2f on job %s, nonce %s", worker, difficulty, jobID, nonce), "var(--success)")
+}
+
+// checkNearMiss announces a share that reached near_miss_percent of the
+// network difficulty without solving a block, the closest a solo miner
+// usually gets
+func (s *Server) checkNearMiss(worker, origin, device, jobID, nonce string, difficulty float64) {
+	threshold := s.cfg.GetNearMissPercent()
+	network := s.stats.GetEffort().NetworkDifficulty
+	if threshold <= 0 || network <= 0 || difficulty < network*threshold/100 {
 		return
 	}
 
-	limit := 50
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if parsed, err := strconv.Atoi(l); err == nil {
-			limit = parsed
-		}
+	percent := difficulty / network * 100
+	event := map[string]interface{}{
+		"worker":             worker,
+		"origin":             origin,
+		"device":             device,
+		"job_id":             jobID,
+		"nonce":              nonce,
+		"difficulty":         difficulty,
+		"network_difficulty": network,
+		"percent":            percent,
+		"threshold_percent":  threshold,
 	}
-
-	sessions := s.stats.GetSessionHistory(limit)
-	jsonResponse(w, sessions)
+	s.wsHub.BroadcastEvent("near_miss", event)
+	s.firehose.Publish("near_miss", event)
+	s.broadcastLog(fmt.Sprintf("🎯 Near miss! %s found a share at %.2f%% of the network difficulty (%.2f of %.2f)", worker, percent, difficulty, network), "var(--success)")
 }
 
-// handleWorkers handles worker CRUD
-func (s *Server) handleWorkers(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		workers := s.manager.GetAllWorkers()
-		workerList := make([]map[string]interface{}, 0, len(workers))
-
-		for _, worker := range workers {
-			workerList = append(workerList, map[string]interface{}{
-				"id":        worker.ID,
-				"name":      worker.Name,
-				"running":   worker.IsRunning(),
-				"hashrate":  worker.GetHashrate(),
-				"hashCount": worker.GetHashCount(),
-			})
-		}
+// notifyRareShare tells clients about a share in the top 0.1% of every
+// share found, with where it ranks in the difficulty histogram
+func (s *Server) notifyRareShare(worker, jobID, nonce string, rank stats.ShareRank) {
+	s.wsHub.BroadcastEvent("rare_share", map[string]interface{}{
+		"worker": worker,
+		"job_id": jobID,
+		"nonce":  nonce,
+		"rank":   rank,
+	})
 
-		jsonResponse(w, workerList)
+	msg := fmt.Sprintf("🌟 Share %.2f from %s is in the top %.3f%% of %d shares (1 in %.0f, %.0fx the median)",
+		rank.Difficulty, worker, 100-rank.Percentile, rank.TotalShares, rank.OneIn, rank.TimesMedian)
+	if rank.Record {
+		msg += fmt.Sprintf(", a new record over %.2f", rank.PreviousBest)
+	}
+	s.broadcastLog(msg, "var(--success)")
+}
 
-	case http.MethodPost:
-		var req struct {
-			Name string `json:"name"`
-		}
+// shareHeader rebuilds the header and coinbase of a share from the recent
+// job it was mined on in the current session
+func (s *Server) shareHeader(epoch uint64, jobID, extranonce2, ntime, nonce, versionBits string) ([]byte, []byte, *stratum.Job, error) {
+	if epoch != s.stratum.SessionEpoch() {
+		return nil, nil, nil, stratum.ErrStaleSession
+	}
+	job := s.proxy.Job(jobID)
+	if job == nil {
+		return nil, nil, nil, fmt.Errorf("job %s no longer known", jobID)
+	}
+	version, err := miner.RolledVersion(job.Version, versionBits, s.stratum.GetVersionMask())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	header, coinbase, err := miner.BuildHeader(job, s.stratum.GetExtranonce1(), extranonce2, ntime, nonce, version)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return header, coinbase, job, nil
+}
 
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			req.Name = ""
-		}
+// checkJobExpiry alerts once per job that outlived the job expiry and,
+// if configured, drops the connection so the reconnect path fetches
+// fresh work
+func (s *Server) checkJobExpiry() {
+	if !s.isMining() || !s.stratum.IsJobStale() {
+		return
+	}
 
-		worker := s.manager.AddWorker(req.Name)
+	job := s.stratum.GetCurrentJob()
+	s.mu.Lock()
+	if job == nil || s.staleJobID == job.ID {
+		s.mu.Unlock()
+		return
+	}
+	s.staleJobID = job.ID
+	s.mu.Unlock()
 
-		// If we have a job, send it to the new worker
-		if job := s.stratum.GetCurrentJob(); job != nil {
-			worker.UpdateJob(job)
-		}
+	age := s.stratum.GetJobAge().Round(time.Second)
+	action := s.cfg.GetJobExpiryAction()
 
-		jsonResponse(w, map[string]interface{}{
-			"id":   worker.ID,
-			"name": worker.Name,
-		})
+	s.wsHub.BroadcastEvent("job_stale", map[string]interface{}{
+		"job_id":      job.ID,
+		"age_seconds": age.Seconds(),
+		"action":      action,
+	})
+	s.broadcastLog(fmt.Sprintf("⌛ No new job for %s, job %s is stale", age, job.ID), "var(--warning)")
 
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	if action == config.JobExpiryReconnect && s.stratum.DropStaleConnection() {
+		s.broadcastLog("🔄 Reconnecting for fresh work", "var(--info)")
 	}
 }
 
-// handleWorkerByID handles individual worker operations
-func (s *Server) handleWorkerByID(w http.ResponseWriter, r *http.Request) {
-	// Extract ID from path /api/workers/{id}
-	idStr := r.URL.Path[len("/api/workers/"):]
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		http.Error(w, "Invalid worker ID", http.StatusBadRequest)
-		return
-	}
+// checkHashes alerts when sampled hashes keep disagreeing with the
+// reference SHA-256 and, if configured, falls back to it
+func (s *Server) checkHashes() {
+	check := miner.GetHashCheck()
 
-	switch r.Method {
-	case http.MethodGet:
-		worker := s.manager.GetWorker(id)
-		if worker == nil {
-			http.Error(w, "Worker not found", http.StatusNotFound)
-			return
-		}
+	s.mu.Lock()
+	alerted := s.hashCheckAlerted
+	s.hashCheckAlerted = check.Unstable
+	s.mu.Unlock()
 
-		jsonResponse(w, map[string]interface{}{
-			"id":        worker.ID,
-			"name":      worker.Name,
-			"running":   worker.IsRunning(),
-			"hashrate":  worker.GetHashrate(),
-			"hashCount": worker.GetHashCount(),
-		})
+	if !check.Unstable || alerted {
+		return
+	}
 
-	case http.MethodDelete:
-		if s.manager.RemoveWorker(id) {
-			jsonResponse(w, map[string]string{"status": "deleted"})
-		} else {
-			http.Error(w, "Worker not found", http.StatusNotFound)
-		}
+	fallback := s.cfg.GetHashCheckFallback()
+	if fallback {
+		miner.SetSafeHashing(true)
+		check.SafeMode = true
+	}
 
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	s.wsHub.BroadcastEvent("hash_check", check)
+	s.broadcastLog(fmt.Sprintf("⚠️ %d of %d sampled hashes were wrong, hardware may be unstable or overclocked", check.Mismatches, check.Checked), "var(--error)")
+	if fallback {
+		s.broadcastLog("🛡️ Switched to the reference SHA-256 implementation", "var(--warning)")
 	}
 }
 
-// handleConfig handles configuration
-func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		jsonResponse(w, map[string]interface{}{
-			"pool_url":        s.cfg.GetPoolURL(),
-			"pool_port":       s.cfg.GetPoolPort(),
-			"wallet_address":  s.cfg.GetWalletAddress(),
-			"max_cpu_percent": s.cfg.GetMaxCPUPercent(),
-			"num_workers":     s.cfg.GetNumWorkers(),
-		})
+// configureClock applies the NTP server and ntime correction settings
+func (s *Server) configureClock() {
+	s.clock.SetServer(s.cfg.GetNTPServer())
+	s.manager.SetNTimeCorrection(s.cfg.GetNTimeCorrection(), s.clock.GetStatus().Offset())
+}
 
-	case http.MethodPut:
-		var updates map[string]interface{}
-		if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
-			http.Error(w, "Invalid JSON", http.StatusBadRequest)
-			return
-		}
+// handleClockCheck applies a measured clock offset to ntime rolling and
+// alerts once when the clock is off by more than clockSkewThreshold
+func (s *Server) handleClockCheck(st timesync.Status) {
+	s.manager.SetNTimeCorrection(s.cfg.GetNTimeCorrection(), st.Offset())
 
-		s.cfg.Update(updates)
+	if st.Error != "" {
+		log.Printf("Clock check against %s failed: %s", st.Server, st.Error)
+	}
 
-		// Apply CPU percent change immediately
-		if _, ok := updates["max_cpu_percent"]; ok {
-			s.manager.SetCPUPercent(s.cfg.GetMaxCPUPercent())
-		}
+	skewed := st.Synced && (st.Offset() > clockSkewThreshold || st.Offset() < -clockSkewThreshold)
 
-		jsonResponse(w, map[string]string{"status": "updated"})
+	s.mu.Lock()
+	alerted := s.clockSkewAlerted
+	s.clockSkewAlerted = skewed
+	s.mu.Unlock()
 
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	if !skewed || alerted {
+		return
+	}
+
+	s.wsHub.BroadcastEvent("clock_skew", st)
+	msg := fmt.Sprintf("⏰ Local clock is off by %.1fs from %s", st.OffsetMs/1000, st.Server)
+	if !s.cfg.GetNTimeCorrection() {
+		msg += ", enable ntime_correction to avoid time-too-new/old rejects"
 	}
+	s.broadcastLog(msg, "var(--warning)")
 }
 
-// handleMiningStart starts mining
-func (s *Server) handleMiningStart(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// checkJobLatency alerts once when the p95 time from mining.notify to
+// workers hashing the job goes over budget, as slow switches to clean
+// jobs waste work on stale ones
+func (s *Server) checkJobLatency() {
+	threshold := s.cfg.GetJobLatencyAlertMs()
+	latency := s.manager.GetJobLatency()
+	over := threshold > 0 && latency.Samples > 0 && latency.P95Ms > float64(threshold)
+
+	s.mu.Lock()
+	alerted := s.jobLatencyAlerted
+	s.jobLatencyAlerted = over
+	s.mu.Unlock()
+
+	if !over || alerted {
 		return
 	}
 
-	// Connect to pool if not connected
-	if !s.stratum.IsConnected() {
-		if err := s.stratum.Connect(); err != nil {
-			jsonResponse(w, map[string]interface{}{
-				"status": "error",
-				"error":  err.Error(),
-			})
-			return
-		}
+	s.wsHub.BroadcastEvent("job_latency", latency)
+	s.broadcastLog(fmt.Sprintf("🐌 Workers take %.0fms (p95) to start on new jobs, over the %dms budget", latency.P95Ms, threshold), "var(--warning)")
+}
 
-		// Subscribe
-		if err := s.stratum.Subscribe(); err != nil {
-			jsonResponse(w, map[string]interface{}{
-				"status": "error",
-				"error":  err.Error(),
-			})
-			return
-		}
+// checkTemperature feeds the CPU temperature to the manager's thermal
+// throttling and reports when it lowers or restores the CPU cap
+func (s *Server) checkTemperature() {
+	celsius, ok := s.thermo.ReadCelsius()
+	if !ok {
+		return
+	}
 
-		// Wait a bit for subscription response
-		time.Sleep(500 * time.Millisecond)
+	before := s.manager.GetThermalStatus().CapPercent
+	capPercent := s.manager.RegulateTemperature(celsius, s.cfg.GetMaxTempCelsius())
+	if capPercent == before {
+		return
+	}
 
-		// Authorize
-		wallet := s.cfg.GetWalletAddress()
-		if wallet == "" {
-			jsonResponse(w, map[string]interface{}{
-				"status": "error",
-				"error":  "No wallet address configured",
-			})
-			return
-		}
+	s.wsHub.BroadcastEvent("thermal", s.manager.GetThermalStatus())
+	switch {
+	case capPercent < before:
+		s.broadcastLog(fmt.Sprintf("🌡️ CPU at %.0f°C, mining capped at %d%% CPU", celsius, capPercent), "var(--warning)")
+	case capPercent == 100:
+		s.broadcastLog(fmt.Sprintf("🌡️ CPU cooled to %.0f°C, thermal cap lifted", celsius), "var(--success)")
+	default:
+		s.broadcastLog(fmt.Sprintf("🌡️ CPU cooled to %.0f°C, mining raised to %d%% CPU", celsius, capPercent), "var(--info)")
+	}
+}
 
-		if err := s.stratum.Authorize(wallet, "x"); err != nil {
-			jsonResponse(w, map[string]interface{}{
-				"status": "error",
-				"error":  err.Error(),
-			})
-			return
-		}
+// checkAutoscale feeds the host load average to the worker autoscaler
+// while mining and reports when it adds or removes a worker. A tick
+// during a session change is skipped rather than waiting on it.
+func (s *Server) checkAutoscale() {
+	if !s.cfg.GetAutoscaleEnabled() {
+		return
+	}
+	load, ok := power.ReadLoadAverage()
+	if !ok || !s.sessionMu.TryLock() {
+		return
+	}
+	defer s.sessionMu.Unlock()
+	if !s.isMining() {
+		return
+	}
 
-		// Wait for authorization
-		time.Sleep(500 * time.Millisecond)
+	before := s.manager.WorkerCount()
+	workers, changed := s.manager.Autoscale(load, s.cfg.GetAutoscaleMinWorkers(), s.cfg.GetAutoscaleMaxWorkers())
+	if !changed {
+		return
 	}
 
-	// Set stratum data to manager
-	s.manager.SetStratumData(s.stratum.GetExtranonce1(), s.stratum.GetExtranonce2Size())
+	s.wsHub.BroadcastEvent("autoscale", s.manager.GetAutoscaleStatus())
+	if s.manager.WorkerCount() > before {
+		s.broadcastLog(fmt.Sprintf("📈 Load %.2f leaves CPUs idle, scaled up to %d workers", load, workers), "var(--info)")
+	} else {
+		s.broadcastLog(fmt.Sprintf("📉 Load %.2f, other processes need the CPU, scaled down to %d workers", load, workers), "var(--warning)")
+	}
+}
 
-	// Add workers if none exist
-	if s.manager.WorkerCount() == 0 {
-		numWorkers := s.cfg.GetNumWorkers()
-		if numWorkers <= 0 {
-			numWorkers = 1
-		}
-		for i := 0; i < numWorkers; i++ {
-			s.manager.AddWorker("")
+// checkDigests emits the daily and weekly digests as they come due
+func (s *Server) checkDigests() {
+	for _, d := range s.stats.GenerateDueDigests() {
+		s.wsHub.BroadcastEvent("digest", d)
+
+		best := 0.0
+		if d.BestShare != nil {
+			best = d.BestShare.Difficulty
 		}
+		s.broadcastLog(fmt.Sprintf("📰 Digest (%s): best share %.2f, %d shares, luck %.6f%%, uptime %.0f%%",
+			d.Period, best, d.Shares, d.LuckPercent, d.UptimePercent), "var(--info)")
 	}
+}
 
-	// Start all workers
-	s.manager.StartAll()
+// handleExtranonce moves the workers onto a rotated extranonce1
+func (s *Server) handleExtranonce(extranonce1 string, extranonce2Size int) {
+	s.manager.UpdateExtranonce(extranonce1, extranonce2Size, s.stratum.SessionEpoch())
+	s.proxy.UpdateExtranonce()
+	s.broadcastLog(fmt.Sprintf("🔁 Pool rotated extranonce1 to %s", extranonce1), "var(--info)")
+}
 
-	// Send current job to workers
-	if job := s.stratum.GetCurrentJob(); job != nil {
-		s.manager.BroadcastJob(job)
+// handleSubmitResult records the pool's verdict on a share and notifies clients
+func (s *Server) handleSubmitResult(result *stratum.SubmitResult) {
+	status := stats.ShareStatusRejected
+	switch {
+	case result.Accepted:
+		status = stats.ShareStatusAccepted
+	case result.Stale:
+		status = stats.ShareStatusStale
 	}
 
-	jsonResponse(w, map[string]string{"status": "started"})
-}
+	s.stats.RecordSubmitResult(result.JobID, result.Nonce, status, result.Category, result.Reason)
+	s.stats.RecordPoolSubmit(result.Pool, status, result.Latency)
+	s.creditWorkerShare(result.JobID, result.Nonce, status)
+
+	event := map[string]interface{}{
+		"job_id":     result.JobID,
+		"nonce":      result.Nonce,
+		"pool":       result.Pool,
+		"status":     status,
+		"category":   result.Category,
+		"reason":     result.Reason,
+		"latency_ms": result.Latency.Milliseconds(),
+	}
+	s.firehose.Publish("share_result", event)
 
-// handleMiningStop stops mining
-func (s *Server) handleMiningStop(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	// Under load accepted shares only show up in the counters
+	if status == stats.ShareStatusAccepted && s.stats.IsSampling() {
 		return
 	}
+	s.wsHub.BroadcastEvent("share_result", event)
+}
 
+// handleQueuedSubmit records what became of a share found while the pool
+// was unreachable. The verdict on a resubmitted share arrives as a normal
+// submit result.
+func (s *Server) handleQueuedSubmit(jobID, nonce, outcome, reason string) {
+	switch outcome {
+	case stratum.QueuedResubmitted:
+		return
+	case stratum.QueuedStale:
+		s.stats.RecordSubmitResult(jobID, nonce, stats.ShareStatusStale, stratum.RejectStale, reason)
+		s.creditWorkerShare(jobID, nonce, stats.ShareStatusStale)
+		s.broadcastShareResult(map[string]interface{}{
+			"job_id":   jobID,
+			"nonce":    nonce,
+			"status":   stats.ShareStatusStale,
+			"category": stratum.RejectStale,
+			"reason":   reason,
+		})
+	default:
+		s.stats.RecordDroppedShare(jobID, nonce)
+		s.creditWorkerShare(jobID, nonce, stats.ShareStatusDropped)
+		s.broadcastShareResult(map[string]interface{}{
+			"job_id": jobID,
+			"nonce":  nonce,
+			"status": stats.ShareStatusDropped,
+			"reason": reason,
+		})
+	}
+}
+
+// broadcastShareResult notifies clients and the firehose of a share
+// verdict
+func (s *Server) broadcastShareResult(event map[string]interface{}) {
+	s.firehose.Publish("share_result", event)
+	s.wsHub.BroadcastEvent("share_result", event)
+}
+
+// handleNoJob notifies clients that the watchdog dropped a connection
+// that stopped sending jobs, the disconnect callback then reconnects
+func (s *Server) handleNoJob(pool stratum.Pool, silent time.Duration) {
+	s.wsHub.BroadcastEvent("no_job_watchdog", map[string]interface{}{
+		"pool":           pool.Name,
+		"silent_seconds": silent.Seconds(),
+	})
+	s.broadcastLog(fmt.Sprintf("🐕 No job from %s for %s, reconnecting", pool.Name, silent.Round(time.Second)), "var(--warning)")
+}
+
+// handleDisconnect starts reconnecting if the pool drops during a session
+func (s *Server) handleDisconnect(err error) {
+	log.Printf("Pool disconnected: %v", err)
+
+	s.mu.Lock()
+	instructed := s.instructedReconnect
+	manual := s.manualSwitch
+	s.instructedReconnect = false
+	s.manualSwitch = false
+	s.mu.Unlock()
+
+	// A reconnect the pool or an operator asked for isn't a service failure
+	if !instructed && !manual {
+		s.stats.RecordPoolDisconnect(s.stratum.ConnectedPool().Name)
+		s.broadcastLog("❌ Disconnected from pool", "var(--error)")
+	}
+
+	s.mu.Lock()
+	if !s.mining || s.reconnecting {
+		s.mu.Unlock()
+		return
+	}
+	s.reconnecting = true
+	s.mu.Unlock()
+
+	source := "auto"
+	switch {
+	case manual:
+		source = "manual"
+	case instructed:
+		source = "pool"
+	}
+	go s.reconnectLoop(source)
+}
+
+// reconnectLoop retries the pool handshake until it succeeds or mining
+// stops. Failover between pools happens inside the stratum client.
+func (s *Server) reconnectLoop(source string) {
+	defer func() {
+		s.mu.Lock()
+		s.reconnecting = false
+		s.mu.Unlock()
+	}()
+
+	s.mu.Lock()
+	delay := s.reconnectDelay
+	s.reconnectDelay = 0
+	s.mu.Unlock()
+
+	// Reconnects get their own ID so their protocol traffic can be traced
+	reqID := newRequestID()
+	s.stratum.SetCorrelationID(reqID)
+	s.recordAction(reqID, "reconnect", source, s.stratum.CurrentPool().Name)
+
+	for {
+		if delay <= 0 {
+			delay = 5 * time.Second
+		}
+
+		select {
+		case <-s.shutdown:
+			return
+		case <-time.After(delay):
+		}
+		delay = 0
+
+		if !s.isMining() {
+			return
+		}
+
+		if err := s.connectPool(); err != nil {
+			log.Printf("Reconnect failed: %v", err)
+			continue
+		}
+
+		pool := s.stratum.CurrentPool()
+		log.Printf("[%s] Reconnected to %s:%d", reqID, pool.URL, pool.Port)
+		s.broadcastLog(fmt.Sprintf("✅ Reconnected to %s:%d", pool.URL, pool.Port), "var(--success)")
+		return
+	}
+}
+
+// handlePoolReconnect prepares for a pool-requested client.reconnect, the
+// handshake is redone by the regular reconnect path
+func (s *Server) handlePoolReconnect(host string, port int, wait time.Duration) {
+	// Pools usually expect an immediate reconnect when no wait is given
+	if wait <= 0 {
+		wait = time.Second
+	}
+
+	s.mu.Lock()
+	s.instructedReconnect = true
+	s.reconnectDelay = wait
+	s.mu.Unlock()
+
+	s.broadcastLog(fmt.Sprintf("↪️ Pool requested reconnect to %s:%d", host, port), "var(--info)")
+}
+
+// handlePoolMessage stores and forwards a client.show_message notice
+func (s *Server) handlePoolMessage(message string) {
+	entry := s.stats.AddPoolMessage(s.stratum.ConnectedPool().Name, message)
+	s.wsHub.BroadcastEvent("pool_message", entry)
+	s.broadcastLog("📢 Pool: "+message, "var(--info)")
+}
+
+// recordAction logs an operator or automatic action under its
+// correlation ID and adds it to the audit trail
+func (s *Server) recordAction(reqID, action, source, detail string) {
+	log.Printf("[%s] %s (%s) %s", reqID, action, source, detail)
+	entry := s.stats.AddAudit(reqID, action, source, detail)
+	s.wsHub.BroadcastEvent("action", entry)
+}
+
+// isMining reports whether a mining session is active
+func (s *Server) isMining() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mining
+}
+
+// setMining records whether a mining session is active
+func (s *Server) setMining(mining bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mining = mining
+}
+
+// broadcastLog sends a log line to the dashboard console
+func (s *Server) broadcastLog(message, color string) {
+	s.wsHub.BroadcastEvent("log", map[string]interface{}{
+		"message": message,
+		"color":   color,
+	})
+}
+
+// targetCPUPercent returns the session CPU override or the configured value
+func (s *Server) targetCPUPercent() int {
+	s.mu.Lock()
+	override := s.sessionCPUPercent
+	s.mu.Unlock()
+
+	if override > 0 {
+		return override
+	}
+	return s.cfg.GetMaxCPUPercent()
+}
+
+// configuredWorkerCount resolves num_workers, 0 meaning one worker per CPU
+// or physical core
+func (s *Server) configuredWorkerCount() int {
+	if n := s.cfg.GetNumWorkers(); n > 0 {
+		return n
+	}
+	return miner.AutoWorkerCount(s.cfg.GetAutoWorkersPhysical())
+}
+
+// connectPool connects, subscribes and authorizes with the current pool,
+// then hands the new session to the workers
+func (s *Server) connectPool() error {
+	if s.cfg.GetWalletAddress() == "" {
+		return fmt.Errorf("no wallet address configured")
+	}
+
+	// Job gaps are measured from the start of each connection, and each
+	// connection starts at the pool's default difficulty
+	s.mu.Lock()
+	s.lastJobAt = time.Now()
+	s.suggestedDifficulty = 0
+	s.mu.Unlock()
+
+	if err := s.stratum.Connect(); err != nil {
+		return err
+	}
+
+	// Version rolling must be negotiated before subscribing
+	if err := s.stratum.Configure(); err != nil {
+		return err
+	}
+
+	if err := s.stratum.Subscribe(); err != nil {
+		return err
+	}
+
+	// Wait a bit for subscription response
+	time.Sleep(500 * time.Millisecond)
+
+	if err := s.stratum.Authorize(s.cfg.GetStratumUsername(), "x"); err != nil {
+		return err
+	}
+
+	// Wait for authorization
+	time.Sleep(500 * time.Millisecond)
+
+	// Running workers (on reconnect) must switch to the new extranonce1
+	s.manager.UpdateExtranonce(s.stratum.GetExtranonce1(), s.stratum.GetExtranonce2Size(), s.stratum.SessionEpoch())
+	if job := s.stratum.GetCurrentJob(); job != nil {
+		s.manager.BroadcastJob(job)
+	}
+
+	return nil
+}
+
+// maybeSuggestDifficulty suggests a share difficulty matching the local
+// hashrate once authorized, and again when the hashrate moves by more
+// than a factor of two
+func (s *Server) maybeSuggestDifficulty() {
+	interval := s.cfg.GetTargetShareSeconds()
+	hashrate := s.manager.GetTotalHashrate()
+	if interval <= 0 || hashrate <= 0 || !s.stratum.IsAuthorized() {
+		return
+	}
+
+	difficulty := hashrate * float64(interval) / miner.HashesPerDifficulty
+
+	s.mu.Lock()
+	last, lastAt := s.suggestedDifficulty, s.suggestedAt
+	if last > 0 && (time.Since(lastAt) < suggestMinInterval || (difficulty < last*2 && difficulty > last/2)) {
+		s.mu.Unlock()
+		return
+	}
+	s.suggestedDifficulty = difficulty
+	s.suggestedAt = time.Now()
+	s.mu.Unlock()
+
+	if err := s.stratum.SuggestDifficulty(difficulty); err != nil {
+		log.Printf("mining.suggest_difficulty failed: %v", err)
+		return
+	}
+	s.broadcastLog(fmt.Sprintf("🎯 Suggested share difficulty %.6g for %.0f H/s", difficulty, hashrate), "var(--info)")
+}
+
+// autoscaleStats returns the worker autoscaler's last reading, nil while
+// autoscaling is off
+func (s *Server) autoscaleStats() *miner.AutoscaleStatus {
+	if !s.cfg.GetAutoscaleEnabled() {
+		return nil
+	}
+	status := s.manager.GetAutoscaleStatus()
+	return &status
+}
+
+// thermalStats returns the thermal throttling status, nil without a
+// temperature sensor
+func (s *Server) thermalStats() *miner.ThermalStatus {
+	if !s.thermo.Available() {
+		return nil
+	}
+	status := s.manager.GetThermalStatus()
+	return &status
+}
+
+// buildStatsPayload builds the stats payload for broadcasting
+func (s *Server) buildStatsPayload() map[string]interface{} {
+	basicStats := s.stats.GetStats()
+
+	// Add worker-specific data
+	workers := s.manager.GetAllWorkers()
+	workerStats := make([]map[string]interface{}, 0, len(workers))
+
+	for _, w := range workers {
+		workerStats = append(workerStats, map[string]interface{}{
+			"id":        w.ID,
+			"name":      w.Name,
+			"running":   w.IsRunning(),
+			"hashrate":  w.GetHashrate(),
+			"hashrates": w.GetHashrates(),
+			"hashCount": w.GetHashCount(),
+			"shares":    w.GetShares(),
+		})
+	}
+
+	proxyHashrate := s.proxy.Hashrate()
+	hashrate := s.manager.GetTotalHashrate() + proxyHashrate
+
+	return map[string]interface{}{
+		"hashrate":          hashrate,
+		"hashrates":         s.manager.GetTotalHashrates(),
+		"proxy_hashrate":    proxyHashrate,
+		"proxy_miners":      len(s.proxy.Miners()),
+		"total_hashes":      basicStats["total_hashes"],
+		"total_shares":      basicStats["total_shares"],
+		"accepted_shares":   basicStats["accepted_shares"],
+		"rejected_shares":   basicStats["rejected_shares"],
+		"reject_categories": basicStats["reject_categories"],
+		"shares_by_origin":  basicStats["shares_by_origin"],
+		"stale_shares":      basicStats["stale_shares"],
+		"dropped_shares":    basicStats["dropped_shares"],
+		"best_difficulty":   basicStats["best_difficulty"],
+		"effort":            s.stats.GetEffort(),
+		"network_share":     s.stats.EstimateNetworkShare(hashrate),
+		"stratum":           s.stratum.GetConnectionStats(),
+		"job_latency":       s.manager.GetJobLatency(),
+		"thermal":           s.thermalStats(),
+		"share_sampling":    s.stats.GetShareSampling(),
+		"uptime_seconds":    basicStats["uptime_seconds"],
+		"workers":           workerStats,
+		"connected":         s.stratum.IsConnected(),
+		"authorized":        s.stratum.IsAuthorized(),
+	}
+}
+
+// handleStatus returns the miner status
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	status := map[string]interface{}{
+		"running":      s.manager.WorkerCount() > 0,
+		"connected":    s.stratum.IsConnected(),
+		"authorized":   s.stratum.IsAuthorized(),
+		"worker_count": s.manager.WorkerCount(),
+		"num_workers":  s.configuredWorkerCount(),
+		"auto_workers": s.cfg.GetNumWorkers() <= 0,
+		"pool_url":     s.cfg.GetPoolURL(),
+		"pool_port":    s.cfg.GetPoolPort(),
+		"active_pool":  s.stratum.CurrentPool(),
+		"version_mask": fmt.Sprintf("%08x", s.stratum.GetVersionMask()),
+		"job_age":      s.stratum.GetJobAge().Seconds(),
+		"job_stale":    s.stratum.IsJobStale(),
+		"session":      s.stratum.GetSession(),
+		"firehose":     s.firehose.GetStatus(),
+		"clock":        s.clock.GetStatus(),
+		"read_only":    s.stats.ReadOnly(),
+		"hash_backend": miner.ActiveHashBackend(),
+	}
+
+	jsonResponse(w, status)
+}
+
+// handleVersion returns build information and the selected hashing path
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"version":      version.Version,
+		"commit":       version.Commit,
+		"go_version":   version.GoVersion(),
+		"os":           runtime.GOOS,
+		"arch":         runtime.GOARCH,
+		"hash_backend": miner.SelectedHashBackend(),
+		"hash_check":   miner.GetHashCheck(),
+	})
+}
+
+// handleSystem returns host information and power efficiency
+func (s *Server) handleSystem(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"os":              runtime.GOOS,
+		"arch":            runtime.GOARCH,
+		"num_cpu":         runtime.NumCPU(),
+		"goroutines":      runtime.NumGoroutine(),
+		"hash_backend":    miner.SelectedHashBackend(),
+		"rapl_available":  s.power.HasRAPL(),
+		"thermal":         s.thermalStats(),
+		"max_cpu_percent": s.cfg.GetMaxCPUPercent(),
+		"cpu_usage":       s.manager.GetCPUUsage(),
+		"autoscale":       s.autoscaleStats(),
+		"share_results":   s.manager.GetResultStats(),
+		"worker_count":    s.manager.WorkerCount(),
+		"efficiency":      s.power.Estimate(s.cfg.GetPowerWatts(), s.manager.GetTotalHashrate()),
+	})
+}
+
+// handleBenchmark returns the last benchmark (GET) or runs a new one
+// (POST, optional {"workers", "rounds", "goroutines", "events", "clients",
+// "ticks", "interval_ms"}). With ?mode=hashrate it measures hashrate
+// instead, see handleHashrateBenchmark.
+func (s *Server) handleBenchmark(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("mode") == "hashrate" {
+		s.handleHashrateBenchmark(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.mu.Lock()
+		result := s.lastBenchmark
+		s.mu.Unlock()
+
+		if result == nil {
+			http.Error(w, "No benchmark has run yet", http.StatusNotFound)
+			return
+		}
+		jsonResponse(w, result)
+
+	case http.MethodPost:
+		var opts bench.Options
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && err != io.EOF {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		if !s.benchMu.TryLock() {
+			http.Error(w, "A benchmark is already running", http.StatusConflict)
+			return
+		}
+		defer s.benchMu.Unlock()
+
+		s.recordAction(requestID(r), "benchmark", r.RemoteAddr, fmt.Sprintf("workers=%d rounds=%d goroutines=%d events=%d clients=%d ticks=%d", opts.Workers, opts.Rounds, opts.Goroutines, opts.Events, opts.Clients, opts.Ticks))
+
+		// A private hub keeps benchmark ticks off the live dashboards
+		hub := NewWSHub()
+		defer hub.Close()
+
+		result, err := bench.Run(opts, hub)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		s.mu.Lock()
+		s.lastBenchmark = result
+		s.mu.Unlock()
+
+		jsonResponse(w, result)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleHashrateBenchmark returns the last hashrate benchmark (GET) or
+// mines a fixed synthetic header without a pool and reports per-worker
+// and total H/s (POST, optional {"workers", "seconds", "cpu_percent",
+// "device", "cpu_affinity"})
+func (s *Server) handleHashrateBenchmark(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.mu.Lock()
+		result := s.lastHashrate
+		s.mu.Unlock()
+
+		if result == nil {
+			http.Error(w, "No hashrate benchmark has run yet", http.StatusNotFound)
+			return
+		}
+		jsonResponse(w, result)
+
+	case http.MethodPost:
+		var opts bench.HashrateOptions
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && err != io.EOF {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if opts.Device != "" {
+			known := false
+			for _, d := range miner.Devices() {
+				known = known || d.ID == opts.Device
+			}
+			if !known {
+				http.Error(w, fmt.Sprintf("Unknown device %q", opts.Device), http.StatusBadRequest)
+				return
+			}
+		}
+
+		if !s.benchMu.TryLock() {
+			http.Error(w, "A benchmark is already running", http.StatusConflict)
+			return
+		}
+		defer s.benchMu.Unlock()
+
+		s.recordAction(requestID(r), "benchmark", r.RemoteAddr, fmt.Sprintf("mode=hashrate workers=%d seconds=%d cpu_percent=%d device=%s", opts.Workers, opts.Seconds, opts.CPUPercent, opts.Device))
+
+		result, err := bench.RunHashrate(opts)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		s.mu.Lock()
+		s.lastHashrate = result
+		s.mu.Unlock()
+
+		s.broadcastLog(fmt.Sprintf("Hashrate benchmark: %.0f H/s on %d workers", result.Hashrate, len(result.Workers)), "var(--info)")
+		jsonResponse(w, result)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleStats returns mining statistics
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	snap, ok := s.requestSnapshot(w, r)
+	if !ok {
+		return
+	}
+	if snap != nil {
+		jsonResponse(w, snap.stats)
+		return
+	}
+
+	jsonResponse(w, s.takeSnapshot())
+}
+
+// handleHistory returns share/block history
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := 100
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			limit = parsed
+		}
+	}
+
+	// Filtering needs the whole history, then keeps the newest matches
+	origin := r.URL.Query().Get("origin")
+	shareLimit := limit
+	if origin != "" {
+		shareLimit = 0
+	}
+
+	snap, ok := s.requestSnapshot(w, r)
+	if !ok {
+		return
+	}
+	if snap != nil {
+		jsonResponse(w, map[string]interface{}{
+			"shares":   filterOrigin(snap.limitShares(shareLimit), origin, limit),
+			"blocks":   snap.limitBlocks(limit),
+			"snapshot": snap.version,
+		})
+		return
+	}
+
+	history := map[string]interface{}{
+		"shares": filterOrigin(s.stats.GetShareHistory(shareLimit), origin, limit),
+		"blocks": s.stats.GetBlockHistory(limit),
+	}
+
+	jsonResponse(w, history)
+}
+
+// filterOrigin keeps up to limit shares from origin, all shares when
+// origin is empty
+func filterOrigin(shares []stats.ShareEntry, origin string, limit int) []stats.ShareEntry {
+	if origin == "" {
+		return shares
+	}
+
+	result := make([]stats.ShareEntry, 0)
+	for _, e := range shares {
+		if e.Origin != origin {
+			continue
+		}
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+		result = append(result, e)
+	}
+	return result
+}
+
+// handleHistoryImport merges an uploaded ckpool or AxeOS share log into
+// the history: POST /api/history/import?format=ckpool&source=ckpool-2023
+func (s *Server) handleHistoryImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.stats.ReadOnly() {
+		http.Error(w, stats.ErrReadOnlyStore.Error(), http.StatusConflict)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	source := r.URL.Query().Get("source")
+	if source == "" {
+		source = format
+	}
+
+	result, err := importer.Parse(format, http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	added := s.stats.ImportShares(result.Shares, source)
+	if err := s.stats.Save(); err != nil {
+		log.Printf("Failed to save imported shares: %v", err)
+	}
+
+	s.recordAction(requestID(r), "import", r.RemoteAddr, fmt.Sprintf("%s: %d shares from %s", source, added, format))
+
+	jsonResponse(w, map[string]interface{}{
+		"status":   "imported",
+		"source":   source,
+		"lines":    result.Lines,
+		"parsed":   result.Parsed,
+		"skipped":  result.Skipped,
+		"imported": added,
+	})
+}
+
+// handleSessions returns session history
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := 50
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			limit = parsed
+		}
+	}
+
+	sessions := s.stats.GetSessionHistory(limit)
+	jsonResponse(w, sessions)
+}
+
+// handleActivity returns per-day mining activity for a calendar widget
+func (s *Server) handleActivity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	days := 365
+	if d := r.URL.Query().Get("days"); d != "" {
+		if parsed, err := strconv.Atoi(d); err == nil && parsed > 0 && parsed <= 366 {
+			days = parsed
+		}
+	}
+
+	activity := s.stats.GetActivity(days)
+
+	var totalHashes uint64
+	var totalHours float64
+	activeDays := 0
+	for _, d := range activity {
+		totalHashes += d.Hashes
+		totalHours += d.MiningHours
+		if d.Hashes > 0 {
+			activeDays++
+		}
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"days":         activity,
+		"active_days":  activeDays,
+		"total_hashes": totalHashes,
+		"total_hours":  totalHours,
+	})
+}
+
+// workerList summarizes every worker for GET /api/workers
+func (s *Server) workerList() []map[string]interface{} {
+	workers := s.manager.GetAllWorkers()
+	workerList := make([]map[string]interface{}, 0, len(workers))
+
+	for _, worker := range workers {
+		workerList = append(workerList, map[string]interface{}{
+			"id":        worker.ID,
+			"name":      worker.Name,
+			"device":    worker.Device,
+			"running":   worker.IsRunning(),
+			"paused":    worker.IsPaused(),
+			"hashrate":  worker.GetHashrate(),
+			"hashrates": worker.GetHashrates(),
+			"hashCount": worker.GetHashCount(),
+			"shares":    worker.GetShares(),
+			"coverage":  worker.GetNonceCoverage(),
+		})
+	}
+	return workerList
+}
+
+// handleWorkers handles worker CRUD
+func (s *Server) handleWorkers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		snap, ok := s.requestSnapshot(w, r)
+		if !ok {
+			return
+		}
+		if snap != nil {
+			jsonResponse(w, snap.workers)
+			return
+		}
+
+		jsonResponse(w, s.workerList())
+
+	case http.MethodPost:
+		var req struct {
+			Name   string `json:"name"`
+			Device string `json:"device"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			req.Name = ""
+		}
+		if req.Device == "" {
+			req.Device = miner.DeviceCPU
+		}
+
+		worker, err := s.manager.AddDeviceWorker(req.Name, req.Device)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// If we have a job, send it to the new worker
+		if job := s.stratum.GetCurrentJob(); job != nil {
+			worker.UpdateJob(job)
+		}
+
+		jsonResponse(w, map[string]interface{}{
+			"id":     worker.ID,
+			"name":   worker.Name,
+			"device": worker.Device,
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleDevices lists the devices workers can mine on, with the number of
+// workers on each
+func (s *Server) handleDevices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	workers := make(map[string]int)
+	for _, worker := range s.manager.GetAllWorkers() {
+		workers[worker.Device]++
+	}
+
+	devices := miner.Devices()
+	list := make([]map[string]interface{}, 0, len(devices))
+	for _, d := range devices {
+		list = append(list, map[string]interface{}{
+			"id":            d.ID,
+			"kind":          d.Kind,
+			"name":          d.Name,
+			"vendor":        d.Vendor,
+			"compute_units": d.ComputeUnits,
+			"memory_bytes":  d.MemoryBytes,
+			"workers":       workers[d.ID],
+		})
+	}
+	jsonResponse(w, list)
+}
+
+// handleWorkerByID handles individual worker operations
+func (s *Server) handleWorkerByID(w http.ResponseWriter, r *http.Request) {
+	// Extract ID from path /api/workers/{id}[/pause|/resume]
+	idStr, action, _ := strings.Cut(r.URL.Path[len("/api/workers/"):], "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid worker ID", http.StatusBadRequest)
+		return
+	}
+
+	if action != "" {
+		s.handleWorkerAction(w, r, id, action)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		worker := s.manager.GetWorker(id)
+		if worker == nil {
+			http.Error(w, "Worker not found", http.StatusNotFound)
+			return
+		}
+
+		limit := 20
+		if l := r.URL.Query().Get("limit"); l != "" {
+			if parsed, err := strconv.Atoi(l); err == nil {
+				limit = parsed
+			}
+		}
+
+		jsonResponse(w, map[string]interface{}{
+			"id":            worker.ID,
+			"name":          worker.Name,
+			"device":        worker.Device,
+			"running":       worker.IsRunning(),
+			"paused":        worker.IsPaused(),
+			"hashrate":      worker.GetHashrate(),
+			"hashrates":     worker.GetHashrates(),
+			"hashCount":     worker.GetHashCount(),
+			"restarts":      worker.GetRestarts(),
+			"shares":        worker.GetShares(),
+			"current_job":   worker.GetCurrentJobID(),
+			"cpu_percent":   worker.GetCPUPercent(),
+			"cpu":           worker.GetCPU(),
+			"nonce_range":   worker.GetNonceRange(),
+			"coverage":      worker.GetNonceCoverage(),
+			"sparkline":     worker.GetSparkline(),
+			"recent_shares": s.stats.GetWorkerShareHistory(worker.ID, limit),
+		})
+
+	case http.MethodDelete:
+		if s.manager.RemoveWorker(id) {
+			jsonResponse(w, map[string]string{"status": "deleted"})
+		} else {
+			http.Error(w, "Worker not found", http.StatusNotFound)
+		}
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleWorkerAction pauses or resumes a worker, keeping its name,
+// counters and nonce range. A resumed worker starts mining again right
+// away while mining is on and not paused by the tariff.
+func (s *Server) handleWorkerAction(w http.ResponseWriter, r *http.Request, id int, action string) {
+	if action != "pause" && action != "resume" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var found bool
+	if action == "pause" {
+		found = s.manager.PauseWorker(id)
+	} else {
+		start := s.isMining() && s.tariff.Current().Action != tariff.ActionPause
+		found = s.manager.ResumeWorker(id, start)
+	}
+	worker := s.manager.GetWorker(id)
+	if !found || worker == nil {
+		http.Error(w, "Worker not found", http.StatusNotFound)
+		return
+	}
+
+	s.recordAction(requestID(r), "worker_"+action, r.RemoteAddr, fmt.Sprintf("%d (%s)", worker.ID, worker.Name))
+
+	jsonResponse(w, map[string]interface{}{
+		"id":      worker.ID,
+		"name":    worker.Name,
+		"paused":  worker.IsPaused(),
+		"running": worker.IsRunning(),
+	})
+}
+
+// handleConfig handles configuration
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		jsonResponse(w, map[string]interface{}{
+			"pool_url":                 s.cfg.GetPoolURL(),
+			"pool_port":                s.cfg.GetPoolPort(),
+			"backup_pools":             s.cfg.GetBackupPools(),
+			"failover_threshold":       s.cfg.GetFailoverThreshold(),
+			"failback_seconds":         s.cfg.GetFailbackSeconds(),
+			"mock_pool":                s.cfg.GetMockPool(),
+			"idle_timeout_seconds":     s.cfg.GetIdleTimeoutSeconds(),
+			"job_expiry_seconds":       s.cfg.GetJobExpirySeconds(),
+			"job_expiry_action":        s.cfg.GetJobExpiryAction(),
+			"no_job_timeout_seconds":   s.cfg.GetNoJobTimeoutSeconds(),
+			"dial_timeout_seconds":     s.cfg.GetDialTimeoutSeconds(),
+			"keepalive_seconds":        s.cfg.GetKeepAliveSeconds(),
+			"tcp_nodelay":              s.cfg.GetTCPNoDelay(),
+			"source_interface":         s.cfg.GetSourceInterface(),
+			"wallet_address":           s.cfg.GetWalletAddress(),
+			"worker_name":              s.cfg.GetWorkerName(),
+			"max_cpu_percent":          s.cfg.GetMaxCPUPercent(),
+			"num_workers":              s.cfg.GetNumWorkers(),
+			"auto_workers_physical":    s.cfg.GetAutoWorkersPhysical(),
+			"autoscale_enabled":        s.cfg.GetAutoscaleEnabled(),
+			"autoscale_min_workers":    s.cfg.GetAutoscaleMinWorkers(),
+			"autoscale_max_workers":    s.cfg.GetAutoscaleMaxWorkers(),
+			"cpu_affinity":             s.cfg.GetCPUAffinity(),
+			"mining_priority":          s.cfg.GetMiningPriority(),
+			"max_temp_celsius":         s.cfg.GetMaxTempCelsius(),
+			"job_latency_alert_ms":     s.cfg.GetJobLatencyAlertMs(),
+			"hash_check_fallback":      s.cfg.GetHashCheckFallback(),
+			"share_sample_threshold":   s.cfg.GetShareSampleThreshold(),
+			"share_sample_one_in":      s.cfg.GetShareSampleOneIn(),
+			"candidate_min_difficulty": s.cfg.GetCandidateMinDifficulty(),
+			"near_miss_percent":        s.cfg.GetNearMissPercent(),
+			"ntp_server":               s.cfg.GetNTPServer(),
+			"ntime_correction":         s.cfg.GetNTimeCorrection(),
+			"firehose_url":             s.cfg.GetFirehoseURL(),
+			"target_share_seconds":     s.cfg.GetTargetShareSeconds(),
+			"stratum_server_port":      s.cfg.GetStratumServerPort(),
+			"getwork_enabled":          s.cfg.GetGetworkEnabled(),
+			"power_watts":              s.cfg.GetPowerWatts(),
+			"tariff_windows":           s.cfg.GetTariffWindows(),
+			"tariff_default_price":     s.cfg.GetTariffDefaultPrice(),
+			"tariff_price_url":         s.cfg.GetTariffPriceURL(),
+			"tariff_throttle_price":    s.cfg.GetTariffThrottlePrice(),
+			"tariff_pause_price":       s.cfg.GetTariffPausePrice(),
+			"tariff_throttle_percent":  s.cfg.GetTariffThrottlePercent(),
+			"mining_schedule":          s.cfg.GetMiningSchedule(),
+		})
+
+	case http.MethodPut:
+		var updates map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		if v, ok := updates["firehose_url"].(string); ok {
+			if err := s.firehose.Configure(v); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		s.cfg.Update(updates)
+		s.applyConfigUpdates(updates)
+
+		jsonResponse(w, map[string]string{"status": "updated"})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// applyConfigUpdates puts the hot settings among updates, already
+// applied to the config, into effect
+func (s *Server) applyConfigUpdates(updates map[string]interface{}) {
+	// Apply CPU percent change immediately, replacing any session override
+	if _, ok := updates["max_cpu_percent"]; ok {
+		s.mu.Lock()
+		s.sessionCPUPercent = 0
+		s.mu.Unlock()
+		s.manager.SetCPUPercent(s.cfg.GetMaxCPUPercent())
+	}
+	if _, ok := updates["cpu_affinity"]; ok {
+		s.manager.SetCPUAffinity(s.cfg.GetCPUAffinity())
+	}
+	if _, ok := updates["mining_schedule"]; ok {
+		s.configureSchedule()
+	}
+
+	s.stats.SetShareSampling(s.cfg.GetShareSampleThreshold(), s.cfg.GetShareSampleOneIn())
+	s.configureClock()
+}
+
+// handleConfigPreview returns what a proposed config update would change
+// and when each change takes effect, without applying it
+func (s *Server) handleConfigPreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var updates map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	preview := s.cfg.Preview(updates)
+	jsonResponse(w, map[string]interface{}{
+		"changes": preview.Changes,
+		"ignored": preview.Ignored,
+		"apply":   preview.Apply,
+		"mining":  s.isMining(),
+	})
+}
+
+// handlePools lists the configured pools in failover order
+func (s *Server) handlePools(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	current := s.stratum.CurrentPool()
+	standby := s.stratum.GetStandbyStatus()
+	pools := s.stratum.GetPools()
+	poolList := make([]map[string]interface{}, 0, len(pools))
+
+	for i, p := range pools {
+		poolList = append(poolList, map[string]interface{}{
+			"name":     p.Name,
+			"url":      p.URL,
+			"port":     p.Port,
+			"priority": i,
+			"active":   p == current,
+			"standby":  standby.Pool != nil && *standby.Pool == p,
+		})
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"pools":   poolList,
+		"standby": standby,
+	})
+}
+
+// handlePoolByName handles per-pool operations under /api/pools/{name}/
+func (s *Server) handlePoolByName(w http.ResponseWriter, r *http.Request) {
+	// Extract name and action from path /api/pools/{name}/{action}
+	parts := strings.Split(strings.Trim(r.URL.Path[len("/api/pools/"):], "/"), "/")
+	if len(parts) != 2 || parts[0] == "" {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	name, action := parts[0], parts[1]
+	switch action {
+	case "report":
+		s.handlePoolReport(w, r, name)
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// handlePoolBenchmark compares connect, subscribe and first-job latency
+// across pools, the configured ones unless a list is given:
+// {"pools": [{"name", "url", "port"}], "timeout_seconds": 10}
+func (s *Server) handlePoolBenchmark(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Pools          []stratum.Pool `json:"pools"`
+		TimeoutSeconds int            `json:"timeout_seconds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	pools := req.Pools
+	if len(pools) == 0 {
+		pools = []stratum.Pool{{Name: "primary", URL: s.cfg.GetPoolURL(), Port: s.cfg.GetPoolPort()}}
+		for _, p := range s.cfg.GetBackupPools() {
+			pools = append(pools, stratum.Pool{Name: p.Name, URL: p.URL, Port: p.Port})
+		}
+	}
+	if len(pools) > maxBenchmarkPools {
+		http.Error(w, fmt.Sprintf("at most %d pools", maxBenchmarkPools), http.StatusBadRequest)
+		return
+	}
+	for i, p := range pools {
+		if p.URL == "" || p.Port <= 0 || p.Port > 65535 {
+			http.Error(w, fmt.Sprintf("pool %d needs a url and port", i), http.StatusBadRequest)
+			return
+		}
+		if p.Name == "" {
+			pools[i].Name = fmt.Sprintf("%s:%d", p.URL, p.Port)
+		}
+	}
+
+	timeout := 10 * time.Second
+	if req.TimeoutSeconds > 0 {
+		timeout = time.Duration(min(req.TimeoutSeconds, 60)) * time.Second
+	}
+
+	reqID := requestID(r)
+	s.recordAction(reqID, "pool_benchmark", r.RemoteAddr, fmt.Sprintf("pools=%d", len(pools)))
+
+	results := s.stratum.BenchmarkPools(pools, s.cfg.GetStratumUsername(), timeout)
+	fastest := ""
+	if len(results) > 0 && results[0].Error == "" {
+		fastest = results[0].Pool.Name
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"results":    results,
+		"fastest":    fastest,
+		"request_id": reqID,
+	})
+}
+
+// handlePoolReport returns the monthly SLA report for a pool
+func (s *Server) handlePoolReport(w http.ResponseWriter, r *http.Request, name string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	reports := s.stats.GetPoolReports(name)
+
+	month := r.URL.Query().Get("month")
+	if month == "" {
+		month = time.Now().Format("2006-01")
+	}
+
+	var current *stats.PoolReport
+	for i := range reports {
+		if reports[i].Month == month {
+			current = &reports[i]
+			break
+		}
+	}
+	if current == nil {
+		current = &stats.PoolReport{Pool: name, Month: month}
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"pool":    name,
+		"report":  current,
+		"history": reports,
+	})
+}
+
+// handleProxy lists the miners connected to the Stratum proxy
+func (s *Server) handleProxy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"listening": s.proxy.IsListening(),
+		"address":   s.proxy.Addr(),
+		"hashrate":  s.proxy.Hashrate(),
+		"miners":    s.proxy.Miners(),
+		"getwork":   s.proxy.GetworkStatus(),
+	})
+}
+
+// getworkHandler serves the proxy's getwork bridge while getwork_enabled
+// is set
+func (s *Server) getworkHandler() http.Handler {
+	bridge := s.proxy.GetworkHandler("/getwork/longpoll")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.cfg.GetGetworkEnabled() {
+			http.NotFound(w, r)
+			return
+		}
+		bridge.ServeHTTP(w, r)
+	})
+}
+
+// handleMessages returns notices received from pools
+func (s *Server) handleMessages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := 50
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			limit = parsed
+		}
+	}
+
+	jsonResponse(w, s.stats.GetPoolMessages(limit))
+}
+
+// handleNetwork returns the local hashrate's share of the network
+func (s *Server) handleNetwork(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	jsonResponse(w, s.stats.EstimateNetworkShare(s.manager.GetTotalHashrate()+s.proxy.Hashrate()))
+}
+
+// handleDigests returns past digests, newest first (GET, optional
+// ?period=daily|weekly&limit=) or generates one now (POST {"period"})
+func (s *Server) handleDigests(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		limit := 30
+		if l := r.URL.Query().Get("limit"); l != "" {
+			if parsed, err := strconv.Atoi(l); err == nil {
+				limit = parsed
+			}
+		}
+		jsonResponse(w, s.stats.GetDigests(r.URL.Query().Get("period"), limit))
+
+	case http.MethodPost:
+		var req struct {
+			Period string `json:"period"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if req.Period == "" {
+			req.Period = stats.DigestDaily
+		}
+
+		digest, ok := s.stats.GenerateDigest(req.Period)
+		if !ok {
+			http.Error(w, "period must be daily or weekly", http.StatusBadRequest)
+			return
+		}
+		s.wsHub.BroadcastEvent("digest", digest)
+		jsonResponse(w, digest)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleStratumStats returns pool connection traffic and reconnect counters
+func (s *Server) handleStratumStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	jsonResponse(w, s.stratum.GetConnectionStats())
+}
+
+// handleStratumJobStats returns job quality metrics per pool, to compare
+// how responsive pools are
+func (s *Server) handleStratumJobStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	jsonResponse(w, s.stratum.GetJobStats())
+}
+
+// handleStratumTrace returns the most recent Stratum protocol frames
+func (s *Server) handleStratumTrace(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := 100
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			limit = parsed
+		}
+	}
+
+	jsonResponse(w, s.stratum.GetTrace(limit))
+}
+
+// handleAudit returns the audit trail, optionally for a single request ID
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := 100
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			limit = parsed
+		}
+	}
+
+	jsonResponse(w, s.stats.GetAuditLog(r.URL.Query().Get("request_id"), limit))
+}
+
+// handleMiningStart starts mining. An optional body overrides config for
+// this session only: {"workers": 8, "cpu_percent": 50, "pool_profile": "ckpool"}
+func (s *Server) handleMiningStart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Workers     int    `json:"workers"`
+		CPUPercent  int    `json:"cpu_percent"`
+		PoolProfile string `json:"pool_profile"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Workers < 0 {
+		http.Error(w, "workers must not be negative", http.StatusBadRequest)
+		return
+	}
+	if req.CPUPercent < 0 || req.CPUPercent > 100 {
+		http.Error(w, "cpu_percent must be between 1 and 100", http.StatusBadRequest)
+		return
+	}
+
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+
+	reqID := requestID(r)
+	s.recordAction(reqID, "start", r.RemoteAddr, fmt.Sprintf("workers=%d cpu_percent=%d pool_profile=%q", req.Workers, req.CPUPercent, req.PoolProfile))
+
+	// Connect to pool if not connected
+	if !s.stratum.IsConnected() {
+		s.stratum.SetCorrelationID(reqID)
+		if err := s.configurePools(req.PoolProfile); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := s.connectSession(); err != nil {
+			jsonResponse(w, map[string]interface{}{
+				"status": "error",
+				"error":  err.Error(),
+			})
+			return
+		}
+	} else if req.PoolProfile != "" && req.PoolProfile != s.stratum.ConnectedPool().Name {
+		http.Error(w, "Already connected to "+s.stratum.ConnectedPool().Name+", use /api/pool/switch to change pool", http.StatusConflict)
+		return
+	}
+
+	s.startSession(req.Workers, req.CPUPercent)
+
+	jsonResponse(w, map[string]interface{}{
+		"status":      "started",
+		"workers":     s.manager.WorkerCount(),
+		"cpu_percent": s.targetCPUPercent(),
+		"pool":        s.stratum.ConnectedPool().Name,
+		"request_id":  reqID,
+	})
+}
+
+// handleMiningStop stops mining
+func (s *Server) handleMiningStop(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+
+	reqID := requestID(r)
+	s.recordAction(reqID, "stop", r.RemoteAddr, "")
+	s.stratum.SetCorrelationID(reqID)
+
+	s.stopSession()
+
+	jsonResponse(w, map[string]string{
+		"status":     "stopped",
+		"request_id": reqID,
+	})
+}
+
+// connectSession applies the proxy and dial options and connects to the
+// configured pools. Caller must hold sessionMu.
+func (s *Server) connectSession() error {
+	if err := s.stratum.SetProxy(s.cfg.GetProxyURL()); err != nil {
+		return err
+	}
+	if err := s.stratum.SetDialOptions(stratum.DialOptions{
+		Timeout:         time.Duration(s.cfg.GetDialTimeoutSeconds()) * time.Second,
+		KeepAlive:       time.Duration(s.cfg.GetKeepAliveSeconds()) * time.Second,
+		NoDelay:         s.cfg.GetTCPNoDelay(),
+		SourceInterface: s.cfg.GetSourceInterface(),
+	}); err != nil {
+		return err
+	}
+	return s.connectPool()
+}
+
+// startSession starts the workers, proxy, tariff and clock on the
+// connected pool. workers and cpuPercent override the config for this
+// session when set. Caller must hold sessionMu.
+func (s *Server) startSession(workers, cpuPercent int) {
+	// Set stratum data to manager
+	s.manager.SetStratumData(s.stratum.GetExtranonce1(), s.stratum.GetExtranonce2Size(), s.stratum.SessionEpoch())
+
+	// Session CPU override applies before workers are created
+	s.mu.Lock()
+	s.sessionCPUPercent = cpuPercent
+	s.mu.Unlock()
+	s.manager.SetCPUPercent(s.targetCPUPercent())
+	s.manager.SetCPUAffinity(s.cfg.GetCPUAffinity())
+	s.manager.SetShareDifficulty(s.stratum.GetDifficulty())
+	s.manager.SetPriority(s.cfg.GetMiningPriority())
+
+	if workers == 0 && s.presetWorkers {
+		workers = s.configuredWorkerCount()
+	}
+	s.presetWorkers = false
+
+	if workers > 0 {
+		s.manager.SetWorkerCount(workers)
+	} else if s.manager.WorkerCount() == 0 {
+		// Add workers if none exist
+		s.manager.SetWorkerCount(s.configuredWorkerCount())
+	}
+
+	// Start all workers
+	s.manager.StartAll()
+	s.setMining(true)
+
+	// Let LAN miners join through the proxy
+	if port := s.cfg.GetStratumServerPort(); port > 0 {
+		if err := s.proxy.Start(fmt.Sprintf(":%d", port)); err != nil {
+			s.broadcastLog(fmt.Sprintf("⚠️ Stratum proxy: %v", err), "var(--warning)")
+		}
+	}
+
+	// Let the tariff scheduler pause or throttle from here on
+	s.configureTariff()
+	s.tariff.Start()
+
+	s.configureClock()
+	s.clock.Start()
+
+	// Send current job to workers
+	if job := s.stratum.GetCurrentJob(); job != nil {
+		s.manager.BroadcastJob(job)
+	}
+}
+
+// stopSession stops mining and disconnects from the pool. Caller must
+// hold sessionMu.
+func (s *Server) stopSession() {
+	s.setMining(false)
+	s.tariff.Stop()
+	s.clock.Stop()
 	s.manager.StopAll()
+	s.proxy.Stop()
 	s.stratum.Close()
+	s.mockPool.Stop()
+
+	effort := s.stats.GetEffort()
+	s.broadcastLog(fmt.Sprintf("📈 Session effort %.4g%%, lifetime %.4g%%", effort.SessionPercent, effort.LifetimePercent), "var(--info)")
 
-	jsonResponse(w, map[string]string{"status": "stopped"})
+	// Session overrides end with the session
+	s.mu.Lock()
+	s.sessionCPUPercent = 0
+	s.mu.Unlock()
 }
 
 // jsonResponse writes a JSON response
@@ -416,7 +2262,8 @@ func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, "+requestIDHeader)
+		w.Header().Set("Access-Control-Expose-Headers", requestIDHeader)
 
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusOK)
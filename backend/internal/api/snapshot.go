@@ -0,0 +1,93 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/soloforge/backend/internal/stats"
+)
+
+// snapshotTTL is how long a snapshot can be read back by its version
+const snapshotTTL = 30 * time.Second
+
+// maxSnapshots bounds the snapshots kept, oldest go first
+const maxSnapshots = 16
+
+// statsSnapshot is what /api/stats, /api/workers and /api/history showed
+// at one moment. Reads carrying its version get the same data, so a
+// dashboard assembling a view from several calls never mixes seconds.
+type statsSnapshot struct {
+	version string
+	takenAt time.Time
+	stats   map[string]interface{}
+	workers []map[string]interface{}
+	shares  []stats.ShareEntry
+	blocks  []stats.BlockEntry
+}
+
+// takeSnapshot captures stats, workers and the full history together and
+// returns the stats payload tagged with the snapshot version
+func (s *Server) takeSnapshot() map[string]interface{} {
+	now := time.Now()
+	snap := &statsSnapshot{
+		version: newRequestID(),
+		takenAt: now,
+		stats:   s.buildStatsPayload(),
+		workers: s.workerList(),
+		shares:  s.stats.GetShareHistory(0),
+		blocks:  s.stats.GetBlockHistory(0),
+	}
+	snap.stats["snapshot"] = snap.version
+
+	s.snapshotMu.Lock()
+	defer s.snapshotMu.Unlock()
+
+	kept := s.snapshots[:0]
+	for _, old := range s.snapshots {
+		if now.Sub(old.takenAt) < snapshotTTL {
+			kept = append(kept, old)
+		}
+	}
+	s.snapshots = append(kept, snap)
+	if len(s.snapshots) > maxSnapshots {
+		s.snapshots = s.snapshots[len(s.snapshots)-maxSnapshots:]
+	}
+	return snap.stats
+}
+
+// requestSnapshot returns the snapshot named by the request's snapshot
+// parameter, or nil for a live read. An unknown or expired version is
+// answered with 410 Gone and false.
+func (s *Server) requestSnapshot(w http.ResponseWriter, r *http.Request) (*statsSnapshot, bool) {
+	version := r.URL.Query().Get("snapshot")
+	if version == "" {
+		return nil, true
+	}
+
+	s.snapshotMu.Lock()
+	defer s.snapshotMu.Unlock()
+
+	for _, snap := range s.snapshots {
+		if snap.version == version && time.Since(snap.takenAt) < snapshotTTL {
+			return snap, true
+		}
+	}
+	http.Error(w, "Snapshot expired, read /api/stats again", http.StatusGone)
+	return nil, false
+}
+
+// limitShares returns the newest limit shares of a snapshot
+func (snap *statsSnapshot) limitShares(limit int) []stats.ShareEntry {
+	if limit <= 0 || limit > len(snap.shares) {
+		return snap.shares
+	}
+	return snap.shares[:limit]
+}
+
+// limitBlocks returns the newest limit blocks of a snapshot
+func (snap *statsSnapshot) limitBlocks(limit int) []stats.BlockEntry {
+	if limit <= 0 || limit > len(snap.blocks) {
+		return snap.blocks
+	}
+	return snap.blocks[:limit]
+}
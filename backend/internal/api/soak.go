@@ -0,0 +1,441 @@
+//go:build soak
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/soloforge/backend/internal/config"
+	"github.com/soloforge/backend/internal/miner"
+	"github.com/soloforge/backend/internal/stats"
+	"github.com/soloforge/backend/internal/stratum"
+)
+
+// Soak runs are long but bounded, and keep a bounded report
+const (
+	maxSoakDuration   = 72 * time.Hour
+	minSoakInterval   = time.Second
+	maxSoakViolations = 100
+
+	// soakGoroutineSlack is how far the goroutine count may rise above
+	// its level after the first check before it counts as growth
+	soakGoroutineSlack = 50
+)
+
+// SoakOptions sizes a soak run. Zero values use the defaults.
+type SoakOptions struct {
+	DurationSeconds      int `json:"duration_seconds"`
+	Workers              int `json:"workers"`
+	CPUPercent           int `json:"cpu_percent"`
+	CheckIntervalSeconds int `json:"check_interval_seconds"`
+}
+
+// SoakReport is the state of a soak run, final once Running is false
+type SoakReport struct {
+	Options       SoakOptions `json:"options"`
+	StartedAt     time.Time   `json:"started_at"`
+	FinishedAt    *time.Time  `json:"finished_at,omitempty"`
+	Running       bool        `json:"running"`
+	Checks        int         `json:"checks"`
+	TotalHashes   uint64      `json:"total_hashes"`
+	TotalShares   int         `json:"total_shares"`
+	Accepted      int         `json:"accepted_shares"`
+	PoolSubmitted int         `json:"pool_submitted"`
+	PoolAccepted  int         `json:"pool_accepted"`
+	RoundTrips    int         `json:"round_trips"`
+	Goroutines    struct {
+		Baseline int `json:"baseline"`
+		Last     int `json:"last"`
+		Peak     int `json:"peak"`
+	} `json:"goroutines"`
+	HeapBytes struct {
+		First uint64 `json:"first"`
+		Last  uint64 `json:"last"`
+		Peak  uint64 `json:"peak"`
+	} `json:"heap_bytes"`
+	Violations       []string `json:"violations"`
+	ViolationCount   int      `json:"violation_count"`
+	Passed           bool     `json:"passed"`
+	ReportPath       string   `json:"report_path,omitempty"`
+	Error            string   `json:"error,omitempty"`
+	lastManagerCount uint64
+}
+
+// soakState is the one soak run a process may have, running or finished
+var soakState struct {
+	mu     sync.Mutex
+	report *SoakReport
+	cancel chan struct{}
+}
+
+// setupSoakRoutes adds the soak test endpoint, built with -tags soak
+func (s *Server) setupSoakRoutes() {
+	s.mux.HandleFunc("/api/soak", s.handleSoak)
+}
+
+// handleSoak returns the current or last soak report (GET), starts a run
+// (POST, optional {"duration_seconds", "workers", "cpu_percent",
+// "check_interval_seconds"}) or ends the running one early (DELETE)
+func (s *Server) handleSoak(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		soakState.mu.Lock()
+		defer soakState.mu.Unlock()
+		if soakState.report == nil {
+			http.Error(w, "No soak test has run yet", http.StatusNotFound)
+			return
+		}
+		jsonResponse(w, soakState.report)
+
+	case http.MethodPost:
+		var opts SoakOptions
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && err != io.EOF {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		opts = normalizeSoak(opts)
+
+		soakState.mu.Lock()
+		if soakState.report != nil && soakState.report.Running {
+			soakState.mu.Unlock()
+			http.Error(w, "A soak test is already running", http.StatusConflict)
+			return
+		}
+		report := &SoakReport{Options: opts, StartedAt: time.Now(), Running: true, Violations: []string{}}
+		cancel := make(chan struct{})
+		soakState.report = report
+		soakState.cancel = cancel
+		soakState.mu.Unlock()
+
+		reqID := requestID(r)
+		s.recordAction(reqID, "soak", r.RemoteAddr, fmt.Sprintf("duration=%ds workers=%d cpu_percent=%d", opts.DurationSeconds, opts.Workers, opts.CPUPercent))
+		s.broadcastLog(fmt.Sprintf("🧪 Soak test started for %s", time.Duration(opts.DurationSeconds)*time.Second), "var(--info)")
+		go s.runSoak(report, cancel)
+
+		jsonResponse(w, map[string]interface{}{
+			"status":     "started",
+			"options":    opts,
+			"request_id": reqID,
+		})
+
+	case http.MethodDelete:
+		soakState.mu.Lock()
+		running := soakState.report != nil && soakState.report.Running && soakState.cancel != nil
+		if running {
+			close(soakState.cancel)
+			soakState.cancel = nil
+		}
+		soakState.mu.Unlock()
+
+		if !running {
+			http.Error(w, "No soak test is running", http.StatusNotFound)
+			return
+		}
+		s.recordAction(requestID(r), "soak_stop", r.RemoteAddr, "")
+		jsonResponse(w, map[string]string{"status": "stopping"})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// normalizeSoak applies defaults and limits
+func normalizeSoak(opts SoakOptions) SoakOptions {
+	if opts.DurationSeconds <= 0 {
+		opts.DurationSeconds = int(time.Hour / time.Second)
+	}
+	opts.DurationSeconds = min(opts.DurationSeconds, int(maxSoakDuration/time.Second))
+	if opts.Workers <= 0 {
+		opts.Workers = max(runtime.NumCPU()/2, 1)
+	}
+	opts.Workers = min(opts.Workers, 256)
+	if opts.CPUPercent <= 0 || opts.CPUPercent > 100 {
+		opts.CPUPercent = 50
+	}
+	if opts.CheckIntervalSeconds <= 0 {
+		opts.CheckIntervalSeconds = 30
+	}
+	opts.CheckIntervalSeconds = max(opts.CheckIntervalSeconds, int(minSoakInterval/time.Second))
+	return opts
+}
+
+// soakStack is a private server, manager, collector and mock pool, so the
+// soak run mines for real without touching the live ones
+type soakStack struct {
+	server  *Server
+	dataDir string
+}
+
+// newSoakStack builds the full stack on a temporary data directory
+func newSoakStack(opts SoakOptions) (*soakStack, error) {
+	dataDir, err := os.MkdirTemp("", "soloforge-soak-*")
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := stats.OpenFileStore(dataDir, "stats.json", false)
+	if err != nil {
+		os.RemoveAll(dataDir)
+		return nil, err
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Update(map[string]interface{}{
+		"mock_pool":    true,
+		"num_workers":  float64(opts.Workers),
+		"ntp_server":   "",
+		"firehose_url": "",
+	})
+
+	srv := NewServer(cfg, stratum.NewClient("127.0.0.1", 1), miner.NewManager(), stats.NewCollectorWithStore(1000, store))
+	return &soakStack{server: srv, dataDir: dataDir}, nil
+}
+
+// call invokes one of the stack's endpoints in process
+func (st *soakStack) call(method, path, body string) (int, string) {
+	rec := httptest.NewRecorder()
+	st.server.GetHandler().ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return rec.Code, rec.Body.String()
+}
+
+// close stops mining and removes the stack's data
+func (st *soakStack) close() {
+	st.call(http.MethodPost, "/api/mining/stop", "")
+	st.server.Stop()
+	st.server.wsHub.Close()
+	st.server.stats.Close()
+	os.RemoveAll(st.dataDir)
+}
+
+// runSoak mines against the mock pool until the duration passes or the
+// run is cancelled, checking invariants every interval
+func (s *Server) runSoak(report *SoakReport, cancel chan struct{}) {
+	opts := report.Options
+
+	st, err := newSoakStack(opts)
+	if err != nil {
+		s.finishSoak(report, err)
+		return
+	}
+	defer st.close()
+
+	st.server.StartStatsLoop()
+	body := fmt.Sprintf(`{"workers":%d,"cpu_percent":%d}`, opts.Workers, opts.CPUPercent)
+	if code, resp := st.call(http.MethodPost, "/api/mining/start", body); code != http.StatusOK || !strings.Contains(resp, `"started"`) {
+		s.finishSoak(report, fmt.Errorf("mining did not start: %d %s", code, strings.TrimSpace(resp)))
+		return
+	}
+
+	deadline := time.NewTimer(time.Duration(opts.DurationSeconds) * time.Second)
+	defer deadline.Stop()
+	ticker := time.NewTicker(time.Duration(opts.CheckIntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-cancel:
+			st.checkSoak(report)
+			s.finishSoak(report, nil)
+			return
+		case <-deadline.C:
+			st.checkSoak(report)
+			s.finishSoak(report, nil)
+			return
+		case <-ticker.C:
+			st.checkSoak(report)
+		}
+	}
+}
+
+// checkSoak asserts the invariants once and records what it saw
+func (st *soakStack) checkSoak(report *SoakReport) {
+	var violations []string
+	fail := func(format string, args ...interface{}) {
+		violations = append(violations, time.Now().Format(time.RFC3339)+" "+fmt.Sprintf(format, args...))
+	}
+
+	// Read the collector's accepted count before the pool's and the
+	// pool's before the collector's total, so in-flight shares cannot
+	// make an honest count look wrong
+	before := st.server.stats.GetStats()
+	pool := st.server.mockPool.GetStats()
+	after := st.server.stats.GetStats()
+	managerCount := st.server.manager.GetTotalHashCount()
+
+	accepted, _ := before["accepted_shares"].(int)
+	total, _ := after["total_shares"].(int)
+	hashes, _ := after["total_hashes"].(uint64)
+	settled := 0
+	for _, key := range []string{"accepted_shares", "rejected_shares", "stale_shares", "dropped_shares"} {
+		n, _ := after[key].(int)
+		settled += n
+	}
+	submitted := pool.Accepted + pool.Rejected
+
+	if accepted > submitted {
+		fail("accepted shares %d exceed shares submitted to the pool %d", accepted, submitted)
+	}
+	if submitted > total {
+		fail("shares submitted to the pool %d exceed shares found %d", submitted, total)
+	}
+	if settled > total {
+		fail("accepted, rejected, stale and dropped shares %d exceed shares found %d", settled, total)
+	}
+	if managerCount < report.lastManagerCount {
+		fail("worker hash count went back from %d to %d", report.lastManagerCount, managerCount)
+	}
+	if hashes < report.TotalHashes {
+		fail("total hashes went back from %d to %d", report.TotalHashes, hashes)
+	}
+
+	if err := st.roundTrip(); err != nil {
+		fail("stats save/load round trip: %v", err)
+	}
+
+	goroutines := runtime.NumGoroutine()
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	soakState.mu.Lock()
+	defer soakState.mu.Unlock()
+
+	report.Checks++
+	report.TotalHashes = hashes
+	report.lastManagerCount = managerCount
+	report.TotalShares = total
+	report.Accepted = accepted
+	report.PoolSubmitted = submitted
+	report.PoolAccepted = pool.Accepted
+	report.RoundTrips++
+
+	// The first check sets the baseline, once workers and connections
+	// are up, and any later rise beyond the slack is a leak
+	if report.Checks == 1 {
+		report.Goroutines.Baseline = goroutines
+		report.HeapBytes.First = mem.HeapAlloc
+	} else if goroutines > report.Goroutines.Baseline+soakGoroutineSlack {
+		violations = append(violations, fmt.Sprintf("%s goroutines grew from %d to %d", time.Now().Format(time.RFC3339), report.Goroutines.Baseline, goroutines))
+	}
+	report.Goroutines.Last = goroutines
+	report.Goroutines.Peak = max(report.Goroutines.Peak, goroutines)
+	report.HeapBytes.Last = mem.HeapAlloc
+	report.HeapBytes.Peak = max(report.HeapBytes.Peak, mem.HeapAlloc)
+
+	for _, v := range violations {
+		log.Printf("Soak invariant violated: %s", v)
+		report.ViolationCount++
+		if len(report.Violations) < maxSoakViolations {
+			report.Violations = append(report.Violations, v)
+		}
+	}
+}
+
+// roundTrip saves the stats, loads them into a fresh collector and checks
+// it exports exactly what was saved. Only the save time and the mining
+// time, which grows with the fresh collector's uptime, may differ.
+func (st *soakStack) roundTrip() error {
+	if err := st.server.stats.Save(); err != nil {
+		return fmt.Errorf("save: %w", err)
+	}
+	saved, err := os.ReadFile(filepath.Join(st.dataDir, "stats.json"))
+	if err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+
+	loaded := stats.NewCollectorWithStore(1000, stats.NewFileStore(st.dataDir, "stats.json"))
+	var exported bytes.Buffer
+	err = loaded.Export(&exported)
+	loaded.Close()
+	if err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+
+	want, err := comparableStats(saved)
+	if err != nil {
+		return fmt.Errorf("saved file: %w", err)
+	}
+	got, err := comparableStats(exported.Bytes())
+	if err != nil {
+		return fmt.Errorf("reloaded stats: %w", err)
+	}
+
+	var differ []string
+	for key, v := range want {
+		if !bytes.Equal(v, got[key]) {
+			differ = append(differ, key)
+		}
+	}
+	for key := range got {
+		if _, ok := want[key]; !ok {
+			differ = append(differ, key)
+		}
+	}
+	if len(differ) > 0 {
+		sort.Strings(differ)
+		return fmt.Errorf("reloaded stats differ in %s", strings.Join(differ, ", "))
+	}
+	return nil
+}
+
+// comparableStats decodes persisted stats into their top-level fields,
+// re-encoded canonically, without the ones expected to change
+func comparableStats(raw []byte) (map[string]json.RawMessage, error) {
+	var data stats.PersistentData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+	data.LastSaved = time.Time{}
+	data.TotalMiningSeconds = 0
+
+	encoded, err := json.Marshal(&data)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(encoded, &fields)
+	return fields, err
+}
+
+// finishSoak marks the run done, writes the report next to other
+// temporary files and logs the verdict
+func (s *Server) finishSoak(report *SoakReport, runErr error) {
+	soakState.mu.Lock()
+	now := time.Now()
+	report.FinishedAt = &now
+	report.Running = false
+	if runErr != nil {
+		report.Error = runErr.Error()
+	}
+	report.Passed = runErr == nil && report.ViolationCount == 0 && report.Checks > 0
+	report.ReportPath = filepath.Join(os.TempDir(), fmt.Sprintf("soloforge-soak-%s.json", report.StartedAt.Format("20060102-150405")))
+	data, err := json.MarshalIndent(report, "", "  ")
+	soakState.mu.Unlock()
+
+	if err == nil {
+		err = os.WriteFile(report.ReportPath, data, 0644)
+	}
+	if err != nil {
+		log.Printf("Failed to write soak report: %v", err)
+	}
+
+	summary := fmt.Sprintf("%d checks, %d hashes, %d shares, %d violations", report.Checks, report.TotalHashes, report.TotalShares, report.ViolationCount)
+	if report.Passed {
+		s.broadcastLog("✅ Soak test passed: "+summary, "var(--success)")
+	} else {
+		s.broadcastLog("❌ Soak test failed: "+summary+" "+report.Error, "var(--error)")
+	}
+	log.Printf("Soak test finished: %s, report %s", summary, report.ReportPath)
+}
@@ -0,0 +1,7 @@
+//go:build !soak
+
+package api
+
+// setupSoakRoutes adds nothing, the soak test endpoint is only built with
+// -tags soak
+func (s *Server) setupSoakRoutes() {}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/soloforge/backend/internal/tariff"
+)
+
+// configureTariff pushes the configured tariff policy to the scheduler
+func (s *Server) configureTariff() {
+	windows := s.cfg.GetTariffWindows()
+	policy := tariff.Policy{
+		Windows:       make([]tariff.Window, 0, len(windows)),
+		DefaultPrice:  s.cfg.GetTariffDefaultPrice(),
+		PriceURL:      s.cfg.GetTariffPriceURL(),
+		ThrottleAbove: s.cfg.GetTariffThrottlePrice(),
+		PauseAbove:    s.cfg.GetTariffPausePrice(),
+	}
+	for _, w := range windows {
+		policy.Windows = append(policy.Windows, tariff.Window{
+			Start: w.Start,
+			End:   w.End,
+			Price: w.Price,
+			Days:  w.Days,
+		})
+	}
+
+	s.tariff.SetPolicy(policy)
+}
+
+// applyTariffDecision pauses, throttles or resumes workers for the
+// current electricity price
+func (s *Server) applyTariffDecision(d tariff.Decision) {
+	if !s.isMining() {
+		return
+	}
+
+	switch d.Action {
+	case tariff.ActionPause:
+		s.manager.StopAll()
+		s.broadcastLog(fmt.Sprintf("💤 Mining paused, electricity at %.4f", d.Price), "var(--warning)")
+	case tariff.ActionThrottle:
+		s.manager.SetCPUPercent(s.cfg.GetTariffThrottlePercent())
+		s.manager.StartAll()
+		s.broadcastLog(fmt.Sprintf("🐢 Mining throttled, electricity at %.4f", d.Price), "var(--warning)")
+	default:
+		s.manager.SetCPUPercent(s.targetCPUPercent())
+		s.manager.StartAll()
+		s.broadcastLog("⚡ Mining at full configured speed", "var(--success)")
+	}
+
+	s.wsHub.BroadcastEvent("tariff", d)
+}
+
+// handleTariff returns the tariff policy and the current decision
+func (s *Server) handleTariff(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.configureTariff()
+
+	jsonResponse(w, map[string]interface{}{
+		"policy":   s.tariff.GetPolicy(),
+		"current":  s.tariff.Evaluate(time.Now()),
+		"override": s.tariff.IsOverridden(),
+	})
+}
+
+// handleTariffOverride forces mining regardless of electricity price
+func (s *Server) handleTariffOverride(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Enabled bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	s.tariff.SetOverride(req.Enabled)
+
+	jsonResponse(w, map[string]interface{}{
+		"status":   "updated",
+		"override": req.Enabled,
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -16,25 +17,69 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
+// Broadcast limits: each topic queues up to topicQueueSize messages, and
+// sends to large audiences are split into fanoutChunk clients handled by
+// at most fanoutWorkers goroutines
+const (
+	topicQueueSize = 64
+	fanoutWorkers  = 8
+	fanoutChunk    = 32
+)
+
 // WSClient represents a connected WebSocket client
 type WSClient struct {
 	conn *websocket.Conn
 	send chan []byte
+	// Closed when the client is removed. send itself is never closed, so
+	// a fan-out still holding the client can't panic.
+	done chan struct{}
+}
+
+// fanoutJob is one chunk of clients to deliver a message to
+type fanoutJob struct {
+	clients []*WSClient
+	message []byte
+	wg      *sync.WaitGroup
 }
 
-// WSHub manages WebSocket connections
+// WSHub manages WebSocket connections. Each event type has its own
+// dispatch goroutine, so a burst on one topic can't delay another.
 type WSHub struct {
-	mu         sync.RWMutex
-	clients    map[*WSClient]bool
+	mu      sync.RWMutex
+	clients map[*WSClient]bool
+	// Copy of clients rebuilt on join and leave, broadcasts iterate it
+	// without holding the lock
+	snapshot   []*WSClient
 	logHistory []map[string]interface{}
+	topics     map[string]chan []byte
+
+	fanout   chan fanoutJob
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	// Messages dropped on a full topic queue or client buffer
+	dropped atomic.Uint64
 }
 
 // NewWSHub creates a new WebSocket hub
 func NewWSHub() *WSHub {
-	return &WSHub{
+	h := &WSHub{
 		clients:    make(map[*WSClient]bool),
 		logHistory: make([]map[string]interface{}, 0),
+		topics:     make(map[string]chan []byte),
+		fanout:     make(chan fanoutJob),
+		stop:       make(chan struct{}),
 	}
+	for i := 0; i < fanoutWorkers; i++ {
+		go h.fanoutWorker()
+	}
+	return h
+}
+
+// Close stops the dispatch and fan-out goroutines. Connected clients are
+// left to disconnect on their own.
+func (h *WSHub) Close() {
+	h.stopOnce.Do(func() { close(h.stop) })
 }
 
 // AddClient adds a new client
@@ -42,12 +87,13 @@ func (h *WSHub) AddClient(client *WSClient) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	h.clients[client] = true
+	h.rebuildSnapshotLocked()
 
 	// Send log history to new client
 	for _, logEntry := range h.logHistory {
 		data, err := json.Marshal(logEntry)
 		if err == nil {
-			client.send <- data
+			h.deliver(client, data)
 		}
 	}
 }
@@ -58,22 +104,24 @@ func (h *WSHub) RemoveClient(client *WSClient) {
 	defer h.mu.Unlock()
 	if _, ok := h.clients[client]; ok {
 		delete(h.clients, client)
-		close(client.send)
+		h.rebuildSnapshotLocked()
+		close(client.done)
 	}
 }
 
-// Broadcast sends a message to all clients
-func (h *WSHub) Broadcast(message []byte) {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
-
+// rebuildSnapshotLocked refreshes the client copy broadcasts iterate.
+// Caller must hold h.mu.
+func (h *WSHub) rebuildSnapshotLocked() {
+	snapshot := make([]*WSClient, 0, len(h.clients))
 	for client := range h.clients {
-		select {
-		case client.send <- message:
-		default:
-			// Client buffer full, skip
-		}
+		snapshot = append(snapshot, client)
 	}
+	h.snapshot = snapshot
+}
+
+// Broadcast sends a message to all clients
+func (h *WSHub) Broadcast(message []byte) {
+	h.publish("", message)
 }
 
 // BroadcastEvent sends a typed event to all clients
@@ -100,7 +148,101 @@ func (h *WSHub) BroadcastEvent(eventType string, data interface{}) {
 		return
 	}
 
-	h.Broadcast(message)
+	h.publish(eventType, message)
+}
+
+// publish queues a message on its topic, starting the topic's dispatch
+// goroutine on first use. A full queue drops the message rather than
+// blocking the caller.
+func (h *WSHub) publish(topic string, message []byte) {
+	h.mu.RLock()
+	queue, ok := h.topics[topic]
+	h.mu.RUnlock()
+
+	if !ok {
+		h.mu.Lock()
+		if queue, ok = h.topics[topic]; !ok {
+			queue = make(chan []byte, topicQueueSize)
+			h.topics[topic] = queue
+			go h.dispatch(queue)
+		}
+		h.mu.Unlock()
+	}
+
+	select {
+	case queue <- message:
+	default:
+		h.dropped.Add(1)
+	}
+}
+
+// dispatch delivers a topic's messages in order until the hub closes
+func (h *WSHub) dispatch(queue chan []byte) {
+	for {
+		select {
+		case <-h.stop:
+			return
+		case message := <-queue:
+			h.fanOut(message)
+		}
+	}
+}
+
+// fanOut delivers a message to every client, in parallel chunks for large
+// audiences. It returns once every client has it queued.
+func (h *WSHub) fanOut(message []byte) {
+	h.mu.RLock()
+	clients := h.snapshot
+	h.mu.RUnlock()
+
+	if len(clients) <= fanoutChunk {
+		for _, client := range clients {
+			h.deliver(client, message)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	for start := 0; start < len(clients); start += fanoutChunk {
+		end := min(start+fanoutChunk, len(clients))
+		wg.Add(1)
+		select {
+		case h.fanout <- fanoutJob{clients: clients[start:end], message: message, wg: &wg}:
+		case <-h.stop:
+			wg.Done()
+		}
+	}
+	wg.Wait()
+}
+
+// fanoutWorker delivers chunks handed out by fanOut
+func (h *WSHub) fanoutWorker() {
+	for {
+		select {
+		case <-h.stop:
+			return
+		case job := <-h.fanout:
+			for _, client := range job.clients {
+				h.deliver(client, job.message)
+			}
+			job.wg.Done()
+		}
+	}
+}
+
+// deliver queues a message for one client without blocking
+func (h *WSHub) deliver(client *WSClient, message []byte) {
+	select {
+	case client.send <- message:
+	default:
+		// Client buffer full, skip
+		h.dropped.Add(1)
+	}
+}
+
+// DroppedCount returns how many messages were dropped on full queues
+func (h *WSHub) DroppedCount() uint64 {
+	return h.dropped.Load()
 }
 
 // ClientCount returns the number of connected clients
@@ -121,6 +263,7 @@ func (h *WSHub) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	client := &WSClient{
 		conn: conn,
 		send: make(chan []byte, 256),
+		done: make(chan struct{}),
 	}
 
 	h.AddClient(client)
@@ -140,12 +283,11 @@ func (h *WSHub) writePump(client *WSClient) {
 
 	for {
 		select {
-		case message, ok := <-client.send:
-			if !ok {
-				client.conn.WriteMessage(websocket.CloseMessage, []byte{})
-				return
-			}
+		case <-client.done:
+			client.conn.WriteMessage(websocket.CloseMessage, []byte{})
+			return
 
+		case message := <-client.send:
 			if err := client.conn.WriteMessage(websocket.TextMessage, message); err != nil {
 				return
 			}
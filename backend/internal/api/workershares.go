@@ -0,0 +1,53 @@
+package api
+
+import (
+	"time"
+
+	"github.com/soloforge/backend/internal/stats"
+)
+
+// Submitted shares whose verdict never comes (a dropped connection) are
+// forgotten after workerShareTTL, once more than maxWorkerShares wait
+const (
+	workerShareTTL  = 10 * time.Minute
+	maxWorkerShares = 1024
+)
+
+// pendingWorkerShare is a local share awaiting the pool's verdict
+type pendingWorkerShare struct {
+	workerID int
+	at       time.Time
+}
+
+// trackWorkerShare remembers which worker found a share, so the verdict
+// can be credited to it
+func (s *Server) trackWorkerShare(workerID int, jobID, nonce string) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.workerShares) >= maxWorkerShares {
+		for key, p := range s.workerShares {
+			if now.Sub(p.at) > workerShareTTL {
+				delete(s.workerShares, key)
+			}
+		}
+	}
+	s.workerShares[jobID+":"+nonce] = pendingWorkerShare{workerID: workerID, at: now}
+}
+
+// creditWorkerShare counts a verdict for the worker that found the share.
+// Dropped shares were never submitted and count for neither side.
+func (s *Server) creditWorkerShare(jobID, nonce, status string) {
+	key := jobID + ":" + nonce
+	s.mu.Lock()
+	p, ok := s.workerShares[key]
+	delete(s.workerShares, key)
+	s.mu.Unlock()
+
+	if !ok || status == stats.ShareStatusDropped {
+		return
+	}
+	s.manager.RecordShareVerdict(p.workerID, status == stats.ShareStatusAccepted)
+}
@@ -0,0 +1,390 @@
+package bench
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"runtime/metrics"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/soloforge/backend/internal/miner"
+	"github.com/soloforge/backend/internal/stats"
+	"github.com/soloforge/backend/internal/stratum"
+)
+
+// Limits keep a benchmark from taking the dashboard down with it
+const (
+	maxWorkers    = 256
+	maxRounds     = 500
+	maxGoroutines = 256
+	maxEvents     = 1000000
+
+	// adoptTimeout bounds how long a round waits for every worker
+	adoptTimeout = 5 * time.Second
+)
+
+// mutexWaitMetric is the cumulative time goroutines spent blocked on
+// sync.Mutex and sync.RWMutex
+const mutexWaitMetric = "/sync/mutex/wait/total:seconds"
+
+// Options sizes a benchmark run. Zero values use the defaults.
+type Options struct {
+	Workers    int `json:"workers"`
+	Rounds     int `json:"rounds"`
+	Goroutines int `json:"goroutines"`
+	Events     int `json:"events"`
+	// WebSocket broadcast: dashboard clients, stats ticks and tick interval
+	Clients    int `json:"clients"`
+	Ticks      int `json:"ticks"`
+	IntervalMs int `json:"interval_ms"`
+}
+
+// DefaultOptions returns a run sized for a typical host
+func DefaultOptions() Options {
+	return Options{
+		Workers:    runtime.NumCPU(),
+		Rounds:     50,
+		Goroutines: 16,
+		Events:     100000,
+		Clients:    100,
+		Ticks:      10,
+		IntervalMs: 1000,
+	}
+}
+
+// Latency summarizes a set of durations in microseconds
+type Latency struct {
+	Count  int     `json:"count"`
+	MeanUs float64 `json:"mean_us"`
+	P50Us  float64 `json:"p50_us"`
+	P99Us  float64 `json:"p99_us"`
+	MaxUs  float64 `json:"max_us"`
+}
+
+// FanoutResult measures how long workers take to switch to a new job
+type FanoutResult struct {
+	Workers int `json:"workers"`
+	Rounds  int `json:"rounds"`
+	// Time spent inside Manager.BroadcastJob
+	Broadcast Latency `json:"broadcast"`
+	// Time until each worker mines the new job
+	Adoption Latency `json:"adoption"`
+	// Time until the last worker mines the new job
+	AllAdopted Latency `json:"all_adopted"`
+	TimedOut   int     `json:"timed_out"`
+}
+
+// ThroughputResult measures the share path into the collector
+type ThroughputResult struct {
+	Goroutines   int     `json:"goroutines"`
+	Events       int     `json:"events"`
+	Seconds      float64 `json:"seconds"`
+	EventsPerSec float64 `json:"events_per_sec"`
+}
+
+// ContentionResult is the mutex wait observed while hammering one type
+type ContentionResult struct {
+	Target     string  `json:"target"`
+	Goroutines int     `json:"goroutines"`
+	Operations int     `json:"operations"`
+	Seconds    float64 `json:"seconds"`
+	// Total time goroutines spent blocked on mutexes during the run
+	MutexWaitSeconds float64 `json:"mutex_wait_seconds"`
+	// Blocked time as a share of the goroutines' combined run time
+	WaitRatio float64 `json:"wait_ratio"`
+}
+
+// Result is a complete benchmark run
+type Result struct {
+	StartedAt  time.Time          `json:"started_at"`
+	Duration   string             `json:"duration"`
+	Options    Options            `json:"options"`
+	GoMaxProcs int                `json:"gomaxprocs"`
+	Fanout     FanoutResult       `json:"fanout"`
+	Shares     ThroughputResult   `json:"shares"`
+	Contention []ContentionResult `json:"contention"`
+	Broadcast  *BroadcastResult   `json:"broadcast,omitempty"`
+}
+
+// Run benchmarks job fan-out, share throughput and lock contention on
+// private Manager and Collector instances, so the live ones are untouched.
+// Fan-out starts real workers and uses CPU while it runs. Given a hub,
+// which should be a fresh one, stats broadcasting is measured too.
+func Run(opts Options, hub Hub) (*Result, error) {
+	opts = normalize(opts)
+
+	start := time.Now()
+	result := &Result{
+		StartedAt:  start,
+		Options:    opts,
+		GoMaxProcs: runtime.GOMAXPROCS(0),
+	}
+
+	fanout, err := benchFanout(opts.Workers, opts.Rounds)
+	if err != nil {
+		return nil, err
+	}
+	result.Fanout = fanout
+	result.Shares = benchShares(opts.Goroutines, opts.Events)
+	result.Contention = []ContentionResult{
+		benchManagerContention(opts.Workers, opts.Goroutines, opts.Events),
+		benchCollectorContention(opts.Goroutines, opts.Events),
+	}
+
+	if hub != nil {
+		broadcast, err := benchBroadcast(hub, opts.Clients, opts.Ticks, time.Duration(opts.IntervalMs)*time.Millisecond)
+		if err != nil {
+			return nil, err
+		}
+		result.Broadcast = &broadcast
+	}
+
+	result.Duration = time.Since(start).String()
+	return result, nil
+}
+
+// normalize applies defaults and limits
+func normalize(opts Options) Options {
+	def := DefaultOptions()
+	if opts.Workers <= 0 {
+		opts.Workers = def.Workers
+	}
+	if opts.Rounds <= 0 {
+		opts.Rounds = def.Rounds
+	}
+	if opts.Goroutines <= 0 {
+		opts.Goroutines = def.Goroutines
+	}
+	if opts.Events <= 0 {
+		opts.Events = def.Events
+	}
+	if opts.Clients <= 0 {
+		opts.Clients = def.Clients
+	}
+	if opts.Ticks <= 0 {
+		opts.Ticks = def.Ticks
+	}
+	if opts.IntervalMs <= 0 {
+		opts.IntervalMs = def.IntervalMs
+	}
+
+	opts.Workers = min(opts.Workers, maxWorkers)
+	opts.Rounds = min(opts.Rounds, maxRounds)
+	opts.Goroutines = min(opts.Goroutines, maxGoroutines)
+	opts.Events = min(opts.Events, maxEvents)
+	opts.Clients = min(opts.Clients, maxClients)
+	opts.Ticks = min(opts.Ticks, maxTicks)
+	opts.IntervalMs = max(opts.IntervalMs, int(minInterval/time.Millisecond))
+	return opts
+}
+
+// benchJob returns a job no worker will find a share for
+func benchJob(id string) *stratum.Job {
+	return &stratum.Job{
+		ID:           id,
+		PrevHash:     "0000000000000000000000000000000000000000000000000000000000000000",
+		Coinbase1:    "01000000010000000000000000000000000000000000000000000000000000000000000000ffffffff",
+		Coinbase2:    "ffffffff0100f2052a010000001976a914000000000000000000000000000000000000000088ac00000000",
+		MerkleBranch: []string{},
+		Version:      "20000000",
+		NBits:        "17034219",
+		NTime:        fmt.Sprintf("%08x", time.Now().Unix()),
+		CleanJobs:    true,
+	}
+}
+
+// benchFanout broadcasts jobs to running workers and times how long each
+// takes to pick the job up
+func benchFanout(workers, rounds int) (FanoutResult, error) {
+	manager := miner.NewManager()
+	manager.SetCPUPercent(100)
+	manager.SetStratumData("00000000", 4, 1)
+	manager.SetWorkerCount(workers)
+	manager.StartAll()
+	defer manager.StopAll()
+
+	all := manager.GetAllWorkers()
+	result := FanoutResult{Workers: len(all), Rounds: rounds}
+
+	var broadcast, adoption, allAdopted []time.Duration
+	for round := 0; round < rounds; round++ {
+		job := benchJob("bench-" + strconv.Itoa(round))
+
+		start := time.Now()
+		manager.BroadcastJob(job)
+		broadcast = append(broadcast, time.Since(start))
+
+		pending := make(map[int]bool, len(all))
+		for _, w := range all {
+			pending[w.ID] = true
+		}
+
+		deadline := start.Add(adoptTimeout)
+		for len(pending) > 0 && time.Now().Before(deadline) {
+			for _, w := range all {
+				if pending[w.ID] && w.GetCurrentJobID() == job.ID {
+					delete(pending, w.ID)
+					adoption = append(adoption, time.Since(start))
+				}
+			}
+			time.Sleep(20 * time.Microsecond)
+		}
+
+		if len(pending) > 0 {
+			result.TimedOut += len(pending)
+			continue
+		}
+		allAdopted = append(allAdopted, time.Since(start))
+	}
+
+	result.Broadcast = summarize(broadcast)
+	result.Adoption = summarize(adoption)
+	result.AllAdopted = summarize(allAdopted)
+	return result, nil
+}
+
+// benchShares replays the share callback path, a pending share followed
+// by the pool's verdict, from concurrent goroutines
+func benchShares(goroutines, events int) ThroughputResult {
+	collector := stats.NewCollectorWithStore(1000, discardStore{})
+	defer collector.Close()
+
+	elapsed := parallel(goroutines, events, func(g, i int) {
+		jobID := strconv.Itoa(i % 16)
+		nonce := strconv.Itoa(i)
+		collector.AddShare(g, "bench", "", jobID, nonce, rand.Float64())
+		collector.RecordSubmitResult(jobID, nonce, stats.ShareStatusAccepted, "", "")
+	})
+
+	return ThroughputResult{
+		Goroutines:   goroutines,
+		Events:       events,
+		Seconds:      elapsed.Seconds(),
+		EventsPerSec: float64(events) / elapsed.Seconds(),
+	}
+}
+
+// benchManagerContention mixes the Manager calls the stats loop, API and
+// job handler make concurrently
+func benchManagerContention(workers, goroutines, operations int) ContentionResult {
+	manager := miner.NewManager()
+	manager.SetWorkerCount(workers)
+	job := benchJob("bench-contention")
+
+	return measureContention("manager", goroutines, operations, func(g, i int) {
+		switch i % 4 {
+		case 0:
+			manager.GetTotalHashrate()
+		case 1:
+			manager.GetTotalHashCount()
+		case 2:
+			manager.GetWorker(i%workers + 1)
+		default:
+			manager.BroadcastJob(job)
+		}
+	})
+}
+
+// benchCollectorContention mixes share recording with the reads the
+// stats loop and API make concurrently
+func benchCollectorContention(goroutines, operations int) ContentionResult {
+	collector := stats.NewCollectorWithStore(1000, discardStore{})
+	defer collector.Close()
+
+	return measureContention("collector", goroutines, operations, func(g, i int) {
+		switch i % 4 {
+		case 0:
+			collector.AddShare(g, "bench", "", "job", strconv.Itoa(i), 1)
+		case 1:
+			collector.UpdateHashes(uint64(i))
+		case 2:
+			collector.GetStats()
+		default:
+			collector.GetShareHistory(50)
+		}
+	})
+}
+
+// measureContention runs op and reports the mutex wait it caused
+func measureContention(target string, goroutines, operations int, op func(g, i int)) ContentionResult {
+	before := mutexWait()
+	elapsed := parallel(goroutines, operations, op)
+	wait := mutexWait() - before
+
+	result := ContentionResult{
+		Target:           target,
+		Goroutines:       goroutines,
+		Operations:       operations,
+		Seconds:          elapsed.Seconds(),
+		MutexWaitSeconds: wait,
+	}
+	if busy := elapsed.Seconds() * float64(goroutines); busy > 0 {
+		result.WaitRatio = wait / busy
+	}
+	return result
+}
+
+// parallel runs n calls of op spread over goroutines and returns the wall
+// time taken
+func parallel(goroutines, n int, op func(g, i int)) time.Duration {
+	var wg sync.WaitGroup
+	start := time.Now()
+
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := g; i < n; i += goroutines {
+				op(g, i)
+			}
+		}(g)
+	}
+
+	wg.Wait()
+	return time.Since(start)
+}
+
+// mutexWait reads the process-wide mutex wait time
+func mutexWait() float64 {
+	sample := []metrics.Sample{{Name: mutexWaitMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindFloat64 {
+		return 0
+	}
+	return sample[0].Value.Float64()
+}
+
+// summarize computes latency percentiles
+func summarize(durations []time.Duration) Latency {
+	if len(durations) == 0 {
+		return Latency{}
+	}
+
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+
+	us := func(d time.Duration) float64 { return float64(d) / float64(time.Microsecond) }
+	return Latency{
+		Count:  len(sorted),
+		MeanUs: us(total / time.Duration(len(sorted))),
+		P50Us:  us(sorted[len(sorted)/2]),
+		P99Us:  us(sorted[(len(sorted)*99)/100]),
+		MaxUs:  us(sorted[len(sorted)-1]),
+	}
+}
+
+// discardStore keeps benchmark collectors off disk
+type discardStore struct{}
+
+func (discardStore) Load() (*stats.PersistentData, error) { return nil, nil }
+func (discardStore) Save(*stats.PersistentData) error     { return nil }
+func (discardStore) Close() error                         { return nil }
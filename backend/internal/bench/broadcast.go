@@ -0,0 +1,177 @@
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Broadcast limits and timings
+const (
+	maxClients  = 1000
+	maxTicks    = 120
+	minInterval = 10 * time.Millisecond
+
+	// noiseInterval paces share_result events sent alongside the stats
+	// ticks, as a busy rig would
+	noiseInterval = 10 * time.Millisecond
+
+	// joinTimeout bounds how long clients take to register with the hub
+	joinTimeout = 5 * time.Second
+)
+
+// Hub is the WebSocket hub under test
+type Hub interface {
+	HandleWebSocket(w http.ResponseWriter, r *http.Request)
+	BroadcastEvent(eventType string, data interface{})
+	ClientCount() int
+	DroppedCount() uint64
+}
+
+// BroadcastResult measures stats delivery to many dashboard clients
+type BroadcastResult struct {
+	Clients    int `json:"clients"`
+	Ticks      int `json:"ticks"`
+	IntervalMs int `json:"interval_ms"`
+	// Time from broadcasting a stats tick to a client reading it
+	Delivery Latency `json:"delivery"`
+	// Change in delivery time between consecutive ticks of one client
+	Jitter Latency `json:"jitter"`
+	// Ticks a client never received
+	Missed int `json:"missed"`
+	// Messages the hub dropped on full queues, noise included
+	Dropped     uint64 `json:"dropped"`
+	NoiseEvents int    `json:"noise_events"`
+}
+
+// statsTick is the payload clients time
+type statsTick struct {
+	Seq    int    `json:"seq"`
+	SentAt int64  `json:"sent_at"`
+	Pad    string `json:"pad"`
+}
+
+// benchBroadcast connects clients to the hub over real WebSockets and
+// times stats ticks sent at the given interval while share events flood
+// another topic
+func benchBroadcast(hub Hub, clients, ticks int, interval time.Duration) (BroadcastResult, error) {
+	result := BroadcastResult{
+		Clients:    clients,
+		Ticks:      ticks,
+		IntervalMs: int(interval / time.Millisecond),
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(hub.HandleWebSocket))
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	conns := make([]*websocket.Conn, 0, clients)
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+	for i := 0; i < clients; i++ {
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			return result, fmt.Errorf("failed to connect client %d: %w", i, err)
+		}
+		conns = append(conns, conn)
+	}
+
+	deadline := time.Now().Add(joinTimeout)
+	for hub.ClientCount() < clients {
+		if time.Now().After(deadline) {
+			return result, fmt.Errorf("only %d of %d clients joined the hub", hub.ClientCount(), clients)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Each reader records the delivery time of every tick it sees
+	delivery := make([][]time.Duration, clients)
+	var readers sync.WaitGroup
+	for i, conn := range conns {
+		readers.Add(1)
+		go func(i int, conn *websocket.Conn) {
+			defer readers.Done()
+			for {
+				_, data, err := conn.ReadMessage()
+				if err != nil {
+					return
+				}
+				received := time.Now()
+
+				var event struct {
+					Type string    `json:"type"`
+					Data statsTick `json:"data"`
+				}
+				if json.Unmarshal(data, &event) != nil || event.Type != "stats" {
+					continue
+				}
+				delivery[i] = append(delivery[i], received.Sub(time.Unix(0, event.Data.SentAt)))
+				if event.Data.Seq == ticks-1 {
+					return
+				}
+			}
+		}(i, conn)
+	}
+
+	stopNoise := make(chan struct{})
+	noiseDone := make(chan int)
+	go func() {
+		ticker := time.NewTicker(noiseInterval)
+		defer ticker.Stop()
+		sent := 0
+		for {
+			select {
+			case <-stopNoise:
+				noiseDone <- sent
+				return
+			case <-ticker.C:
+				hub.BroadcastEvent("share_result", map[string]interface{}{"accepted": true, "seq": sent})
+				sent++
+			}
+		}
+	}()
+
+	droppedBefore := hub.DroppedCount()
+	pad := strings.Repeat("x", 2048)
+	ticker := time.NewTicker(interval)
+	for seq := 0; seq < ticks; seq++ {
+		<-ticker.C
+		hub.BroadcastEvent("stats", statsTick{Seq: seq, SentAt: time.Now().UnixNano(), Pad: pad})
+	}
+	ticker.Stop()
+
+	// Give the last tick an interval to arrive, then unblock stragglers
+	time.Sleep(interval)
+	for _, conn := range conns {
+		conn.SetReadDeadline(time.Now())
+	}
+	readers.Wait()
+	close(stopNoise)
+	result.NoiseEvents = <-noiseDone
+	result.Dropped = hub.DroppedCount() - droppedBefore
+
+	var all, jitter []time.Duration
+	for _, d := range delivery {
+		result.Missed += ticks - len(d)
+		all = append(all, d...)
+		for j := 1; j < len(d); j++ {
+			diff := d[j] - d[j-1]
+			if diff < 0 {
+				diff = -diff
+			}
+			jitter = append(jitter, diff)
+		}
+	}
+	result.Delivery = summarize(all)
+	result.Jitter = summarize(jitter)
+	return result, nil
+}
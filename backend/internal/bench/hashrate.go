@@ -0,0 +1,133 @@
+package bench
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/soloforge/backend/internal/miner"
+)
+
+// Hashrate benchmark limits and timings
+const (
+	defaultHashrateSeconds = 10
+	maxHashrateSeconds     = 300
+
+	// hashrateWarmup lets workers tune their batch size before counting
+	hashrateWarmup = 2 * time.Second
+
+	// hashrateNTime fixes the header, so runs hash the same work
+	hashrateNTime = "65000000"
+)
+
+// HashrateOptions sizes a hashrate benchmark. Zero values use the
+// defaults: one CPU worker per CPU at full speed for 10 seconds.
+type HashrateOptions struct {
+	Workers     int    `json:"workers"`
+	Seconds     int    `json:"seconds"`
+	CPUPercent  int    `json:"cpu_percent"`
+	Device      string `json:"device"`
+	CPUAffinity bool   `json:"cpu_affinity"`
+}
+
+// WorkerHashrate is one worker's share of a hashrate benchmark
+type WorkerHashrate struct {
+	ID       int     `json:"id"`
+	Device   string  `json:"device"`
+	CPU      int     `json:"cpu"`
+	Hashes   uint64  `json:"hashes"`
+	Hashrate float64 `json:"hashrate"`
+}
+
+// HashrateResult is a complete hashrate benchmark run
+type HashrateResult struct {
+	StartedAt  time.Time         `json:"started_at"`
+	Seconds    float64           `json:"seconds"`
+	Options    HashrateOptions   `json:"options"`
+	GoMaxProcs int               `json:"gomaxprocs"`
+	Backend    miner.HashBackend `json:"backend"`
+	Workers    []WorkerHashrate  `json:"workers"`
+	Hashes     uint64            `json:"hashes"`
+	Hashrate   float64           `json:"hashrate"`
+}
+
+// RunHashrate mines a fixed synthetic header on private workers, with no
+// pool, and measures their hashrate after a warm-up. The live workers
+// keep mining and compete for the CPU, so stop them for clean numbers.
+func RunHashrate(opts HashrateOptions) (*HashrateResult, error) {
+	opts = normalizeHashrate(opts)
+
+	manager := miner.NewManager()
+	manager.SetCPUPercent(opts.CPUPercent)
+	manager.SetCPUAffinity(opts.CPUAffinity)
+	manager.SetStratumData("00000000", 4, 1)
+	defer manager.StopAll()
+
+	for i := 0; i < opts.Workers; i++ {
+		if _, err := manager.AddDeviceWorker("", opts.Device); err != nil {
+			return nil, err
+		}
+	}
+
+	job := benchJob("hashrate")
+	job.NTime = hashrateNTime
+	manager.BroadcastJob(job)
+	time.Sleep(hashrateWarmup)
+
+	workers := manager.GetAllWorkers()
+	sort.Slice(workers, func(i, j int) bool { return workers[i].ID < workers[j].ID })
+	before := make([]uint64, len(workers))
+	for i, w := range workers {
+		if !w.IsRunning() {
+			return nil, fmt.Errorf("worker %d could not start on %s", w.ID, w.Device)
+		}
+		before[i] = w.GetHashCount()
+	}
+
+	start := time.Now()
+	time.Sleep(time.Duration(opts.Seconds) * time.Second)
+	elapsed := time.Since(start).Seconds()
+
+	result := &HashrateResult{
+		StartedAt:  start,
+		Seconds:    elapsed,
+		Options:    opts,
+		GoMaxProcs: runtime.GOMAXPROCS(0),
+		Backend:    miner.ActiveHashBackend(),
+		Workers:    make([]WorkerHashrate, 0, len(workers)),
+	}
+	for i, w := range workers {
+		hashes := w.GetHashCount() - before[i]
+		result.Workers = append(result.Workers, WorkerHashrate{
+			ID:       w.ID,
+			Device:   w.Device,
+			CPU:      w.GetCPU(),
+			Hashes:   hashes,
+			Hashrate: float64(hashes) / elapsed,
+		})
+		result.Hashes += hashes
+	}
+	result.Hashrate = float64(result.Hashes) / elapsed
+	return result, nil
+}
+
+// normalizeHashrate applies defaults and limits
+func normalizeHashrate(opts HashrateOptions) HashrateOptions {
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+	if opts.Seconds <= 0 {
+		opts.Seconds = defaultHashrateSeconds
+	}
+	if opts.CPUPercent <= 0 || opts.CPUPercent > 100 {
+		opts.CPUPercent = 100
+	}
+	if opts.Device == "" {
+		opts.Device = miner.DeviceCPU
+	}
+
+	opts.Workers = min(opts.Workers, maxWorkers)
+	opts.Seconds = min(opts.Seconds, maxHashrateSeconds)
+	return opts
+}
@@ -0,0 +1,43 @@
+// Package clockwindow matches times against daily "HH:MM" windows, which
+// the tariff price schedule and the mining schedule both use.
+package clockwindow
+
+import "time"
+
+// Parse parses "HH:MM" into minutes since midnight
+func Parse(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Contains reports whether t falls in the window from start to end.
+// Windows may span midnight (22:00-07:00) and equal start and end cover
+// the whole day. onDay reports whether the window runs on the day it
+// starts, so the early part of a midnight-spanning window is checked
+// against the day before.
+func Contains(start, end string, t time.Time, onDay func(time.Weekday) bool) (bool, error) {
+	from, err := Parse(start)
+	if err != nil {
+		return false, err
+	}
+	to, err := Parse(end)
+	if err != nil {
+		return false, err
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	today := t.Weekday()
+	yesterday := t.AddDate(0, 0, -1).Weekday()
+
+	switch {
+	case from < to:
+		return minute >= from && minute < to && onDay(today), nil
+	case from > to:
+		return (minute >= from && onDay(today)) || (minute < to && onDay(yesterday)), nil
+	default:
+		return onDay(today), nil
+	}
+}
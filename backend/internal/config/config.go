@@ -3,9 +3,48 @@ package config
 import (
 	"encoding/json"
 	"os"
+	"sort"
+	"strings"
 	"sync"
 )
 
+// Job expiry actions
+const (
+	JobExpiryReconnect = "reconnect"
+	JobExpiryAlert     = "alert"
+)
+
+// Mining thread priorities
+const (
+	MiningPriorityNormal = "normal"
+	MiningPriorityLow    = "low"
+	MiningPriorityIdle   = "idle"
+)
+
+// PoolConfig describes a backup mining pool
+type PoolConfig struct {
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	Port     int    `json:"port"`
+	Priority int    `json:"priority"`
+}
+
+// TariffWindow is a time-of-use electricity price period
+type TariffWindow struct {
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+	Price float64  `json:"price"`
+	Days  []string `json:"days,omitempty"`
+}
+
+// ScheduleRule is a time-of-day window to mine in
+type ScheduleRule struct {
+	ID    string   `json:"id"`
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+	Days  []string `json:"days,omitempty"`
+}
+
 // Config holds the application configuration
 type Config struct {
 	mu sync.RWMutex
@@ -14,22 +53,172 @@ type Config struct {
 	PoolURL  string `json:"pool_url"`
 	PoolPort int    `json:"pool_port"`
 
+	// Failover settings, backups are tried in ascending priority
+	BackupPools       []PoolConfig `json:"backup_pools"`
+	FailoverThreshold int          `json:"failover_threshold"`
+	FailbackSeconds   int          `json:"failback_seconds"`
+	StandbyEnabled    bool         `json:"standby_enabled"`
+
+	// Mine against the embedded mock pool instead of the pools above, for
+	// offline testing and development
+	MockPool bool `json:"mock_pool"`
+
+	// Seconds without any pool message before the connection is
+	// considered dead, 0 disables the check
+	IdleTimeoutSeconds int `json:"idle_timeout_seconds"`
+
+	// Seconds without a new job before the current one is stale and no
+	// longer submitted against, 0 disables expiry. JobExpiryAction is
+	// "reconnect" to fetch fresh work or "alert" to only notify.
+	JobExpirySeconds int    `json:"job_expiry_seconds"`
+	JobExpiryAction  string `json:"job_expiry_action"`
+
+	// Seconds a connection may go without any job, even a repeated one,
+	// before it is torn down and reconnected, 0 disables the watchdog
+	NoJobTimeoutSeconds int `json:"no_job_timeout_seconds"`
+
+	// SOCKS5 proxy for the pool connection, e.g. socks5://127.0.0.1:9050
+	ProxyURL string `json:"proxy_url"`
+
+	// Pool connection tuning: dial timeout, TCP keepalive period (0
+	// disables keepalive), TCP_NODELAY and the interface name or local IP
+	// to dial from, empty lets the OS choose
+	DialTimeoutSeconds int    `json:"dial_timeout_seconds"`
+	KeepAliveSeconds   int    `json:"keepalive_seconds"`
+	TCPNoDelay         bool   `json:"tcp_nodelay"`
+	SourceInterface    string `json:"source_interface"`
+
 	// Wallet
 	WalletAddress string `json:"wallet_address"`
 
+	// Rig name appended to the wallet as wallet.worker, so pool dashboards
+	// tell rigs apart; empty authorizes with the bare wallet
+	WorkerName string `json:"worker_name"`
+
 	// Mining settings
 	MaxCPUPercent int `json:"max_cpu_percent"`
-	NumWorkers    int `json:"num_workers"`
+	// 0 starts one worker per CPU, "auto" through Update
+	NumWorkers int `json:"num_workers"`
+	// With num_workers 0, count physical cores instead of logical CPUs
+	AutoWorkersPhysical bool `json:"auto_workers_physical"`
+
+	// While mining, add workers when CPUs sit idle by the load average
+	// and remove them when other processes need the CPU, within min and
+	// max (0 max for one per CPU)
+	AutoscaleEnabled    bool `json:"autoscale_enabled"`
+	AutoscaleMinWorkers int  `json:"autoscale_min_workers"`
+	AutoscaleMaxWorkers int  `json:"autoscale_max_workers"`
+
+	// Pin each worker to its own CPU, physical cores first (Linux only)
+	CPUAffinity bool `json:"cpu_affinity"`
+
+	// Scheduling priority of mining threads: normal, low or idle
+	MiningPriority string `json:"mining_priority"`
+
+	// Lower the CPU percent while the CPU is hotter than this, restoring
+	// it once cooled; 0 disables thermal throttling
+	MaxTempCelsius float64 `json:"max_temp_celsius"`
+
+	// Alert when the p95 time from mining.notify to workers hashing the
+	// job exceeds this many milliseconds, 0 disables the alert
+	JobLatencyAlertMs int `json:"job_latency_alert_ms"`
+
+	// Switch to the reference SHA-256 implementation when sampled hashes
+	// keep disagreeing with it
+	HashCheckFallback bool `json:"hash_check_fallback"`
+
+	// Above ShareSampleThreshold shares per second, the share history
+	// keeps only 1 in ShareSampleOneIn low-difficulty shares while counters
+	// stay exact; 0 disables sampling
+	ShareSampleThreshold float64 `json:"share_sample_threshold"`
+	ShareSampleOneIn     int     `json:"share_sample_one_in"`
+
+	// Shares at or above this difficulty are kept with their full header
+	// and coinbase as block candidates, 0 disables it
+	CandidateMinDifficulty float64 `json:"candidate_min_difficulty"`
+
+	// Shares reaching this percent of the network difficulty without
+	// solving a block are announced as near misses, 0 disables it
+	NearMissPercent float64 `json:"near_miss_percent"`
+
+	// NTP server the local clock is checked against, empty disables the
+	// check. With NTimeCorrection, workers roll ntime using the corrected
+	// clock, within bounds pools accept.
+	NTPServer       string `json:"ntp_server"`
+	NTimeCorrection bool   `json:"ntime_correction"`
+
+	// Stream every share and block event as NDJSON to an http(s) URL or
+	// unix:///path/to.sock, empty disables the firehose
+	FirehoseURL string `json:"firehose_url"`
+
+	// Desired seconds between shares, used to suggest a share difficulty
+	// from the local hashrate; 0 leaves the pool default
+	TargetShareSeconds int `json:"target_share_seconds"`
+
+	// Local port for the Stratum proxy that LAN ASICs can mine through,
+	// 0 disables it
+	StratumServerPort int `json:"stratum_server_port"`
+
+	// Serve classic getwork at /getwork for legacy USB miners
+	GetworkEnabled bool `json:"getwork_enabled"`
+
+	// Stats persistence: "file" (StorageDSN is the data directory) or
+	// "postgres" (StorageDSN is a connection string). StorageInstance keys
+	// this dashboard's rows when several share one database.
+	StorageDriver   string `json:"storage_driver"`
+	StorageDSN      string `json:"storage_dsn"`
+	StorageInstance string `json:"storage_instance"`
+
+	// When another instance holds the file store's data directory, run
+	// with its stats read-only instead of refusing to start
+	StorageReadOnlyFallback bool `json:"storage_read_only_fallback"`
+
+	// Power draw estimate in watts for efficiency reporting, 0 uses RAPL
+	PowerWatts float64 `json:"power_watts"`
+
+	// Energy tariff, mining throttles or pauses above the price thresholds
+	TariffWindows         []TariffWindow `json:"tariff_windows"`
+	TariffDefaultPrice    float64        `json:"tariff_default_price"`
+	TariffPriceURL        string         `json:"tariff_price_url"`
+	TariffThrottlePrice   float64        `json:"tariff_throttle_price"`
+	TariffPausePrice      float64        `json:"tariff_pause_price"`
+	TariffThrottlePercent int            `json:"tariff_throttle_percent"`
+
+	// Mining starts and stops with these windows, empty leaves it to the
+	// operator
+	MiningSchedule []ScheduleRule `json:"mining_schedule"`
 }
 
 // DefaultConfig returns a config with sensible defaults
 func DefaultConfig() *Config {
 	return &Config{
-		PoolURL:       "solo.ckpool.org",
-		PoolPort:      3333,
-		WalletAddress: "1FngDUBvDhPh9z3paCRHFEtHjnUMAFacn9",
-		MaxCPUPercent: 80,
-		NumWorkers:    4,
+		PoolURL:               "solo.ckpool.org",
+		PoolPort:              3333,
+		BackupPools:           []PoolConfig{},
+		FailoverThreshold:     3,
+		FailbackSeconds:       60,
+		IdleTimeoutSeconds:    300,
+		JobExpirySeconds:      300,
+		JobExpiryAction:       JobExpiryReconnect,
+		NoJobTimeoutSeconds:   600,
+		DialTimeoutSeconds:    30,
+		KeepAliveSeconds:      30,
+		TCPNoDelay:            true,
+		StorageDriver:         "file",
+		StorageDSN:            "/app/data",
+		WalletAddress:         "1FngDUBvDhPh9z3paCRHFEtHjnUMAFacn9",
+		MaxCPUPercent:         80,
+		NumWorkers:            4,
+		AutoscaleMinWorkers:   1,
+		MiningPriority:        MiningPriorityNormal,
+		TargetShareSeconds:    30,
+		JobLatencyAlertMs:     250,
+		ShareSampleOneIn:      10,
+		NearMissPercent:       1,
+		NTPServer:             "pool.ntp.org",
+		TariffWindows:         []TariffWindow{},
+		TariffThrottlePercent: 30,
+		MiningSchedule:        []ScheduleRule{},
 	}
 }
 
@@ -79,6 +268,110 @@ func (c *Config) GetPoolPort() int {
 	return c.PoolPort
 }
 
+// GetBackupPools returns the backup pools sorted by priority
+func (c *Config) GetBackupPools() []PoolConfig {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	pools := make([]PoolConfig, len(c.BackupPools))
+	copy(pools, c.BackupPools)
+	sort.SliceStable(pools, func(i, j int) bool {
+		return pools[i].Priority < pools[j].Priority
+	})
+	return pools
+}
+
+// GetFailoverThreshold returns the consecutive failures before failover
+func (c *Config) GetFailoverThreshold() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.FailoverThreshold
+}
+
+// GetFailbackSeconds returns how often the primary is probed for failback
+func (c *Config) GetFailbackSeconds() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.FailbackSeconds
+}
+
+// GetStandbyEnabled returns whether a warm standby connection is kept
+func (c *Config) GetStandbyEnabled() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.StandbyEnabled
+}
+
+// GetMockPool returns whether mining uses the embedded mock pool
+func (c *Config) GetMockPool() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.MockPool
+}
+
+// GetIdleTimeoutSeconds returns the dead-connection timeout
+func (c *Config) GetIdleTimeoutSeconds() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.IdleTimeoutSeconds
+}
+
+// GetNoJobTimeoutSeconds returns the no-job watchdog timeout
+func (c *Config) GetNoJobTimeoutSeconds() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.NoJobTimeoutSeconds
+}
+
+// GetJobExpirySeconds returns the job expiry thread-safely
+func (c *Config) GetJobExpirySeconds() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.JobExpirySeconds
+}
+
+// GetJobExpiryAction returns what to do about a stale job thread-safely
+func (c *Config) GetJobExpiryAction() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.JobExpiryAction
+}
+
+// GetProxyURL returns the pool connection proxy thread-safely
+func (c *Config) GetProxyURL() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ProxyURL
+}
+
+// GetDialTimeoutSeconds returns the pool dial timeout thread-safely
+func (c *Config) GetDialTimeoutSeconds() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.DialTimeoutSeconds
+}
+
+// GetKeepAliveSeconds returns the TCP keepalive period thread-safely
+func (c *Config) GetKeepAliveSeconds() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.KeepAliveSeconds
+}
+
+// GetTCPNoDelay returns whether Nagle's algorithm is disabled
+func (c *Config) GetTCPNoDelay() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.TCPNoDelay
+}
+
+// GetSourceInterface returns the interface or IP to dial from
+func (c *Config) GetSourceInterface() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.SourceInterface
+}
+
 // GetWalletAddress returns the wallet address thread-safely
 func (c *Config) GetWalletAddress() string {
 	c.mu.RLock()
@@ -86,6 +379,26 @@ func (c *Config) GetWalletAddress() string {
 	return c.WalletAddress
 }
 
+// GetWorkerName returns the rig name thread-safely
+func (c *Config) GetWorkerName() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.WorkerName
+}
+
+// GetStratumUsername returns the pool username, wallet.worker when a
+// worker name is set
+func (c *Config) GetStratumUsername() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	worker := strings.TrimSpace(c.WorkerName)
+	if worker == "" || c.WalletAddress == "" {
+		return c.WalletAddress
+	}
+	return c.WalletAddress + "." + worker
+}
+
 // GetMaxCPUPercent returns the max CPU percentage thread-safely
 func (c *Config) GetMaxCPUPercent() int {
 	c.mu.RLock()
@@ -100,6 +413,233 @@ func (c *Config) GetNumWorkers() int {
 	return c.NumWorkers
 }
 
+// GetAutoWorkersPhysical returns whether an automatic worker count skips
+// hyperthread siblings
+func (c *Config) GetAutoWorkersPhysical() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.AutoWorkersPhysical
+}
+
+// GetAutoscaleEnabled returns whether workers scale with the load
+// average thread-safely
+func (c *Config) GetAutoscaleEnabled() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.AutoscaleEnabled
+}
+
+// GetAutoscaleMinWorkers returns the autoscaler's lower bound
+// thread-safely
+func (c *Config) GetAutoscaleMinWorkers() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.AutoscaleMinWorkers
+}
+
+// GetAutoscaleMaxWorkers returns the autoscaler's upper bound, 0 for one
+// per CPU, thread-safely
+func (c *Config) GetAutoscaleMaxWorkers() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.AutoscaleMaxWorkers
+}
+
+// GetCPUAffinity returns whether workers are pinned to CPUs
+func (c *Config) GetCPUAffinity() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.CPUAffinity
+}
+
+// GetMiningPriority returns the mining thread priority thread-safely
+func (c *Config) GetMiningPriority() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.MiningPriority
+}
+
+// GetMaxTempCelsius returns the thermal throttling limit thread-safely
+func (c *Config) GetMaxTempCelsius() float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.MaxTempCelsius
+}
+
+// GetStorage returns the stats persistence driver, DSN and instance name
+func (c *Config) GetStorage() (driver, dsn, instance string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.StorageDriver, c.StorageDSN, c.StorageInstance
+}
+
+// GetStorageReadOnlyFallback returns whether a locked data directory is
+// opened read-only rather than treated as an error
+func (c *Config) GetStorageReadOnlyFallback() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.StorageReadOnlyFallback
+}
+
+// GetJobLatencyAlertMs returns the job latency alert threshold
+func (c *Config) GetJobLatencyAlertMs() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.JobLatencyAlertMs
+}
+
+// GetShareSampleThreshold returns the share rate above which share
+// bookkeeping is sampled
+func (c *Config) GetShareSampleThreshold() float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ShareSampleThreshold
+}
+
+// GetShareSampleOneIn returns how many low-difficulty shares one recorded
+// share stands for while sampling
+func (c *Config) GetShareSampleOneIn() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ShareSampleOneIn
+}
+
+// GetNearMissPercent returns the share of the network difficulty a near
+// miss reaches thread-safely
+func (c *Config) GetNearMissPercent() float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.NearMissPercent
+}
+
+// GetCandidateMinDifficulty returns the difficulty from which shares are
+// kept as block candidates
+func (c *Config) GetCandidateMinDifficulty() float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.CandidateMinDifficulty
+}
+
+// GetNTPServer returns the NTP server the clock is checked against
+func (c *Config) GetNTPServer() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.NTPServer
+}
+
+// GetNTimeCorrection returns whether workers roll ntime with the
+// NTP-corrected clock
+func (c *Config) GetNTimeCorrection() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.NTimeCorrection
+}
+
+// GetFirehoseURL returns where share and block events are streamed
+func (c *Config) GetFirehoseURL() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.FirehoseURL
+}
+
+// GetHashCheckFallback returns whether unstable hashing falls back to the
+// reference implementation
+func (c *Config) GetHashCheckFallback() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.HashCheckFallback
+}
+
+// GetTargetShareSeconds returns the desired time between shares
+func (c *Config) GetTargetShareSeconds() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.TargetShareSeconds
+}
+
+// GetGetworkEnabled returns whether the getwork bridge is served
+func (c *Config) GetGetworkEnabled() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.GetworkEnabled
+}
+
+// GetStratumServerPort returns the Stratum proxy port, 0 if disabled
+func (c *Config) GetStratumServerPort() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.StratumServerPort
+}
+
+// GetPowerWatts returns the configured power draw thread-safely
+func (c *Config) GetPowerWatts() float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.PowerWatts
+}
+
+// GetTariffWindows returns a copy of the tariff windows
+func (c *Config) GetTariffWindows() []TariffWindow {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	windows := make([]TariffWindow, len(c.TariffWindows))
+	copy(windows, c.TariffWindows)
+	return windows
+}
+
+// GetMiningSchedule returns a copy of the mining schedule
+func (c *Config) GetMiningSchedule() []ScheduleRule {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	rules := make([]ScheduleRule, len(c.MiningSchedule))
+	copy(rules, c.MiningSchedule)
+	return rules
+}
+
+// SetMiningSchedule replaces the mining schedule
+func (c *Config) SetMiningSchedule(rules []ScheduleRule) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.MiningSchedule = rules
+}
+
+// GetTariffDefaultPrice returns the price used outside tariff windows
+func (c *Config) GetTariffDefaultPrice() float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.TariffDefaultPrice
+}
+
+// GetTariffPriceURL returns the dynamic price API URL
+func (c *Config) GetTariffPriceURL() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.TariffPriceURL
+}
+
+// GetTariffThrottlePrice returns the price at which mining is throttled
+func (c *Config) GetTariffThrottlePrice() float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.TariffThrottlePrice
+}
+
+// GetTariffPausePrice returns the price at which mining is paused
+func (c *Config) GetTariffPausePrice() float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.TariffPausePrice
+}
+
+// GetTariffThrottlePercent returns the CPU percentage used while throttled
+func (c *Config) GetTariffThrottlePercent() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.TariffThrottlePercent
+}
+
 // Update updates the configuration with new values
 func (c *Config) Update(updates map[string]interface{}) {
 	c.mu.Lock()
@@ -111,13 +651,232 @@ func (c *Config) Update(updates map[string]interface{}) {
 	if v, ok := updates["pool_port"].(float64); ok {
 		c.PoolPort = int(v)
 	}
+	if v, ok := updates["backup_pools"].([]interface{}); ok {
+		c.BackupPools = parsePools(v)
+	}
+	if v, ok := updates["failover_threshold"].(float64); ok {
+		c.FailoverThreshold = int(v)
+	}
+	if v, ok := updates["failback_seconds"].(float64); ok {
+		c.FailbackSeconds = int(v)
+	}
+	if v, ok := updates["standby_enabled"].(bool); ok {
+		c.StandbyEnabled = v
+	}
+	if v, ok := updates["mock_pool"].(bool); ok {
+		c.MockPool = v
+	}
+	if v, ok := updates["idle_timeout_seconds"].(float64); ok {
+		c.IdleTimeoutSeconds = int(v)
+	}
+	if v, ok := updates["job_expiry_seconds"].(float64); ok {
+		c.JobExpirySeconds = int(v)
+	}
+	if v, ok := updates["no_job_timeout_seconds"].(float64); ok && v >= 0 {
+		c.NoJobTimeoutSeconds = int(v)
+	}
+	if v, ok := updates["job_expiry_action"].(string); ok && (v == JobExpiryReconnect || v == JobExpiryAlert) {
+		c.JobExpiryAction = v
+	}
+	if v, ok := updates["proxy_url"].(string); ok {
+		c.ProxyURL = v
+	}
+	if v, ok := updates["dial_timeout_seconds"].(float64); ok && v > 0 {
+		c.DialTimeoutSeconds = int(v)
+	}
+	if v, ok := updates["keepalive_seconds"].(float64); ok {
+		c.KeepAliveSeconds = int(v)
+	}
+	if v, ok := updates["tcp_nodelay"].(bool); ok {
+		c.TCPNoDelay = v
+	}
+	if v, ok := updates["source_interface"].(string); ok {
+		c.SourceInterface = strings.TrimSpace(v)
+	}
 	if v, ok := updates["wallet_address"].(string); ok {
 		c.WalletAddress = v
 	}
+	if v, ok := updates["worker_name"].(string); ok {
+		c.WorkerName = v
+	}
 	if v, ok := updates["max_cpu_percent"].(float64); ok {
 		c.MaxCPUPercent = int(v)
 	}
 	if v, ok := updates["num_workers"].(float64); ok {
 		c.NumWorkers = int(v)
 	}
+	if v, ok := updates["num_workers"].(string); ok && v == "auto" {
+		c.NumWorkers = 0
+	}
+	if v, ok := updates["auto_workers_physical"].(bool); ok {
+		c.AutoWorkersPhysical = v
+	}
+	if v, ok := updates["autoscale_enabled"].(bool); ok {
+		c.AutoscaleEnabled = v
+	}
+	if v, ok := updates["autoscale_min_workers"].(float64); ok && v >= 1 {
+		c.AutoscaleMinWorkers = int(v)
+	}
+	if v, ok := updates["autoscale_max_workers"].(float64); ok && v >= 0 {
+		c.AutoscaleMaxWorkers = int(v)
+	}
+	if v, ok := updates["cpu_affinity"].(bool); ok {
+		c.CPUAffinity = v
+	}
+	if v, ok := updates["mining_priority"].(string); ok && (v == MiningPriorityNormal || v == MiningPriorityLow || v == MiningPriorityIdle) {
+		c.MiningPriority = v
+	}
+	if v, ok := updates["max_temp_celsius"].(float64); ok && v >= 0 {
+		c.MaxTempCelsius = v
+	}
+	if v, ok := updates["job_latency_alert_ms"].(float64); ok {
+		c.JobLatencyAlertMs = int(v)
+	}
+	if v, ok := updates["hash_check_fallback"].(bool); ok {
+		c.HashCheckFallback = v
+	}
+	if v, ok := updates["share_sample_threshold"].(float64); ok && v >= 0 {
+		c.ShareSampleThreshold = v
+	}
+	if v, ok := updates["share_sample_one_in"].(float64); ok && v >= 1 {
+		c.ShareSampleOneIn = int(v)
+	}
+	if v, ok := updates["candidate_min_difficulty"].(float64); ok && v >= 0 {
+		c.CandidateMinDifficulty = v
+	}
+	if v, ok := updates["near_miss_percent"].(float64); ok && v >= 0 && v < 100 {
+		c.NearMissPercent = v
+	}
+	if v, ok := updates["ntp_server"].(string); ok {
+		c.NTPServer = v
+	}
+	if v, ok := updates["ntime_correction"].(bool); ok {
+		c.NTimeCorrection = v
+	}
+	if v, ok := updates["firehose_url"].(string); ok {
+		c.FirehoseURL = v
+	}
+	if v, ok := updates["target_share_seconds"].(float64); ok {
+		c.TargetShareSeconds = int(v)
+	}
+	if v, ok := updates["stratum_server_port"].(float64); ok {
+		c.StratumServerPort = int(v)
+	}
+	if v, ok := updates["getwork_enabled"].(bool); ok {
+		c.GetworkEnabled = v
+	}
+	if v, ok := updates["power_watts"].(float64); ok {
+		c.PowerWatts = v
+	}
+	if v, ok := updates["tariff_windows"].([]interface{}); ok {
+		c.TariffWindows = parseTariffWindows(v)
+	}
+	if v, ok := updates["mining_schedule"].([]interface{}); ok {
+		c.MiningSchedule = parseScheduleRules(v)
+	}
+	if v, ok := updates["tariff_default_price"].(float64); ok {
+		c.TariffDefaultPrice = v
+	}
+	if v, ok := updates["tariff_price_url"].(string); ok {
+		c.TariffPriceURL = v
+	}
+	if v, ok := updates["tariff_throttle_price"].(float64); ok {
+		c.TariffThrottlePrice = v
+	}
+	if v, ok := updates["tariff_pause_price"].(float64); ok {
+		c.TariffPausePrice = v
+	}
+	if v, ok := updates["tariff_throttle_percent"].(float64); ok {
+		c.TariffThrottlePercent = int(v)
+	}
+}
+
+// parsePools converts a decoded JSON array into pool configs, skipping
+// entries without a URL
+func parsePools(raw []interface{}) []PoolConfig {
+	pools := make([]PoolConfig, 0, len(raw))
+	for _, item := range raw {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		var p PoolConfig
+		p.Name, _ = m["name"].(string)
+		p.URL, _ = m["url"].(string)
+		if v, ok := m["port"].(float64); ok {
+			p.Port = int(v)
+		}
+		if v, ok := m["priority"].(float64); ok {
+			p.Priority = int(v)
+		}
+
+		if p.URL == "" {
+			continue
+		}
+		if p.Name == "" {
+			p.Name = p.URL
+		}
+		pools = append(pools, p)
+	}
+	return pools
+}
+
+// parseScheduleRules converts a decoded JSON array into schedule rules.
+// Rules without an ID are given one by the API.
+func parseScheduleRules(raw []interface{}) []ScheduleRule {
+	rules := make([]ScheduleRule, 0, len(raw))
+	for _, item := range raw {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		var r ScheduleRule
+		r.ID, _ = m["id"].(string)
+		r.Start, _ = m["start"].(string)
+		r.End, _ = m["end"].(string)
+		if days, ok := m["days"].([]interface{}); ok {
+			for _, d := range days {
+				if day, ok := d.(string); ok {
+					r.Days = append(r.Days, day)
+				}
+			}
+		}
+
+		if r.Start == "" || r.End == "" {
+			continue
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// parseTariffWindows converts a decoded JSON array into tariff windows
+func parseTariffWindows(raw []interface{}) []TariffWindow {
+	windows := make([]TariffWindow, 0, len(raw))
+	for _, item := range raw {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		var w TariffWindow
+		w.Start, _ = m["start"].(string)
+		w.End, _ = m["end"].(string)
+		w.Price, _ = m["price"].(float64)
+		if days, ok := m["days"].([]interface{}); ok {
+			for _, d := range days {
+				if day, ok := d.(string); ok {
+					w.Days = append(w.Days, day)
+				}
+			}
+		}
+
+		if w.Start == "" || w.End == "" {
+			continue
+		}
+		windows = append(windows, w)
+	}
+	return windows
 }
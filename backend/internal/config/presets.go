@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MiningPreset is a named mining intensity: how many workers to run, how
+// hard each works and how hot the CPU may get
+type MiningPreset struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Workers per CPU, 0 for one per CPU through num_workers "auto"
+	WorkersPerCPU  float64 `json:"workers_per_cpu"`
+	MaxCPUPercent  int     `json:"max_cpu_percent"`
+	MaxTempCelsius float64 `json:"max_temp_celsius"`
+}
+
+// miningPresets lists the presets, gentlest first
+var miningPresets = []MiningPreset{
+	{
+		Name:           "eco",
+		Description:    "Quiet and cool: a quarter of the CPUs at half speed",
+		WorkersPerCPU:  0.25,
+		MaxCPUPercent:  50,
+		MaxTempCelsius: 70,
+	},
+	{
+		Name:           "balanced",
+		Description:    "Half the CPUs, leaving room for other work",
+		WorkersPerCPU:  0.5,
+		MaxCPUPercent:  80,
+		MaxTempCelsius: 80,
+	},
+	{
+		Name:           "max",
+		Description:    "Every CPU flat out, throttled only by temperature",
+		MaxCPUPercent:  100,
+		MaxTempCelsius: 90,
+	},
+}
+
+// MiningPresets returns the mining intensity presets
+func MiningPresets() []MiningPreset {
+	presets := make([]MiningPreset, len(miningPresets))
+	copy(presets, miningPresets)
+	return presets
+}
+
+// Workers returns the worker count the preset runs on numCPU CPUs, 0
+// meaning one per CPU
+func (p MiningPreset) Workers(numCPU int) int {
+	if p.WorkersPerCPU == 0 {
+		return 0
+	}
+	return max(1, int(float64(numCPU)*p.WorkersPerCPU))
+}
+
+// PresetUpdates returns the config updates applying the named preset on
+// numCPU CPUs, to pass to Update in one call
+func PresetUpdates(name string, numCPU int) (map[string]interface{}, error) {
+	for _, p := range miningPresets {
+		if !strings.EqualFold(p.Name, name) {
+			continue
+		}
+
+		updates := map[string]interface{}{
+			"max_cpu_percent":  float64(p.MaxCPUPercent),
+			"max_temp_celsius": p.MaxTempCelsius,
+		}
+		if n := p.Workers(numCPU); n > 0 {
+			updates["num_workers"] = float64(n)
+		} else {
+			updates["num_workers"] = "auto"
+		}
+		return updates, nil
+	}
+	return nil, fmt.Errorf("unknown preset %q", name)
+}
+
+// CurrentPreset returns the name of the preset the config matches on
+// numCPU CPUs, or "" when it has been tuned by hand
+func (c *Config) CurrentPreset(numCPU int) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, p := range miningPresets {
+		if c.NumWorkers == p.Workers(numCPU) && c.MaxCPUPercent == p.MaxCPUPercent && c.MaxTempCelsius == p.MaxTempCelsius {
+			return p.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// When a changed setting takes effect, least to most disruptive
+const (
+	// ApplyHot settings take effect immediately
+	ApplyHot = "hot"
+	// ApplySession settings take effect the next time mining starts
+	ApplySession = "session"
+	// ApplyReconnect settings take effect on the next pool connection,
+	// made when mining is restarted
+	ApplyReconnect = "reconnect"
+	// ApplyRestart settings are only read at startup and can't be changed
+	// through Update; edit the config file and restart
+	ApplyRestart = "restart"
+)
+
+// applyOrder ranks the apply modes by disruption
+var applyOrder = map[string]int{
+	ApplyHot:       1,
+	ApplySession:   2,
+	ApplyReconnect: 3,
+	ApplyRestart:   4,
+}
+
+// applyModes says when each setting takes effect, keyed by JSON name
+var applyModes = map[string]string{
+	"pool_url":                   ApplyReconnect,
+	"pool_port":                  ApplyReconnect,
+	"backup_pools":               ApplyReconnect,
+	"failover_threshold":         ApplyReconnect,
+	"failback_seconds":           ApplyReconnect,
+	"standby_enabled":            ApplyReconnect,
+	"mock_pool":                  ApplyReconnect,
+	"idle_timeout_seconds":       ApplyReconnect,
+	"job_expiry_seconds":         ApplyReconnect,
+	"job_expiry_action":          ApplyHot,
+	"no_job_timeout_seconds":     ApplyReconnect,
+	"proxy_url":                  ApplyReconnect,
+	"dial_timeout_seconds":       ApplyReconnect,
+	"keepalive_seconds":          ApplyReconnect,
+	"tcp_nodelay":                ApplyReconnect,
+	"source_interface":           ApplyReconnect,
+	"wallet_address":             ApplyReconnect,
+	"worker_name":                ApplyReconnect,
+	"max_cpu_percent":            ApplyHot,
+	"num_workers":                ApplySession,
+	"auto_workers_physical":      ApplySession,
+	"autoscale_enabled":          ApplyHot,
+	"autoscale_min_workers":      ApplyHot,
+	"autoscale_max_workers":      ApplyHot,
+	"cpu_affinity":               ApplyHot,
+	"mining_priority":            ApplySession,
+	"max_temp_celsius":           ApplyHot,
+	"job_latency_alert_ms":       ApplyHot,
+	"hash_check_fallback":        ApplyHot,
+	"share_sample_threshold":     ApplyHot,
+	"share_sample_one_in":        ApplyHot,
+	"candidate_min_difficulty":   ApplyHot,
+	"near_miss_percent":          ApplyHot,
+	"ntp_server":                 ApplyHot,
+	"ntime_correction":           ApplyHot,
+	"firehose_url":               ApplyHot,
+	"target_share_seconds":       ApplyHot,
+	"stratum_server_port":        ApplySession,
+	"getwork_enabled":            ApplyHot,
+	"storage_driver":             ApplyRestart,
+	"storage_dsn":                ApplyRestart,
+	"storage_instance":           ApplyRestart,
+	"storage_read_only_fallback": ApplyRestart,
+	"power_watts":                ApplyHot,
+	"tariff_windows":             ApplySession,
+	"tariff_default_price":       ApplySession,
+	"tariff_price_url":           ApplySession,
+	"tariff_throttle_price":      ApplySession,
+	"tariff_pause_price":         ApplySession,
+	"tariff_throttle_percent":    ApplySession,
+	"mining_schedule":            ApplyHot,
+}
+
+// Change is a setting a proposed update would change
+type Change struct {
+	Key   string      `json:"key"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+	Apply string      `json:"apply"`
+}
+
+// Preview describes what a proposed update would change. Apply is the
+// most disruptive mode among the changes, empty if nothing changes.
+// Ignored lists keys Update would not apply: unknown settings and values
+// it rejects or that don't change anything.
+type Preview struct {
+	Changes []Change `json:"changes"`
+	Ignored []string `json:"ignored"`
+	Apply   string   `json:"apply"`
+}
+
+// Preview returns the changes Update would make, without making them
+func (c *Config) Preview(updates map[string]interface{}) Preview {
+	current := c.fields()
+
+	proposed := DefaultConfig()
+	c.mu.RLock()
+	data, _ := json.Marshal(c)
+	c.mu.RUnlock()
+	json.Unmarshal(data, proposed)
+	proposed.Update(updates)
+	next := proposed.fields()
+
+	keys := make([]string, 0, len(updates))
+	for key := range updates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	p := Preview{Changes: []Change{}, Ignored: []string{}}
+	for key, value := range next {
+		if reflect.DeepEqual(current[key], value) {
+			continue
+		}
+		p.Changes = append(p.Changes, Change{Key: key, From: current[key], To: value, Apply: ApplyMode(key)})
+	}
+
+	for _, key := range keys {
+		if hasChange(p.Changes, key) {
+			continue
+		}
+		// Startup-only settings can't be updated, but still tell the
+		// user a restart would change them
+		old, known := current[key]
+		if known && ApplyMode(key) == ApplyRestart && !reflect.DeepEqual(old, updates[key]) {
+			p.Changes = append(p.Changes, Change{Key: key, From: old, To: updates[key], Apply: ApplyRestart})
+			continue
+		}
+		p.Ignored = append(p.Ignored, key)
+	}
+
+	sort.Slice(p.Changes, func(i, j int) bool { return p.Changes[i].Key < p.Changes[j].Key })
+	for _, change := range p.Changes {
+		if applyOrder[change.Apply] > applyOrder[p.Apply] {
+			p.Apply = change.Apply
+		}
+	}
+	return p
+}
+
+// ApplyMode returns when a setting takes effect, hot for unknown ones
+func ApplyMode(key string) string {
+	if mode, ok := applyModes[key]; ok {
+		return mode
+	}
+	return ApplyHot
+}
+
+// fields returns the settings as their JSON values, keyed by JSON name
+func (c *Config) fields() map[string]interface{} {
+	c.mu.RLock()
+	data, _ := json.Marshal(c)
+	c.mu.RUnlock()
+
+	fields := make(map[string]interface{})
+	json.Unmarshal(data, &fields)
+	return fields
+}
+
+// hasChange reports whether a key is among the changes
+func hasChange(changes []Change, key string) bool {
+	for _, change := range changes {
+		if change.Key == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,4 @@
+// Package cpuid reads x86 CPU features that golang.org/x/sys/cpu doesn't
+// expose. It is kept apart from the miner package, which can't hold Go
+// assembly when built with cgo for OpenCL.
+package cpuid
@@ -0,0 +1,15 @@
+package cpuid
+
+// HasSHA reports whether the CPU supports the SHA extensions, CPUID leaf 7
+// EBX bit 29
+func HasSHA() bool {
+	maxLeaf, _, _, _ := cpuid(0, 0)
+	if maxLeaf < 7 {
+		return false
+	}
+	_, ebx, _, _ := cpuid(7, 0)
+	return ebx&(1<<29) != 0
+}
+
+// cpuid executes the CPUID instruction, implemented in cpuid_amd64.s
+func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
@@ -0,0 +1,8 @@
+//go:build !amd64
+
+package cpuid
+
+// HasSHA reports false, the SHA extensions are x86 only
+func HasSHA() bool {
+	return false
+}
@@ -0,0 +1,286 @@
+// Package firehose streams share and block events as NDJSON to an HTTP
+// endpoint or a local Unix socket, for users feeding their own analytics.
+package firehose
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// queueSize bounds the events waiting to be sent, newer ones are dropped
+// while the sink is slow or down
+const queueSize = 4096
+
+// batchSize and batchInterval bound how long events wait to be sent
+const (
+	batchSize     = 256
+	batchInterval = time.Second
+)
+
+// retryDelay is the pause after a failed delivery
+const retryDelay = 5 * time.Second
+
+// Event is one NDJSON line
+type Event struct {
+	Type string      `json:"type"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data"`
+}
+
+// Status describes the sink
+type Status struct {
+	Target    string    `json:"target,omitempty"`
+	Sent      uint64    `json:"sent"`
+	Dropped   uint64    `json:"dropped"`
+	Queued    int       `json:"queued"`
+	LastError string    `json:"last_error,omitempty"`
+	LastSent  time.Time `json:"last_sent,omitempty"`
+}
+
+// Sink delivers events to the configured target
+type Sink struct {
+	mu sync.Mutex
+
+	target string
+	queue  chan []byte
+	stop   chan struct{}
+	done   chan struct{}
+
+	httpClient *http.Client
+
+	sent      uint64
+	dropped   uint64
+	lastError string
+	lastSent  time.Time
+}
+
+// NewSink creates a disabled sink
+func NewSink() *Sink {
+	return &Sink{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Configure points the sink at an http(s) URL receiving NDJSON POSTs, or
+// unix:///path/to.sock for a stream of lines. An empty target disables
+// the sink. Events queued for a previous target are dropped.
+func (s *Sink) Configure(target string) error {
+	target = strings.TrimSpace(target)
+	if target != "" {
+		if err := validateTarget(target); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	if target == s.target {
+		s.mu.Unlock()
+		return nil
+	}
+	stop, done := s.stop, s.done
+	s.target = target
+	s.stop, s.done, s.queue = nil, nil, nil
+	s.lastError = ""
+	if target != "" {
+		s.queue = make(chan []byte, queueSize)
+		s.stop = make(chan struct{})
+		s.done = make(chan struct{})
+		go s.deliverLoop(target, s.queue, s.stop, s.done)
+	}
+	s.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+	return nil
+}
+
+// Stop disables the sink
+func (s *Sink) Stop() {
+	s.Configure("")
+}
+
+// Publish queues an event, without blocking the caller
+func (s *Sink) Publish(eventType string, data interface{}) {
+	s.mu.Lock()
+	queue := s.queue
+	s.mu.Unlock()
+	if queue == nil {
+		return
+	}
+
+	line, err := json.Marshal(Event{Type: eventType, Time: time.Now(), Data: data})
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	select {
+	case queue <- line:
+	default:
+		s.mu.Lock()
+		s.dropped++
+		s.mu.Unlock()
+	}
+}
+
+// GetStatus returns the sink's target and delivery counters
+func (s *Sink) GetStatus() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Status{
+		Target:    s.target,
+		Sent:      s.sent,
+		Dropped:   s.dropped,
+		Queued:    len(s.queue),
+		LastError: s.lastError,
+		LastSent:  s.lastSent,
+	}
+}
+
+// deliverLoop sends queued events in batches until stop is closed
+func (s *Sink) deliverLoop(target string, queue chan []byte, stop, done chan struct{}) {
+	defer close(done)
+
+	send := s.postBatch
+	var stream *unixStream
+	if path, ok := unixPath(target); ok {
+		stream = &unixStream{path: path}
+		defer stream.close()
+		send = func(_ string, batch []byte) error {
+			return stream.write(batch)
+		}
+	}
+
+	ticker := time.NewTicker(batchInterval)
+	defer ticker.Stop()
+
+	var batch bytes.Buffer
+	count := 0
+	flush := func() {
+		if count == 0 {
+			return
+		}
+		if err := send(target, batch.Bytes()); err != nil {
+			s.recordFailure(count, err)
+			select {
+			case <-stop:
+			case <-time.After(retryDelay):
+			}
+		} else {
+			s.recordSent(count)
+		}
+		batch.Reset()
+		count = 0
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case line := <-queue:
+			batch.Write(line)
+			count++
+			if count >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// postBatch sends a batch of lines in one request
+func (s *Sink) postBatch(target string, batch []byte) error {
+	resp, err := s.httpClient.Post(target, "application/x-ndjson", bytes.NewReader(batch))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("firehose endpoint returned %s", resp.Status)
+	}
+	return nil
+}
+
+// recordSent counts delivered events
+func (s *Sink) recordSent(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent += uint64(n)
+	s.lastSent = time.Now()
+	s.lastError = ""
+}
+
+// recordFailure counts events lost to a failed delivery
+func (s *Sink) recordFailure(n int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dropped += uint64(n)
+	s.lastError = err.Error()
+}
+
+// unixStream is a lazily (re)connected Unix socket
+type unixStream struct {
+	path string
+	conn net.Conn
+}
+
+// write sends data, reconnecting first if needed
+func (u *unixStream) write(data []byte) error {
+	if u.conn == nil {
+		conn, err := net.DialTimeout("unix", u.path, 5*time.Second)
+		if err != nil {
+			return err
+		}
+		u.conn = conn
+	}
+
+	u.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if _, err := u.conn.Write(data); err != nil {
+		u.close()
+		return err
+	}
+	return nil
+}
+
+// close drops the connection
+func (u *unixStream) close() {
+	if u.conn != nil {
+		u.conn.Close()
+		u.conn = nil
+	}
+}
+
+// unixPath returns the socket path of a unix:// target
+func unixPath(target string) (string, bool) {
+	if !strings.HasPrefix(target, "unix://") {
+		return "", false
+	}
+	return strings.TrimPrefix(target, "unix://"), true
+}
+
+// validateTarget checks a target is an http(s) URL or a unix:// path
+func validateTarget(target string) error {
+	if path, ok := unixPath(target); ok {
+		if path == "" {
+			return fmt.Errorf("firehose target %q has no socket path", target)
+		}
+		return nil
+	}
+
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("firehose target must be an http(s) URL or unix:///path, got %q", target)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package importer
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/soloforge/backend/internal/stats"
+)
+
+// RunCLI implements the "import" subcommand:
+//
+//	soloforge import -format ckpool [-source name] sharelog...
+//
+// Shares are merged into the configured stats store. A file store in use
+// by a running server is locked, so the import fails instead of racing it.
+func RunCLI(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(out)
+	format := fs.String("format", FormatCKPool, "log format: ckpool or axeos")
+	source := fs.String("source", "", "source tag for imported shares (default: the format)")
+	driver := fs.String("storage", stats.StoreDriverFile, "storage driver: file or postgres")
+	dsn := fs.String("dsn", "/app/data", "data directory or postgres connection string")
+	instance := fs.String("instance", "", "postgres instance name")
+	history := fs.Int("history", 1000, "share history size")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("no log files given")
+	}
+	if *source == "" {
+		*source = *format
+	}
+
+	store, err := stats.OpenStore(*driver, *dsn, *instance, false)
+	if err != nil {
+		return err
+	}
+	collector := stats.NewCollectorWithStore(*history, store)
+	defer collector.Close()
+
+	total := 0
+	for _, path := range fs.Args() {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		result, err := Parse(*format, file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		added := collector.ImportShares(result.Shares, *source)
+		total += added
+		fmt.Fprintf(out, "%s: %d lines, %d shares, %d new\n", filepath.Base(path), result.Lines, result.Parsed, added)
+	}
+
+	if err := collector.Save(); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Imported %d shares as %q\n", total, *source)
+	return nil
+}
@@ -0,0 +1,158 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/soloforge/backend/internal/stats"
+)
+
+// Supported log formats
+const (
+	FormatCKPool = "ckpool"
+	FormatAxeOS  = "axeos"
+)
+
+// maxLineSize bounds a single log line
+const maxLineSize = 1 << 20
+
+// Result summarizes a parsed log
+type Result struct {
+	Shares  []stats.ShareEntry `json:"-"`
+	Lines   int                `json:"lines"`
+	Parsed  int                `json:"parsed"`
+	Skipped int                `json:"skipped"`
+}
+
+// Parse reads a share log in the given format. Lines that aren't shares
+// (startup messages, other log output) are counted as skipped.
+func Parse(format string, r io.Reader) (*Result, error) {
+	var parseLine func(string) (stats.ShareEntry, bool)
+	switch format {
+	case FormatCKPool:
+		parseLine = parseCKPoolLine
+	case FormatAxeOS:
+		parseLine = parseAxeOSLine
+	default:
+		return nil, fmt.Errorf("unknown log format %q, use %s or %s", format, FormatCKPool, FormatAxeOS)
+	}
+
+	result := &Result{Shares: make([]stats.ShareEntry, 0)}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		result.Lines++
+
+		entry, ok := parseLine(line)
+		if !ok {
+			result.Skipped++
+			continue
+		}
+		result.Shares = append(result.Shares, entry)
+		result.Parsed++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ckpoolShare is one line of a ckpool sharelog
+type ckpoolShare struct {
+	WorkInfoID   interface{} `json:"workinfoid"`
+	Nonce        string      `json:"nonce"`
+	Diff         float64     `json:"diff"`
+	SDiff        float64     `json:"sdiff"`
+	Result       bool        `json:"result"`
+	RejectReason string      `json:"reject-reason"`
+	CreateDate   string      `json:"createdate"`
+	WorkerName   string      `json:"workername"`
+}
+
+// parseCKPoolLine parses a JSON sharelog line. createdate is
+// "seconds,nanoseconds" since the epoch.
+func parseCKPoolLine(line string) (stats.ShareEntry, bool) {
+	var s ckpoolShare
+	if err := json.Unmarshal([]byte(line), &s); err != nil || s.Nonce == "" || s.CreateDate == "" {
+		return stats.ShareEntry{}, false
+	}
+
+	secs, nsecs, _ := strings.Cut(s.CreateDate, ",")
+	sec, err := strconv.ParseInt(secs, 10, 64)
+	if err != nil {
+		return stats.ShareEntry{}, false
+	}
+	nsec, _ := strconv.ParseInt(nsecs, 10, 64)
+
+	entry := stats.ShareEntry{
+		Timestamp:  time.Unix(sec, nsec),
+		WorkerName: s.WorkerName,
+		JobID:      fmt.Sprint(s.WorkInfoID),
+		Nonce:      s.Nonce,
+		Difficulty: s.SDiff,
+		Status:     stats.ShareStatusAccepted,
+	}
+	if !s.Result {
+		entry.Status = stats.ShareStatusRejected
+		entry.Reason = s.RejectReason
+		if strings.Contains(strings.ToLower(s.RejectReason), "stale") {
+			entry.Status = stats.ShareStatusStale
+		}
+	}
+	return entry, true
+}
+
+// axeosResult matches the AxeOS asic_result line, e.g.
+// "ID: 1a2b, ver: 20000000 Nonce 1A2B3C4D diff 1234.5 of 1000."
+var axeosResult = regexp.MustCompile(`Nonce ([0-9A-Fa-f]{8}) diff ([0-9.]+) of ([0-9.]+)`)
+
+// axeosJobID extracts the job ID from the same line
+var axeosJobID = regexp.MustCompile(`ID: ([0-9A-Za-z]+)`)
+
+// parseAxeOSLine parses an asic_result line from an AxeOS log. The device
+// only logs uptime, so a line needs a leading RFC 3339 timestamp, as
+// added by syslog forwarding, to be placed in history.
+func parseAxeOSLine(line string) (stats.ShareEntry, bool) {
+	m := axeosResult.FindStringSubmatch(line)
+	if m == nil {
+		return stats.ShareEntry{}, false
+	}
+
+	stamp, _, _ := strings.Cut(line, " ")
+	ts, err := time.Parse(time.RFC3339, stamp)
+	if err != nil {
+		return stats.ShareEntry{}, false
+	}
+
+	diff, _ := strconv.ParseFloat(m[2], 64)
+	poolDiff, _ := strconv.ParseFloat(strings.TrimSuffix(m[3], "."), 64)
+
+	entry := stats.ShareEntry{
+		Timestamp:  ts,
+		WorkerName: "AxeOS",
+		Nonce:      strings.ToLower(m[1]),
+		Difficulty: diff,
+		Status:     stats.ShareStatusAccepted,
+	}
+	if id := axeosJobID.FindStringSubmatch(line); id != nil {
+		entry.JobID = id[1]
+	}
+
+	// Results below the pool difficulty are never submitted
+	if diff < poolDiff {
+		return stats.ShareEntry{}, false
+	}
+	return entry, true
+}
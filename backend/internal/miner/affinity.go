@@ -0,0 +1,85 @@
+package miner
+
+import (
+	"log"
+	"runtime"
+	"sort"
+)
+
+// noCPU means a worker runs on whatever CPU the scheduler picks
+const noCPU = -1
+
+// AutoWorkerCount returns how many workers an automatic worker count
+// starts: one per CPU the process may use, or per physical core when
+// physical is set and the topology is known
+func AutoWorkerCount(physical bool) int {
+	if physical {
+		if n := physicalCores(); n > 0 {
+			return n
+		}
+	}
+	return runtime.NumCPU()
+}
+
+// SetCPUAffinity pins each worker to its own CPU, or unpins them all.
+// Workers apply the change before their next batch.
+func (m *Manager) SetCPUAffinity(enabled bool) {
+	m.mu.Lock()
+	m.cpuAffinity = enabled
+	m.mu.Unlock()
+
+	m.assignCPUs()
+}
+
+// assignCPUs gives each worker, ordered by ID, the next CPU from
+// affinityCPUs, wrapping when there are more workers than CPUs
+func (m *Manager) assignCPUs() {
+	m.partitionMu.Lock()
+	defer m.partitionMu.Unlock()
+
+	m.mu.RLock()
+	enabled := m.cpuAffinity
+	m.mu.RUnlock()
+
+	var cpus []int
+	if enabled {
+		cpus = affinityCPUs()
+	}
+
+	workers := m.GetAllWorkers()
+	sort.Slice(workers, func(i, j int) bool { return workers[i].ID < workers[j].ID })
+	for i, w := range workers {
+		cpu := noCPU
+		if len(cpus) > 0 {
+			cpu = cpus[i%len(cpus)]
+		}
+		w.SetCPU(cpu)
+	}
+}
+
+// SetCPU sets the CPU the worker is pinned to, noCPU for none
+func (w *Worker) SetCPU(cpu int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.cpu = cpu
+}
+
+// GetCPU returns the CPU the worker is pinned to, -1 for none
+func (w *Worker) GetCPU() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.cpu
+}
+
+// pinCPU moves the mining goroutine's thread onto cpu, or back onto every
+// CPU for noCPU. The goroutine stays locked to its thread from the first
+// pin, so the mask never reaches other goroutines: the thread exits with
+// the mining loop instead of returning to the scheduler.
+func (w *Worker) pinCPU(cpu int) {
+	if cpu != noCPU {
+		runtime.LockOSThread()
+	}
+	if err := setThreadAffinity(cpu); err != nil {
+		log.Printf("Worker %d: CPU affinity: %v", w.ID, err)
+	}
+}
@@ -0,0 +1,83 @@
+package miner
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// processCPUs is the set of CPUs the process may run on, read at startup
+// before any thread is pinned
+var processCPUs = func() unix.CPUSet {
+	var set unix.CPUSet
+	if err := unix.SchedGetaffinity(0, &set); err != nil {
+		set.Zero()
+	}
+	return set
+}()
+
+// affinityCPUs returns the CPUs the process may run on, one per physical
+// core first and their hyperthread siblings after, so workers share a
+// core only when there are more workers than cores
+func affinityCPUs() []int {
+	cores, siblings := splitCores()
+	return append(cores, siblings...)
+}
+
+// physicalCores returns how many physical cores the process may run on
+func physicalCores() int {
+	cores, _ := splitCores()
+	return len(cores)
+}
+
+// splitCores divides the CPUs the process may run on into the first of
+// each physical core and their hyperthread siblings
+func splitCores() (cores, siblings []int) {
+	for cpu := 0; cpu < len(processCPUs)*64; cpu++ {
+		if !processCPUs.IsSet(cpu) {
+			continue
+		}
+		if firstSibling(cpu) == cpu {
+			cores = append(cores, cpu)
+		} else {
+			siblings = append(siblings, cpu)
+		}
+	}
+	sort.Ints(cores)
+	sort.Ints(siblings)
+	return cores, siblings
+}
+
+// firstSibling returns the lowest numbered CPU sharing cpu's physical
+// core, or cpu itself when the topology is unknown
+func firstSibling(cpu int) int {
+	data, err := os.ReadFile(fmt.Sprintf("/sys/devices/system/cpu/cpu%d/topology/thread_siblings_list", cpu))
+	if err != nil {
+		return cpu
+	}
+	// A list such as "0,4" or "0-1", lowest first
+	first := strings.FieldsFunc(strings.TrimSpace(string(data)), func(r rune) bool { return r == ',' || r == '-' })
+	if len(first) == 0 {
+		return cpu
+	}
+	sibling, err := strconv.Atoi(first[0])
+	if err != nil {
+		return cpu
+	}
+	return sibling
+}
+
+// setThreadAffinity pins the calling thread to cpu, or restores the
+// process's CPUs for noCPU
+func setThreadAffinity(cpu int) error {
+	set := processCPUs
+	if cpu != noCPU {
+		set.Zero()
+		set.Set(cpu)
+	}
+	return unix.SchedSetaffinity(0, &set)
+}
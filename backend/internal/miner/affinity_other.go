@@ -0,0 +1,20 @@
+//go:build !linux
+
+package miner
+
+import "errors"
+
+// affinityCPUs returns no CPUs, pinning is only available on Linux
+func affinityCPUs() []int {
+	return nil
+}
+
+// physicalCores returns 0, the topology is only read on Linux
+func physicalCores() int {
+	return 0
+}
+
+// setThreadAffinity always fails, pinning is only available on Linux
+func setThreadAffinity(cpu int) error {
+	return errors.New("not supported on this platform")
+}
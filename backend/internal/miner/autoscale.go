@@ -0,0 +1,86 @@
+package miner
+
+import (
+	"runtime"
+	"time"
+)
+
+// The autoscaler adds a CPU worker while at least autoscaleIdleCPUs of
+// the host's CPUs sit idle by the load average, and removes one while
+// the load exceeds the CPUs by more than autoscaleOverload, meaning
+// other processes are waiting for CPU. It moves one worker at a time,
+// at most once per autoscaleInterval, so the 1-minute load average
+// shows each change before the next.
+const (
+	autoscaleIdleCPUs = 1.0
+	autoscaleOverload = 0.5
+	autoscaleInterval = time.Minute
+)
+
+// AutoscaleStatus is the last load average reading and the worker count
+// the autoscaler set
+type AutoscaleStatus struct {
+	LoadAverage float64 `json:"load_average"`
+	CPUs        int     `json:"cpus"`
+	Workers     int     `json:"workers"`
+	MinWorkers  int     `json:"min_workers"`
+	MaxWorkers  int     `json:"max_workers"`
+	// When the autoscaler last changed the worker count, zero before
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// Autoscale records a load average reading and moves the number of CPU
+// workers towards soaking up idle CPUs without crowding out other
+// processes, within minWorkers and maxWorkers (0 for one per CPU). It
+// returns the new worker count and whether it changed.
+func (m *Manager) Autoscale(load float64, minWorkers, maxWorkers int) (int, bool) {
+	cpus := runtime.NumCPU()
+	if maxWorkers <= 0 {
+		maxWorkers = cpus
+	}
+	minWorkers = min(max(minWorkers, 1), maxWorkers)
+
+	workers := 0
+	for _, w := range m.GetAllWorkers() {
+		if w.Device == DeviceCPU {
+			workers++
+		}
+	}
+
+	m.mu.Lock()
+	target := workers
+	switch {
+	case workers < minWorkers:
+		target = minWorkers
+	case workers > maxWorkers:
+		target = maxWorkers
+	case time.Since(m.autoscale.ChangedAt) < autoscaleInterval:
+	case load <= float64(cpus)-autoscaleIdleCPUs && workers < maxWorkers:
+		target = workers + 1
+	case load > float64(cpus)+autoscaleOverload && workers > minWorkers:
+		target = workers - 1
+	}
+
+	m.autoscale.LoadAverage = load
+	m.autoscale.CPUs = cpus
+	m.autoscale.Workers = target
+	m.autoscale.MinWorkers = minWorkers
+	m.autoscale.MaxWorkers = maxWorkers
+	if target != workers {
+		m.autoscale.ChangedAt = time.Now()
+	}
+	m.mu.Unlock()
+
+	if target == workers {
+		return workers, false
+	}
+	m.SetWorkerCount(target)
+	return target, true
+}
+
+// GetAutoscaleStatus returns the autoscaler's last reading and decision
+func (m *Manager) GetAutoscaleStatus() AutoscaleStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.autoscale
+}
@@ -0,0 +1,35 @@
+package miner
+
+import "time"
+
+// Batches are sized so each takes about targetBatchTime: long enough to
+// keep per-batch overhead negligible, short enough that new jobs, shutdown
+// and throttling take effect promptly
+const (
+	targetBatchTime = 75 * time.Millisecond
+	minBatchSize    = 256
+	maxBatchSize    = 1 << 24
+)
+
+// tuneBatchSize returns the batch size that would have taken
+// targetBatchTime, given size nonces took elapsed. It moves at most 2x per
+// batch so one slow batch (a preempted thread, a GC pause) can't swing it.
+func tuneBatchSize(size int, elapsed time.Duration) int {
+	if elapsed <= 0 {
+		return min(size*2, maxBatchSize)
+	}
+
+	next := int(float64(size) * float64(targetBatchTime) / float64(elapsed))
+	next = min(max(next, size/2), size*2)
+	return min(max(next, minBatchSize), maxBatchSize)
+}
+
+// throttleDelay returns the pause after a batch that took elapsed so the
+// worker is busy cpuPercent of the time
+func throttleDelay(elapsed time.Duration, cpuPercent int) time.Duration {
+	if cpuPercent >= 100 {
+		return 0
+	}
+	cpuPercent = max(cpuPercent, 1)
+	return elapsed * time.Duration(100-cpuPercent) / time.Duration(cpuPercent)
+}
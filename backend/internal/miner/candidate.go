@@ -0,0 +1,79 @@
+package miner
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// HeaderFields are the decoded fields of a header built by BuildHeader.
+// Hashes are in the byte order block explorers show.
+type HeaderFields struct {
+	Version           string    `json:"version"`
+	PrevHash          string    `json:"prev_hash"`
+	MerkleRoot        string    `json:"merkle_root"`
+	NTime             string    `json:"ntime"`
+	Time              time.Time `json:"time"`
+	Bits              string    `json:"bits"`
+	Nonce             string    `json:"nonce"`
+	Hash              string    `json:"hash"`
+	Difficulty        float64   `json:"difficulty"`
+	Target            string    `json:"target"`
+	NetworkDifficulty float64   `json:"network_difficulty"`
+	MeetsTarget       bool      `json:"meets_target"`
+}
+
+// MerkleStep is one level of the coinbase's merkle path: the sibling hash
+// and the node it hashes to, both in explorer byte order
+type MerkleStep struct {
+	Branch string `json:"branch"`
+	Node   string `json:"node"`
+}
+
+// DecodeHeader splits an 80-byte header into its fields and scores its
+// hash against the network target in its bits
+func DecodeHeader(header []byte) (HeaderFields, error) {
+	if len(header) != 80 {
+		return HeaderFields{}, fmt.Errorf("header is %d bytes, want 80", len(header))
+	}
+
+	ntime := binary.LittleEndian.Uint32(header[68:72])
+	bits := fmt.Sprintf("%08x", binary.LittleEndian.Uint32(header[72:76]))
+	hash := doubleSHA256(header)
+	target := calculateTarget(bits)
+
+	return HeaderFields{
+		Version:           fmt.Sprintf("%08x", binary.LittleEndian.Uint32(header[0:4])),
+		PrevHash:          hex.EncodeToString(reverseBytes(header[4:36])),
+		MerkleRoot:        hex.EncodeToString(reverseBytes(header[36:68])),
+		NTime:             fmt.Sprintf("%08x", ntime),
+		Time:              time.Unix(int64(ntime), 0).UTC(),
+		Bits:              bits,
+		Nonce:             fmt.Sprintf("%08x", binary.LittleEndian.Uint32(header[76:80])),
+		Hash:              hex.EncodeToString(reverseBytes(hash)),
+		Difficulty:        hashDifficulty(hash),
+		Target:            fmt.Sprintf("%064x", target),
+		NetworkDifficulty: NetworkDifficulty(bits),
+		MeetsTarget:       target.Sign() > 0 && new(big.Int).SetBytes(reverseBytes(hash)).Cmp(target) <= 0,
+	}, nil
+}
+
+// MerklePath returns the coinbase transaction ID and each step from it to
+// the merkle root
+func MerklePath(coinbase []byte, branches []string) (string, []MerkleStep) {
+	node := doubleSHA256(coinbase)
+	txid := hex.EncodeToString(reverseBytes(node))
+
+	steps := make([]MerkleStep, 0, len(branches))
+	for _, branch := range branches {
+		branchBytes, _ := hex.DecodeString(branch)
+		node = doubleSHA256(append(node, branchBytes...))
+		steps = append(steps, MerkleStep{
+			Branch: hex.EncodeToString(reverseBytes(branchBytes)),
+			Node:   hex.EncodeToString(reverseBytes(node)),
+		})
+	}
+	return txid, steps
+}
@@ -0,0 +1,106 @@
+package miner
+
+import (
+	"math"
+
+	"github.com/soloforge/backend/internal/stratum"
+)
+
+// nonceSpaceSize is the number of nonces under each header
+const nonceSpaceSize = float64(math.MaxUint32) + 1
+
+// NonceCoverage is how much of the nonce space a worker explored for one
+// job. Each pass searches the worker's nonce range under one header, a
+// fresh extranonce2 or version bits; a pass cut short by a new job leaves
+// the rest of that header's nonces unsearched.
+type NonceCoverage struct {
+	JobID string `json:"job_id"`
+	// Passes started on the job, and those that walked the whole range
+	Passes          int `json:"passes"`
+	CompletedPasses int `json:"completed_passes"`
+	// Completed passes followed by rolling the version bits, or by a
+	// fresh extranonce2 and merkle root once those were used up
+	VersionRollovers    int `json:"version_rollovers"`
+	ExtranonceRollovers int `json:"extranonce_rollovers"`
+	// Nonces hashed on the job, and in its current or last pass
+	Scanned     uint64 `json:"scanned"`
+	PassScanned uint64 `json:"pass_scanned"`
+	// Size of the worker's nonce range
+	RangeSize uint64 `json:"range_size"`
+	// Share of the worker's range the current or last pass covered
+	PassFraction float64 `json:"pass_fraction"`
+	// Nonces hashed per header started, as a share of the 32-bit space
+	SpaceFraction float64 `json:"space_fraction"`
+}
+
+// JobCoverage is the nonce coverage of the job being mined and of the one
+// before it
+type JobCoverage struct {
+	Current  *NonceCoverage `json:"current"`
+	Previous *NonceCoverage `json:"previous"`
+}
+
+// startCoveragePass counts a new pass over r for job, starting over when
+// the job changed. exhausted says the previous pass walked its whole
+// range, versionRolled that the new pass rolled the version bits rather
+// than extranonce2. Only called by the mining goroutine.
+func (w *Worker) startCoveragePass(job *stratum.Job, r NonceRange, exhausted, versionRolled bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	c := &w.coverage
+	if w.coverageJob != job {
+		if w.coverageJob != nil {
+			w.lastCoverage = *c
+		}
+		w.coverageJob = job
+		*c = NonceCoverage{JobID: job.ID}
+	} else if exhausted {
+		c.CompletedPasses++
+		if versionRolled {
+			c.VersionRollovers++
+		} else {
+			c.ExtranonceRollovers++
+		}
+	}
+	c.Passes++
+	c.PassScanned = 0
+	c.RangeSize = uint64(r.End-r.Start) + 1
+}
+
+// recordCoverage adds hashed nonces to the current pass
+func (w *Worker) recordCoverage(scanned int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.coverage.Scanned += uint64(scanned)
+	w.coverage.PassScanned += uint64(scanned)
+}
+
+// GetNonceCoverage returns the worker's nonce coverage of the current and
+// previous job
+func (w *Worker) GetNonceCoverage() JobCoverage {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var result JobCoverage
+	if w.coverageJob != nil {
+		current := w.coverage.withFractions()
+		result.Current = &current
+	}
+	if w.lastCoverage.Passes > 0 {
+		previous := w.lastCoverage.withFractions()
+		result.Previous = &previous
+	}
+	return result
+}
+
+// withFractions fills in the coverage fractions from the counts
+func (c NonceCoverage) withFractions() NonceCoverage {
+	if c.RangeSize > 0 {
+		c.PassFraction = float64(c.PassScanned) / float64(c.RangeSize)
+	}
+	if c.Passes > 0 {
+		c.SpaceFraction = float64(c.Scanned) / (float64(c.Passes) * nonceSpaceSize)
+	}
+	return c
+}
@@ -0,0 +1,124 @@
+package miner
+
+import (
+	"math"
+	"runtime"
+	"time"
+)
+
+// The throttle sleep after each batch is scaled by a feedback controller:
+// every cpuControlInterval the process's measured CPU usage is compared
+// with what the running CPU workers are allowed, and the scale moved by
+// the ratio damped by cpuControlGain, within min/maxThrottleScale.
+const (
+	cpuControlInterval = time.Second
+	cpuControlGain     = 0.5
+	minThrottleScale   = 0.1
+	maxThrottleScale   = 10.0
+)
+
+// CPUUsage is the process CPU usage the throttle controller last measured
+type CPUUsage struct {
+	// Percent of one CPU the running CPU workers may use together
+	TargetPercent float64 `json:"target_percent"`
+	// Percent of one CPU the whole process used over the last interval
+	MeasuredPercent float64 `json:"measured_percent"`
+	// Factor applied to every worker's throttle sleep
+	ThrottleScale float64 `json:"throttle_scale"`
+	// False where process CPU time can't be read, throttling is then
+	// open-loop
+	Measured bool `json:"measured"`
+}
+
+// startCPUControl starts the throttle controller unless it runs already
+func (m *Manager) startCPUControl() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cpuControlStop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	m.cpuControlStop = stop
+	m.cpuSampledAt = time.Time{}
+
+	go func() {
+		ticker := time.NewTicker(cpuControlInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				m.regulateCPU(now)
+			}
+		}
+	}()
+}
+
+// stopCPUControl stops the throttle controller, keeping the scale it
+// settled on for the next start
+func (m *Manager) stopCPUControl() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cpuControlStop != nil {
+		close(m.cpuControlStop)
+		m.cpuControlStop = nil
+	}
+}
+
+// regulateCPU measures the process CPU usage since the last call and
+// moves the throttle scale to bring it to the running CPU workers' share
+func (m *Manager) regulateCPU(now time.Time) {
+	cpuTime, ok := processCPUTime()
+	if !ok {
+		return
+	}
+
+	m.mu.Lock()
+	percent := m.effectiveCPUPercentLocked()
+	workers := make([]*Worker, 0, len(m.workers))
+	running := 0
+	for _, w := range m.workers {
+		workers = append(workers, w)
+		if w.Device == DeviceCPU && w.IsRunning() {
+			running++
+		}
+	}
+	// Workers beyond the CPU count can't add usage, don't chase them
+	target := float64(min(running, runtime.NumCPU()) * percent)
+
+	if !m.cpuSampledAt.IsZero() && now.After(m.cpuSampledAt) {
+		measured := 100 * float64(cpuTime-m.cpuTime) / float64(now.Sub(m.cpuSampledAt))
+		m.cpuUsage = CPUUsage{
+			TargetPercent:   target,
+			MeasuredPercent: measured,
+			Measured:        true,
+		}
+		switch {
+		case percent >= 100:
+			m.throttleScale = 1
+		case target > 0 && measured > 0:
+			scale := m.throttleScale * math.Pow(measured/target, cpuControlGain)
+			m.throttleScale = min(max(scale, minThrottleScale), maxThrottleScale)
+		}
+	}
+	m.cpuTime = cpuTime
+	m.cpuSampledAt = now
+	m.cpuUsage.ThrottleScale = m.throttleScale
+	scale := m.throttleScale
+	m.mu.Unlock()
+
+	for _, w := range workers {
+		w.SetThrottleScale(scale)
+	}
+}
+
+// GetCPUUsage returns the last process CPU usage measurement and the
+// throttle scale it set
+func (m *Manager) GetCPUUsage() CPUUsage {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	usage := m.cpuUsage
+	usage.ThrottleScale = m.throttleScale
+	return usage
+}
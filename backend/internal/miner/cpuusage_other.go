@@ -0,0 +1,10 @@
+//go:build !unix
+
+package miner
+
+import "time"
+
+// processCPUTime is unsupported here, throttling stays open-loop
+func processCPUTime() (time.Duration, bool) {
+	return 0, false
+}
@@ -0,0 +1,123 @@
+package miner
+
+import (
+	"encoding/binary"
+	"fmt"
+	"runtime"
+)
+
+// Device kinds
+const (
+	DeviceCPU    = "cpu"
+	DeviceOpenCL = "opencl"
+)
+
+// Device is hardware workers can mine on
+type Device struct {
+	ID           string `json:"id"`
+	Kind         string `json:"kind"`
+	Name         string `json:"name"`
+	Vendor       string `json:"vendor,omitempty"`
+	ComputeUnits int    `json:"compute_units"`
+	MemoryBytes  uint64 `json:"memory_bytes,omitempty"`
+}
+
+// scanAbortInterval is how many nonces the CPU hashes between checks of
+// a batch's abort function, a power of two
+const scanAbortInterval = 64
+
+// Scanner is a mining backend, searching nonces on one device. Each
+// worker opens its own and only uses it from its mining goroutine.
+type Scanner interface {
+	// Scan hashes up to count nonces from start on an 80-byte header,
+	// stopping at the first whose double SHA-256, read as a little-endian
+	// number, is at or below the big-endian target, or early once abort
+	// returns true. It returns how many nonces it hashed, found meaning
+	// the last of them met the target.
+	Scan(header []byte, target *[32]byte, start uint32, count int, abort func() bool) (scanned int, found bool, err error)
+	// Close releases the device
+	Close() error
+}
+
+// Devices lists the devices workers can mine on, the CPU first. GPUs are
+// listed when built with -tags opencl and an OpenCL runtime is installed.
+func Devices() []Device {
+	cpu := Device{
+		ID:           DeviceCPU,
+		Kind:         DeviceCPU,
+		Name:         SelectedHashBackend().String(),
+		ComputeUnits: runtime.NumCPU(),
+	}
+	return append([]Device{cpu}, openCLDevices()...)
+}
+
+// findDevice returns the device with the given ID
+func findDevice(id string) (Device, bool) {
+	for _, d := range Devices() {
+		if d.ID == id {
+			return d, true
+		}
+	}
+	return Device{}, false
+}
+
+// openScanner opens a mining backend on a device
+func openScanner(device string) (Scanner, error) {
+	if device == DeviceCPU {
+		return &cpuScanner{}, nil
+	}
+	if _, ok := findDevice(device); !ok {
+		return nil, fmt.Errorf("unknown device %q", device)
+	}
+	return openOpenCL(device)
+}
+
+// cpuScanner hashes on the calling goroutine with the selected SHA-256
+// path, spot-checking it against the reference implementation
+type cpuScanner struct {
+	// Hashes left until the next reference check
+	verifyCountdown int
+}
+
+// Scan hashes nonces one after another, checking abort every
+// scanAbortInterval of them
+func (s *cpuScanner) Scan(header []byte, target *[32]byte, start uint32, count int, abort func() bool) (int, bool, error) {
+	for i := 0; i < count; i++ {
+		if i&(scanAbortInterval-1) == 0 && i > 0 && abort() {
+			return i, false, nil
+		}
+		nonce := start + uint32(i)
+		binary.LittleEndian.PutUint32(header[76:80], nonce)
+
+		// Double SHA256, kept on the stack
+		hash := doubleSHA256Sum(header)
+
+		// Spot-check the optimized backend against the reference path
+		s.verifyCountdown--
+		if s.verifyCountdown <= 0 {
+			s.verifyCountdown = nextVerify()
+			verifyHash(header, hash[:])
+		}
+
+		if meetsTarget(&hash, target) {
+			return i + 1, true, nil
+		}
+	}
+	return count, false, nil
+}
+
+// meetsTarget reports whether a hash, in SHA-256 byte order, is at most
+// the big-endian target, comparing in place without reversing a copy
+func meetsTarget(hash, target *[32]byte) bool {
+	for i := range target {
+		if b := hash[31-i]; b != target[i] {
+			return b < target[i]
+		}
+	}
+	return true
+}
+
+// Close does nothing, the CPU needs no setup
+func (s *cpuScanner) Close() error {
+	return nil
+}
@@ -0,0 +1,53 @@
+package miner
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+)
+
+// LocalExtranoncePrefix starts every extranonce2 the local workers use.
+// The Stratum proxy numbers its miners' prefixes from 01, so local and
+// proxied work never overlap.
+const LocalExtranoncePrefix = "00"
+
+// maxExtranonce2WorkerBits bounds the worker ID field of extranonce2
+const maxExtranonce2WorkerBits = 16
+
+// extranonce2Value lays out an extranonce2 of size bytes in the local
+// workers' space: LocalExtranoncePrefix when there is room for it, then
+// the worker ID in the high half of the rest, at most 16 bits, and the
+// pass counter in the low half. Workers with different IDs below the
+// field's limit never share a value, and a worker repeats one only when
+// its counter wraps.
+func extranonce2Value(size, workerID int, counter uint64) string {
+	buf := make([]byte, size)
+	field := buf
+	if size >= 2 {
+		field = buf[1:]
+	}
+
+	bits := min(8*len(field), 64)
+	workerBits := min(bits/2, maxExtranonce2WorkerBits)
+	counterBits := bits - workerBits
+
+	value := (uint64(workerID) & (1<<workerBits - 1)) << counterBits
+	if counterBits < 64 {
+		counter &= 1<<counterBits - 1
+	}
+	value |= counter
+
+	// Right-align in the field, any bytes beyond 64 bits stay zero
+	var be [8]byte
+	binary.BigEndian.PutUint64(be[:], value)
+	n := min(len(field), 8)
+	copy(field[len(field)-n:], be[8-n:])
+	return hex.EncodeToString(buf)
+}
+
+// nextExtranonce2Locked returns the worker's next extranonce2 and counts
+// the pass. Caller must hold w.mu.
+func (w *Worker) nextExtranonce2Locked(size int) string {
+	e := extranonce2Value(size, w.ID, w.extranonce2Counter)
+	w.extranonce2Counter++
+	return e
+}
@@ -0,0 +1,94 @@
+package miner
+
+import (
+	"bufio"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// CPUInfo describes the host CPU and the SHA-256 features it offers
+type CPUInfo struct {
+	// Model name from the kernel, empty when unknown
+	Model string `json:"model"`
+	// Physical cores the process may use, 0 when the topology is unknown
+	Cores int `json:"cores"`
+	// Logical CPUs the process may use
+	Threads int  `json:"threads"`
+	SHANI   bool `json:"sha_ni"`
+	AVX2    bool `json:"avx2"`
+	NEON    bool `json:"neon"`
+	// Every feature the hash backend detection found
+	Features []string `json:"features"`
+}
+
+// DetectCPU returns the CPU model, core and thread counts and hashing
+// features. The model is read from /proc/cpuinfo, so only on Linux.
+func DetectCPU() CPUInfo {
+	info := CPUInfo{
+		Model:    cpuModel(),
+		Cores:    physicalCores(),
+		Threads:  runtime.NumCPU(),
+		Features: selectedBackend.Features,
+	}
+	for _, feature := range selectedBackend.Features {
+		switch feature {
+		case "sha-ni":
+			info.SHANI = true
+		case "avx2":
+			info.AVX2 = true
+		case "neon":
+			info.NEON = true
+		}
+	}
+	return info
+}
+
+// cpuModel returns the first model name in /proc/cpuinfo. x86 reports
+// "model name"; ARM kernels report "Model" or "Hardware", if anything.
+func cpuModel() string {
+	f, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "model name", "Model", "Hardware":
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// HostMemory returns the host's total RAM in bytes from /proc/meminfo,
+// 0 where that isn't available
+func HostMemory() uint64 {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// MemTotal:       16318412 kB
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "MemTotal:" {
+			continue
+		}
+		kb, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0
+		}
+		return kb * 1024
+	}
+	return 0
+}
@@ -0,0 +1,53 @@
+package miner
+
+import (
+	"runtime"
+	"strings"
+)
+
+// HashBackend describes the SHA-256 code path selected for this CPU
+type HashBackend struct {
+	Name     string   `json:"name"`
+	Arch     string   `json:"arch"`
+	Features []string `json:"features"`
+}
+
+// selectedBackend is resolved once at startup from CPU feature detection.
+// crypto/sha256 ships SHA-NI/AVX2 (amd64) and ARMv8 SHA2 (arm64) assembly
+// and dispatches on the same features, so this reports the path it takes.
+var selectedBackend = detectHashBackend()
+
+// SelectedHashBackend returns the hashing backend in use
+func SelectedHashBackend() HashBackend {
+	return selectedBackend
+}
+
+// ActiveHashBackend returns the backend the workers are hashing with: the
+// selected one, or the pure-Go reference path while the hash check has
+// switched to it
+func ActiveHashBackend() HashBackend {
+	if safeHashing.Load() {
+		return newHashBackend("reference", nil)
+	}
+	return selectedBackend
+}
+
+// newHashBackend builds a backend description for the current architecture
+func newHashBackend(name string, features []string) HashBackend {
+	if features == nil {
+		features = []string{}
+	}
+	return HashBackend{
+		Name:     name,
+		Arch:     runtime.GOARCH,
+		Features: features,
+	}
+}
+
+// String returns a short human-readable description
+func (b HashBackend) String() string {
+	if len(b.Features) == 0 {
+		return b.Name + " (" + b.Arch + ")"
+	}
+	return b.Name + " (" + b.Arch + ": " + strings.Join(b.Features, ", ") + ")"
+}
@@ -0,0 +1,38 @@
+package miner
+
+import (
+	"golang.org/x/sys/cpu"
+
+	"github.com/soloforge/backend/internal/cpuid"
+)
+
+// detectHashBackend reports the SHA-256 path crypto/sha256 takes on x86-64
+// CPUs. The conditions are the ones of the Go 1.22 standard library this
+// module targets: AVX2 with BMI2, and SHA-NI only on top of both.
+func detectHashBackend() HashBackend {
+	shaNI := cpuid.HasSHA()
+
+	var features []string
+	if shaNI {
+		features = append(features, "sha-ni")
+	}
+	if cpu.X86.HasAVX2 {
+		features = append(features, "avx2")
+	}
+	if cpu.X86.HasBMI2 {
+		features = append(features, "bmi2")
+	}
+	if cpu.X86.HasSSSE3 {
+		features = append(features, "ssse3")
+	}
+
+	avx2 := cpu.X86.HasAVX2 && cpu.X86.HasBMI2
+	switch {
+	case avx2 && shaNI:
+		return newHashBackend("sha-ni", features)
+	case avx2:
+		return newHashBackend("avx2", features)
+	default:
+		return newHashBackend("generic", features)
+	}
+}
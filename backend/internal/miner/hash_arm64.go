@@ -0,0 +1,19 @@
+package miner
+
+import "golang.org/x/sys/cpu"
+
+// detectHashBackend picks the best SHA-256 path for ARMv8 CPUs
+func detectHashBackend() HashBackend {
+	var features []string
+	if cpu.ARM64.HasSHA2 {
+		features = append(features, "sha2")
+	}
+	if cpu.ARM64.HasASIMD {
+		features = append(features, "neon")
+	}
+
+	if cpu.ARM64.HasSHA2 {
+		return newHashBackend("armv8-sha2", features)
+	}
+	return newHashBackend("generic", features)
+}
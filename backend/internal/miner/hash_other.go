@@ -0,0 +1,8 @@
+//go:build !amd64 && !arm64
+
+package miner
+
+// detectHashBackend falls back to the portable implementation
+func detectHashBackend() HashBackend {
+	return newHashBackend("generic", nil)
+}
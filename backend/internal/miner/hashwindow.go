@@ -0,0 +1,111 @@
+package miner
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Windowed hashrates come from hash counts sampled every
+// hashWindowInterval, enough of them kept to cover the longest window
+const (
+	hashWindowInterval = 5 * time.Second
+	hashWindowSize     = int(15*time.Minute/hashWindowInterval) + 1
+)
+
+// Hashrates are hashrates in H/s over sliding windows and the average
+// over all time spent mining
+type Hashrates struct {
+	OneMinute      float64 `json:"1m"`
+	FiveMinutes    float64 `json:"5m"`
+	FifteenMinutes float64 `json:"15m"`
+	Lifetime       float64 `json:"lifetime"`
+}
+
+// hashSample is a worker's hash count at one moment
+type hashSample struct {
+	at    time.Time
+	count uint64
+}
+
+// hashWindow is a ring of hash count samples, oldest overwritten first
+type hashWindow struct {
+	samples [hashWindowSize]hashSample
+	next    int
+	size    int
+}
+
+// add records a sample, unless the last one is under hashWindowInterval old
+func (h *hashWindow) add(at time.Time, count uint64) {
+	if h.size > 0 && at.Sub(h.latest().at) < hashWindowInterval {
+		return
+	}
+	h.samples[h.next] = hashSample{at: at, count: count}
+	h.next = (h.next + 1) % hashWindowSize
+	h.size = min(h.size+1, hashWindowSize)
+}
+
+// latest returns the newest sample, the window must not be empty
+func (h *hashWindow) latest() hashSample {
+	return h.samples[(h.next-1+hashWindowSize)%hashWindowSize]
+}
+
+// rate returns the hashrate from the oldest sample within span of now up
+// to count hashes at now. Shorter histories give the rate over what there
+// is.
+func (h *hashWindow) rate(now time.Time, count uint64, span time.Duration) float64 {
+	for i := h.size; i > 0; i-- {
+		s := h.samples[(h.next-i+hashWindowSize)%hashWindowSize]
+		if now.Sub(s.at) > span {
+			continue
+		}
+		elapsed := now.Sub(s.at).Seconds()
+		if elapsed <= 0 || count < s.count {
+			return 0
+		}
+		return float64(count-s.count) / elapsed
+	}
+	return 0
+}
+
+// GetHashrates returns the worker's 1, 5 and 15 minute hashrates, sampled
+// by SampleHashrate, and its lifetime average
+func (w *Worker) GetHashrates() Hashrates {
+	now := time.Now()
+	count := atomic.LoadUint64(&w.hashCount)
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return Hashrates{
+		OneMinute:      w.window.rate(now, count, time.Minute),
+		FiveMinutes:    w.window.rate(now, count, 5*time.Minute),
+		FifteenMinutes: w.window.rate(now, count, 15*time.Minute),
+		Lifetime:       w.lifetimeHashrateLocked(now, count),
+	}
+}
+
+// lifetimeHashrateLocked averages count over the time spent mining, so
+// stopped and paused periods don't count. Caller must hold w.mu.
+func (w *Worker) lifetimeHashrateLocked(now time.Time, count uint64) float64 {
+	elapsed := w.minedFor
+	if w.running {
+		elapsed += now.Sub(w.startTime)
+	}
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(count) / elapsed.Seconds()
+}
+
+// GetTotalHashrates returns the combined windowed and lifetime hashrates
+// of all workers
+func (m *Manager) GetTotalHashrates() Hashrates {
+	var total Hashrates
+	for _, w := range m.GetAllWorkers() {
+		rates := w.GetHashrates()
+		total.OneMinute += rates.OneMinute
+		total.FiveMinutes += rates.FiveMinutes
+		total.FifteenMinutes += rates.FifteenMinutes
+		total.Lifetime += rates.Lifetime
+	}
+	return total
+}
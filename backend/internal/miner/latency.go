@@ -0,0 +1,69 @@
+package miner
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxLatencySamples bounds the job latencies kept for percentiles
+const maxLatencySamples = 512
+
+// JobLatency summarizes the time from mining.notify to workers hashing
+// the new job, coinbase and merkle precompute included
+type JobLatency struct {
+	Samples int     `json:"samples"`
+	LastMs  float64 `json:"last_ms"`
+	P50Ms   float64 `json:"p50_ms"`
+	P95Ms   float64 `json:"p95_ms"`
+	MaxMs   float64 `json:"max_ms"`
+}
+
+// latencyTracker keeps recent job latencies from every worker under its
+// own lock, so reporting doesn't contend with worker management
+type latencyTracker struct {
+	mu      sync.Mutex
+	samples []time.Duration
+}
+
+// record adds a latency sample, dropping the oldest beyond the limit
+func (t *latencyTracker) record(latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.samples = append(t.samples, latency)
+	if len(t.samples) > maxLatencySamples {
+		t.samples = t.samples[1:]
+	}
+}
+
+// summary computes percentiles over the kept samples
+func (t *latencyTracker) summary() JobLatency {
+	t.mu.Lock()
+	sorted := make([]time.Duration, len(t.samples))
+	copy(sorted, t.samples)
+	var last time.Duration
+	if len(t.samples) > 0 {
+		last = t.samples[len(t.samples)-1]
+	}
+	t.mu.Unlock()
+
+	if len(sorted) == 0 {
+		return JobLatency{}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	return JobLatency{
+		Samples: len(sorted),
+		LastMs:  ms(last),
+		P50Ms:   ms(sorted[len(sorted)/2]),
+		P95Ms:   ms(sorted[(len(sorted)*95)/100]),
+		MaxMs:   ms(sorted[len(sorted)-1]),
+	}
+}
+
+// GetJobLatency returns the notify-to-first-hash latency across workers
+func (m *Manager) GetJobLatency() JobLatency {
+	return m.latency.summary()
+}
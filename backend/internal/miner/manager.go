@@ -1,7 +1,10 @@
 package miner
 
 import (
+	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/soloforge/backend/internal/stratum"
 )
@@ -10,46 +13,138 @@ import (
 type Manager struct {
 	mu sync.RWMutex
 
+	// Serializes nonce range and CPU assignment
+	partitionMu sync.Mutex
+
 	workers    map[int]*Worker
 	nextID     int
 	cpuPercent int
 
+	// Pin each worker to its own CPU
+	cpuAffinity bool
+
+	// Scheduling priority of mining threads
+	priority string
+
+	// Thermal throttling: last reading, limit and the cap on cpuPercent
+	// it set, 0 when not throttled
+	celsius         float64
+	limitCelsius    float64
+	thermalCap      int
+	thermalAdjusted time.Time
+
+	// Closed-loop throttling: the controller's stop channel, the last
+	// process CPU time sample and the scale on throttle sleeps it set
+	cpuControlStop chan struct{}
+	cpuTime        time.Duration
+	cpuSampledAt   time.Time
+	cpuUsage       CPUUsage
+	throttleScale  float64
+
+	// Load-based worker autoscaling: last reading and decision
+	autoscale AutoscaleStatus
+
 	// Stratum connection data
 	extranonce1     string
 	extranonce2Size int
+	epoch           uint64
+	versionMask     uint32
+
+	// Pool share difficulty workers report shares at, 0 for blocks only
+	shareDifficulty float64
+
+	// ntime rolling with the NTP-corrected clock
+	rollNTime   bool
+	ntimeOffset time.Duration
+
+	// Time from mining.notify to each worker's first hash on the job
+	latency latencyTracker
+
+	// Found nonces on their way to the submitter
+	results *resultPipeline
 
 	// Callbacks
-	onShareFound func(workerID int, jobID, extranonce2, ntime, nonce string, difficulty float64)
+	onShareFound func(workerID int, epoch uint64, jobID, extranonce2, ntime, nonce, versionBits string, difficulty float64, block bool)
+	onBlockFound func(epoch uint64, jobID, extranonce2, ntime, nonce, versionBits string)
+	onError      func(WorkerError)
 }
 
 // NewManager creates a new worker manager
 func NewManager() *Manager {
 	return &Manager{
-		workers:    make(map[int]*Worker),
-		nextID:     1,
-		cpuPercent: 80,
+		workers:       make(map[int]*Worker),
+		nextID:        1,
+		cpuPercent:    80,
+		throttleScale: 1,
+		results:       newResultPipeline(),
 	}
 }
 
-// SetShareCallback sets the callback for found shares
-func (m *Manager) SetShareCallback(cb func(workerID int, jobID, extranonce2, ntime, nonce string, difficulty float64)) {
+// SetShareCallback sets the callback the submitter hands checked shares
+// to, block set for those also meeting the network target
+func (m *Manager) SetShareCallback(cb func(workerID int, epoch uint64, jobID, extranonce2, ntime, nonce, versionBits string, difficulty float64, block bool)) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.onShareFound = cb
 }
 
-// SetStratumData sets the extranonce data from the Stratum connection
-func (m *Manager) SetStratumData(extranonce1 string, extranonce2Size int) {
+// SetBlockCallback sets the fast-path callback the mining goroutine calls
+// for solutions meeting the network target, ahead of the share callback
+func (m *Manager) SetBlockCallback(cb func(epoch uint64, jobID, extranonce2, ntime, nonce, versionBits string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onBlockFound = cb
+}
+
+// SetStratumData sets the extranonce data and session epoch from the
+// Stratum connection
+func (m *Manager) SetStratumData(extranonce1 string, extranonce2Size int, epoch uint64) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.extranonce1 = extranonce1
 	m.extranonce2Size = extranonce2Size
+	m.epoch = epoch
 }
 
-// SetCPUPercent sets the CPU throttling for all workers
+// UpdateExtranonce applies new extranonce data to the manager and every
+// worker, so running workers restart their extranonce2 assignment
+func (m *Manager) UpdateExtranonce(extranonce1 string, extranonce2Size int, epoch uint64) {
+	m.SetStratumData(extranonce1, extranonce2Size, epoch)
+
+	for _, w := range m.GetAllWorkers() {
+		w.SetExtranonce(extranonce1, extranonce2Size, epoch)
+	}
+}
+
+// SetVersionMask sets the negotiated version-rolling mask for all workers
+func (m *Manager) SetVersionMask(mask uint32) {
+	m.mu.Lock()
+	m.versionMask = mask
+	m.mu.Unlock()
+
+	for _, w := range m.GetAllWorkers() {
+		w.SetVersionMask(mask)
+	}
+}
+
+// SetShareDifficulty sets the pool share difficulty for all workers, so
+// they report shares as well as blocks; 0 reports blocks only
+func (m *Manager) SetShareDifficulty(difficulty float64) {
+	m.mu.Lock()
+	m.shareDifficulty = difficulty
+	m.mu.Unlock()
+
+	for _, w := range m.GetAllWorkers() {
+		w.SetShareDifficulty(difficulty)
+	}
+}
+
+// SetCPUPercent sets the CPU throttling for all workers. While the
+// thermal cap is lower, workers run at the cap instead.
 func (m *Manager) SetCPUPercent(percent int) {
 	m.mu.Lock()
 	m.cpuPercent = percent
+	percent = m.effectiveCPUPercentLocked()
 	workers := make([]*Worker, 0, len(m.workers))
 	for _, w := range m.workers {
 		workers = append(workers, w)
@@ -61,8 +156,19 @@ func (m *Manager) SetCPUPercent(percent int) {
 	}
 }
 
-// AddWorker creates and starts a new worker
+// AddWorker creates and starts a new CPU worker
 func (m *Manager) AddWorker(name string) *Worker {
+	w, _ := m.AddDeviceWorker(name, DeviceCPU)
+	return w
+}
+
+// AddDeviceWorker creates and starts a new worker mining on a device from
+// Devices
+func (m *Manager) AddDeviceWorker(name, device string) (*Worker, error) {
+	if _, ok := findDevice(device); !ok {
+		return nil, fmt.Errorf("unknown device %q", device)
+	}
+
 	m.mu.Lock()
 	id := m.nextID
 	m.nextID++
@@ -71,19 +177,31 @@ func (m *Manager) AddWorker(name string) *Worker {
 		name = "Worker " + string(rune('A'+id-1))
 	}
 
-	worker := NewWorker(id, name, m.cpuPercent)
-	worker.SetShareCallback(m.onShareFound)
+	worker := NewWorker(id, name, m.effectiveCPUPercentLocked())
+	worker.Device = device
+	worker.submit = m.queueShare
+	worker.SetErrorCallback(m.onError)
+	worker.SetJobLatencyCallback(m.latency.record)
+	worker.SetVersionMask(m.versionMask)
+	worker.SetShareDifficulty(m.shareDifficulty)
+	worker.SetNTimeCorrection(m.rollNTime, m.ntimeOffset)
+	worker.SetThrottleScale(m.throttleScale)
+	worker.SetPriority(m.priority)
 	m.workers[id] = worker
 
 	extranonce1 := m.extranonce1
 	extranonce2Size := m.extranonce2Size
+	epoch := m.epoch
 	m.mu.Unlock()
 
+	m.partitionNonces()
+	m.assignCPUs()
 	if extranonce1 != "" {
-		worker.Start(extranonce1, extranonce2Size)
+		m.startResults()
+		worker.Start(extranonce1, extranonce2Size, epoch)
 	}
 
-	return worker
+	return worker, nil
 }
 
 // RemoveWorker stops and removes a worker
@@ -97,11 +215,34 @@ func (m *Manager) RemoveWorker(id int) bool {
 
 	if exists {
 		worker.Stop()
+		m.partitionNonces()
+		m.assignCPUs()
 	}
 
 	return exists
 }
 
+// SetWorkerCount adds or removes CPU workers to reach n, removing the
+// most recently added first. Workers on other devices are left alone.
+func (m *Manager) SetWorkerCount(n int) {
+	var workers []*Worker
+	for _, w := range m.GetAllWorkers() {
+		if w.Device == DeviceCPU {
+			workers = append(workers, w)
+		}
+	}
+	for i := len(workers); i < n; i++ {
+		m.AddWorker("")
+	}
+
+	if len(workers) > n {
+		sort.Slice(workers, func(i, j int) bool { return workers[i].ID > workers[j].ID })
+		for _, w := range workers[:len(workers)-n] {
+			m.RemoveWorker(w.ID)
+		}
+	}
+}
+
 // GetWorker returns a worker by ID
 func (m *Manager) GetWorker(id int) *Worker {
 	m.mu.RLock()
@@ -141,11 +282,24 @@ func (m *Manager) GetTotalHashCount() uint64 {
 	return total
 }
 
-// StartAll starts all workers
+// SampleHashrates records a sparkline sample for every worker
+func (m *Manager) SampleHashrates() {
+	now := time.Now()
+	for _, w := range m.GetAllWorkers() {
+		w.SampleHashrate(now)
+	}
+}
+
+// StartAll starts all workers except paused ones, the controller
+// holding their CPU usage to the CPU percent and the share submitter
 func (m *Manager) StartAll() {
+	m.startCPUControl()
+	m.startResults()
+
 	m.mu.RLock()
 	extranonce1 := m.extranonce1
 	extranonce2Size := m.extranonce2Size
+	epoch := m.epoch
 	workers := make([]*Worker, 0, len(m.workers))
 	for _, w := range m.workers {
 		workers = append(workers, w)
@@ -153,18 +307,58 @@ func (m *Manager) StartAll() {
 	m.mu.RUnlock()
 
 	for _, w := range workers {
-		if !w.IsRunning() {
-			w.Start(extranonce1, extranonce2Size)
+		if !w.IsRunning() && !w.IsPaused() {
+			w.Start(extranonce1, extranonce2Size, epoch)
 		}
 	}
 }
 
-// StopAll stops all workers
+// PauseWorker stops a worker and keeps it stopped until ResumeWorker
+func (m *Manager) PauseWorker(id int) bool {
+	worker := m.GetWorker(id)
+	if worker == nil {
+		return false
+	}
+	worker.Pause()
+	return true
+}
+
+// ResumeWorker clears a worker's pause and, if start is set, starts it on
+// the current session
+func (m *Manager) ResumeWorker(id int, start bool) bool {
+	worker := m.GetWorker(id)
+	if worker == nil {
+		return false
+	}
+	worker.Resume()
+
+	m.mu.RLock()
+	extranonce1 := m.extranonce1
+	extranonce2Size := m.extranonce2Size
+	epoch := m.epoch
+	m.mu.RUnlock()
+
+	if start && extranonce1 != "" {
+		m.startResults()
+		worker.Start(extranonce1, extranonce2Size, epoch)
+	}
+	return true
+}
+
+// StopAll stops all workers and waits for their mining goroutines to
+// exit, so a following StartAll never overlaps them, then for the
+// submitter to hand on the shares they found
 func (m *Manager) StopAll() {
+	m.stopCPUControl()
+
 	workers := m.GetAllWorkers()
 	for _, w := range workers {
 		w.Stop()
 	}
+	for _, w := range workers {
+		w.Wait()
+	}
+	m.stopResults()
 }
 
 // BroadcastJob sends a new job to all workers
@@ -0,0 +1,90 @@
+package miner
+
+import (
+	"math"
+	"sort"
+)
+
+// nonceRestart moves the cursor past any range, starting a new pass
+const nonceRestart = math.MaxUint64
+
+// NonceRange is the inclusive part of the 32-bit nonce space a worker
+// searches
+type NonceRange struct {
+	Start uint32 `json:"start"`
+	End   uint32 `json:"end"`
+}
+
+// fullNonceRange is the whole nonce space, searched by a lone worker
+var fullNonceRange = NonceRange{Start: 0, End: math.MaxUint32}
+
+// splitNonceSpace returns the index-th of count disjoint ranges covering
+// the nonce space, the last one taking the remainder
+func splitNonceSpace(index, count int) NonceRange {
+	if count <= 1 {
+		return fullNonceRange
+	}
+
+	span := (uint64(math.MaxUint32) + 1) / uint64(count)
+	start := uint64(index) * span
+	end := start + span - 1
+	if index == count-1 {
+		end = math.MaxUint32
+	}
+	return NonceRange{Start: uint32(start), End: uint32(end)}
+}
+
+// partitionNonces gives each worker, ordered by ID, a disjoint share of
+// the nonce space
+func (m *Manager) partitionNonces() {
+	// Serialized so a stale worker list cannot be applied last
+	m.partitionMu.Lock()
+	defer m.partitionMu.Unlock()
+
+	workers := m.GetAllWorkers()
+	sort.Slice(workers, func(i, j int) bool { return workers[i].ID < workers[j].ID })
+	for i, w := range workers {
+		w.SetNonceRange(splitNonceSpace(i, len(workers)))
+	}
+}
+
+// SetNonceRange sets the part of the nonce space the worker searches
+func (w *Worker) SetNonceRange(r NonceRange) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.nonceRange = r
+}
+
+// GetNonceRange returns the part of the nonce space the worker searches
+func (w *Worker) GetNonceRange() NonceRange {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.nonceRange
+}
+
+// nextNonceBatch returns the first nonce and size of the next batch of up
+// to size nonces, walking r sequentially. The bool reports a new pass,
+// when the range was exhausted or a new job or range moved the cursor out
+// of it, and the header must change so the pass does not repeat work.
+func (w *Worker) nextNonceBatch(r NonceRange, size int) (uint32, int, bool) {
+	newPass := false
+	if w.nonceCursor < uint64(r.Start) || w.nonceCursor > uint64(r.End) {
+		w.nonceCursor = uint64(r.Start)
+		newPass = true
+	}
+
+	start := w.nonceCursor
+	n := uint64(size)
+	if left := uint64(r.End) - start + 1; left < n {
+		n = left
+	}
+	w.nonceCursor += n
+	return uint32(start), int(n), newPass
+}
+
+// nextVersionBits steps through every combination of the version bits in
+// mask, the search dimension BIP 310 adds on top of the nonce. It returns
+// 0 after the last one, or when no bits may roll.
+func nextVersionBits(bits, mask uint32) uint32 {
+	return ((bits&mask | ^mask) + 1) & mask
+}
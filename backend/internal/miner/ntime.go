@@ -0,0 +1,53 @@
+package miner
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// maxNTimeRoll bounds how far ntime is rolled past the job's. Pools reject
+// times much further ahead (ckpool at about two hours), stay well inside.
+const maxNTimeRoll = 10 * time.Minute
+
+// correctedNTime rolls the job's ntime forward to the corrected clock,
+// never before the job's own time nor more than maxNTimeRoll past it, so
+// a broken local clock cannot cause time-too-old or time-too-new rejects
+func correctedNTime(jobNTime string, now time.Time) string {
+	base, err := strconv.ParseUint(jobNTime, 16, 32)
+	if err != nil || len(jobNTime) != 8 {
+		return jobNTime
+	}
+
+	t := now.Unix()
+	lo, hi := int64(base), int64(base)+int64(maxNTimeRoll/time.Second)
+	if t <= lo {
+		return jobNTime
+	}
+	if t > hi {
+		t = hi
+	}
+	return fmt.Sprintf("%08x", uint32(t))
+}
+
+// SetNTimeCorrection makes every worker roll ntime with the local clock
+// corrected by offset, or use the job's ntime as sent when disabled
+func (m *Manager) SetNTimeCorrection(enabled bool, offset time.Duration) {
+	m.mu.Lock()
+	m.rollNTime = enabled
+	m.ntimeOffset = offset
+	m.mu.Unlock()
+
+	for _, w := range m.GetAllWorkers() {
+		w.SetNTimeCorrection(enabled, offset)
+	}
+}
+
+// SetNTimeCorrection sets whether the worker rolls ntime, and the offset
+// from the local clock to the corrected one
+func (w *Worker) SetNTimeCorrection(enabled bool, offset time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.rollNTime = enabled
+	w.ntimeOffset = offset
+}
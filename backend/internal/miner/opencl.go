@@ -0,0 +1,315 @@
+//go:build opencl && cgo
+
+package miner
+
+/*
+#cgo CFLAGS: -DCL_TARGET_OPENCL_VERSION=120
+#cgo linux LDFLAGS: -lOpenCL
+#cgo darwin LDFLAGS: -framework OpenCL
+#cgo windows LDFLAGS: -lOpenCL
+#include <stdlib.h>
+#ifdef __APPLE__
+#include <OpenCL/opencl.h>
+#else
+#include <CL/cl.h>
+#endif
+*/
+import "C"
+
+import (
+	_ "embed"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// openCLKernel is the nonce scanning kernel, built for each device opened
+//
+//go:embed opencl_scan.cl
+var openCLKernel string
+
+// openCLDevices lists the GPUs of every OpenCL platform, enumerated once
+var openCLDevices = sync.OnceValue(func() []Device {
+	var devices []Device
+	for p, platform := range clPlatforms() {
+		for d, dev := range clDevices(platform) {
+			devices = append(devices, Device{
+				ID:           fmt.Sprintf("%s:%d:%d", DeviceOpenCL, p, d),
+				Kind:         DeviceOpenCL,
+				Name:         clDeviceString(dev, C.CL_DEVICE_NAME),
+				Vendor:       clDeviceString(dev, C.CL_DEVICE_VENDOR),
+				ComputeUnits: int(clDeviceUint(dev, C.CL_DEVICE_MAX_COMPUTE_UNITS)),
+				MemoryBytes:  uint64(clDeviceUlong(dev, C.CL_DEVICE_GLOBAL_MEM_SIZE)),
+			})
+		}
+	}
+	return devices
+})
+
+// clPlatforms returns the installed OpenCL platforms
+func clPlatforms() []C.cl_platform_id {
+	var n C.cl_uint
+	if C.clGetPlatformIDs(0, nil, &n) != C.CL_SUCCESS || n == 0 {
+		return nil
+	}
+	ids := make([]C.cl_platform_id, n)
+	if C.clGetPlatformIDs(n, &ids[0], nil) != C.CL_SUCCESS {
+		return nil
+	}
+	return ids
+}
+
+// clDevices returns a platform's GPUs
+func clDevices(platform C.cl_platform_id) []C.cl_device_id {
+	var n C.cl_uint
+	if C.clGetDeviceIDs(platform, C.CL_DEVICE_TYPE_GPU, 0, nil, &n) != C.CL_SUCCESS || n == 0 {
+		return nil
+	}
+	ids := make([]C.cl_device_id, n)
+	if C.clGetDeviceIDs(platform, C.CL_DEVICE_TYPE_GPU, n, &ids[0], nil) != C.CL_SUCCESS {
+		return nil
+	}
+	return ids
+}
+
+// clDeviceString reads a string property of a device
+func clDeviceString(dev C.cl_device_id, param C.cl_device_info) string {
+	var size C.size_t
+	if C.clGetDeviceInfo(dev, param, 0, nil, &size) != C.CL_SUCCESS || size == 0 {
+		return ""
+	}
+	buf := make([]byte, size)
+	if C.clGetDeviceInfo(dev, param, size, unsafe.Pointer(&buf[0]), nil) != C.CL_SUCCESS {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(buf), "\x00"))
+}
+
+// clDeviceUint reads a cl_uint property of a device
+func clDeviceUint(dev C.cl_device_id, param C.cl_device_info) C.cl_uint {
+	var v C.cl_uint
+	C.clGetDeviceInfo(dev, param, C.size_t(unsafe.Sizeof(v)), unsafe.Pointer(&v), nil)
+	return v
+}
+
+// clDeviceUlong reads a cl_ulong property of a device
+func clDeviceUlong(dev C.cl_device_id, param C.cl_device_info) C.cl_ulong {
+	var v C.cl_ulong
+	C.clGetDeviceInfo(dev, param, C.size_t(unsafe.Sizeof(v)), unsafe.Pointer(&v), nil)
+	return v
+}
+
+// clError describes a failed OpenCL call
+func clError(call string, status C.cl_int) error {
+	return fmt.Errorf("%s failed with OpenCL error %d", call, int(status))
+}
+
+// openCLScanner runs the scan kernel on one GPU
+type openCLScanner struct {
+	device  C.cl_device_id
+	context C.cl_context
+	queue   C.cl_command_queue
+	program C.cl_program
+	kernel  C.cl_kernel
+
+	// Kernel arguments
+	midstate C.cl_mem
+	tail     C.cl_mem
+	target   C.cl_mem
+	result   C.cl_mem
+
+	// Work items per group
+	local C.size_t
+}
+
+// openOpenCL builds the scan kernel on the GPU with the given ID
+func openOpenCL(id string) (Scanner, error) {
+	var p, d int
+	if _, err := fmt.Sscanf(id, DeviceOpenCL+":%d:%d", &p, &d); err != nil {
+		return nil, fmt.Errorf("unknown device %q", id)
+	}
+	platforms := clPlatforms()
+	if p < 0 || p >= len(platforms) {
+		return nil, fmt.Errorf("unknown device %q", id)
+	}
+	devices := clDevices(platforms[p])
+	if d < 0 || d >= len(devices) {
+		return nil, fmt.Errorf("unknown device %q", id)
+	}
+
+	s := &openCLScanner{device: devices[d]}
+	if err := s.open(); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// open creates the context, queue, kernel and buffers
+func (s *openCLScanner) open() error {
+	var status C.cl_int
+
+	s.context = C.clCreateContext(nil, 1, &s.device, nil, nil, &status)
+	if status != C.CL_SUCCESS {
+		return clError("clCreateContext", status)
+	}
+	s.queue = C.clCreateCommandQueue(s.context, s.device, 0, &status)
+	if status != C.CL_SUCCESS {
+		return clError("clCreateCommandQueue", status)
+	}
+
+	src := C.CString(openCLKernel)
+	defer C.free(unsafe.Pointer(src))
+	length := C.size_t(len(openCLKernel))
+	s.program = C.clCreateProgramWithSource(s.context, 1, &src, &length, &status)
+	if status != C.CL_SUCCESS {
+		return clError("clCreateProgramWithSource", status)
+	}
+	if status := C.clBuildProgram(s.program, 1, &s.device, nil, nil, nil); status != C.CL_SUCCESS {
+		return fmt.Errorf("%w: %s", clError("clBuildProgram", status), s.buildLog())
+	}
+
+	name := C.CString("scan")
+	defer C.free(unsafe.Pointer(name))
+	s.kernel = C.clCreateKernel(s.program, name, &status)
+	if status != C.CL_SUCCESS {
+		return clError("clCreateKernel", status)
+	}
+
+	buffers := []struct {
+		mem   *C.cl_mem
+		size  C.size_t
+		flags C.cl_mem_flags
+	}{
+		{&s.midstate, 32, C.CL_MEM_READ_ONLY},
+		{&s.tail, 12, C.CL_MEM_READ_ONLY},
+		{&s.target, 32, C.CL_MEM_READ_ONLY},
+		{&s.result, 8, C.CL_MEM_READ_WRITE},
+	}
+	for _, b := range buffers {
+		*b.mem = C.clCreateBuffer(s.context, b.flags, b.size, nil, &status)
+		if status != C.CL_SUCCESS {
+			return clError("clCreateBuffer", status)
+		}
+	}
+
+	args := []*C.cl_mem{&s.midstate, &s.tail, &s.target}
+	for i, mem := range args {
+		if status := C.clSetKernelArg(s.kernel, C.cl_uint(i), C.size_t(unsafe.Sizeof(*mem)), unsafe.Pointer(mem)); status != C.CL_SUCCESS {
+			return clError("clSetKernelArg", status)
+		}
+	}
+	if status := C.clSetKernelArg(s.kernel, 5, C.size_t(unsafe.Sizeof(s.result)), unsafe.Pointer(&s.result)); status != C.CL_SUCCESS {
+		return clError("clSetKernelArg", status)
+	}
+
+	status = C.clGetKernelWorkGroupInfo(s.kernel, s.device, C.CL_KERNEL_WORK_GROUP_SIZE, C.size_t(unsafe.Sizeof(s.local)), unsafe.Pointer(&s.local), nil)
+	if status != C.CL_SUCCESS {
+		return clError("clGetKernelWorkGroupInfo", status)
+	}
+	if s.local == 0 {
+		s.local = 1
+	}
+	return nil
+}
+
+// buildLog returns the compiler output of a failed kernel build
+func (s *openCLScanner) buildLog() string {
+	var size C.size_t
+	if C.clGetProgramBuildInfo(s.program, s.device, C.CL_PROGRAM_BUILD_LOG, 0, nil, &size) != C.CL_SUCCESS || size == 0 {
+		return "no build log"
+	}
+	buf := make([]byte, size)
+	if C.clGetProgramBuildInfo(s.program, s.device, C.CL_PROGRAM_BUILD_LOG, size, unsafe.Pointer(&buf[0]), nil) != C.CL_SUCCESS {
+		return "no build log"
+	}
+	return strings.TrimSpace(strings.TrimRight(string(buf), "\x00"))
+}
+
+// Scan runs one kernel launch over the batch. The header's first 64 bytes
+// are hashed here once, the GPU only hashes the rest. A launch can't be
+// interrupted, so abort is only checked before it.
+func (s *openCLScanner) Scan(header []byte, target *[32]byte, start uint32, count int, abort func() bool) (int, bool, error) {
+	if count <= 0 || abort() {
+		return 0, false, nil
+	}
+
+	midstate := sha256IV
+	sha256Block(&midstate, header[:64])
+	var tail [3]uint32
+	for i := range tail {
+		tail[i] = binary.BigEndian.Uint32(header[64+4*i:])
+	}
+	var targetWords [8]uint32
+	for i := range targetWords {
+		targetWords[i] = binary.BigEndian.Uint32(target[4*i:])
+	}
+	var result [2]uint32
+
+	// Blocking writes, the GPU copies the data before they return
+	writes := []struct {
+		mem  C.cl_mem
+		data unsafe.Pointer
+		size C.size_t
+	}{
+		{s.midstate, unsafe.Pointer(&midstate[0]), 32},
+		{s.tail, unsafe.Pointer(&tail[0]), 12},
+		{s.target, unsafe.Pointer(&targetWords[0]), 32},
+		{s.result, unsafe.Pointer(&result[0]), 8},
+	}
+	for _, w := range writes {
+		if status := C.clEnqueueWriteBuffer(s.queue, w.mem, C.CL_TRUE, 0, w.size, w.data, 0, nil, nil); status != C.CL_SUCCESS {
+			return 0, false, clError("clEnqueueWriteBuffer", status)
+		}
+	}
+
+	startArg, countArg := C.cl_uint(start), C.cl_uint(count)
+	if status := C.clSetKernelArg(s.kernel, 3, C.size_t(unsafe.Sizeof(startArg)), unsafe.Pointer(&startArg)); status != C.CL_SUCCESS {
+		return 0, false, clError("clSetKernelArg", status)
+	}
+	if status := C.clSetKernelArg(s.kernel, 4, C.size_t(unsafe.Sizeof(countArg)), unsafe.Pointer(&countArg)); status != C.CL_SUCCESS {
+		return 0, false, clError("clSetKernelArg", status)
+	}
+
+	// Round up to whole work groups, the kernel skips the excess
+	global := (C.size_t(count) + s.local - 1) / s.local * s.local
+	if status := C.clEnqueueNDRangeKernel(s.queue, s.kernel, 1, nil, &global, &s.local, 0, nil, nil); status != C.CL_SUCCESS {
+		return 0, false, clError("clEnqueueNDRangeKernel", status)
+	}
+	if status := C.clEnqueueReadBuffer(s.queue, s.result, C.CL_TRUE, 0, 8, unsafe.Pointer(&result[0]), 0, nil, nil); status != C.CL_SUCCESS {
+		return 0, false, clError("clEnqueueReadBuffer", status)
+	}
+	if result[0] == 0 {
+		return count, false, nil
+	}
+	return int(result[1]-start) + 1, true, nil
+}
+
+// Close releases everything open was able to create
+func (s *openCLScanner) Close() error {
+	var errs []error
+	for _, mem := range []C.cl_mem{s.midstate, s.tail, s.target, s.result} {
+		if mem != nil {
+			if status := C.clReleaseMemObject(mem); status != C.CL_SUCCESS {
+				errs = append(errs, clError("clReleaseMemObject", status))
+			}
+		}
+	}
+	if s.kernel != nil {
+		C.clReleaseKernel(s.kernel)
+	}
+	if s.program != nil {
+		C.clReleaseProgram(s.program)
+	}
+	if s.queue != nil {
+		C.clReleaseCommandQueue(s.queue)
+	}
+	if s.context != nil {
+		C.clReleaseContext(s.context)
+	}
+	*s = openCLScanner{}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,15 @@
+//go:build !opencl || !cgo
+
+package miner
+
+import "errors"
+
+// openCLDevices returns no GPUs, OpenCL support is built with -tags opencl
+func openCLDevices() []Device {
+	return nil
+}
+
+// openOpenCL always fails, OpenCL support is built with -tags opencl
+func openOpenCL(device string) (Scanner, error) {
+	return nil, errors.New("built without OpenCL support")
+}
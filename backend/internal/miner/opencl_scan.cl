@@ -0,0 +1,119 @@
+// Double SHA-256 of an 80-byte block header over a range of nonces. The
+// host hashes the first 64 bytes once into midstate, each work item
+// finishes the header with its nonce and hashes the digest again.
+
+#define ROTR(x, n) rotate((uint)(x), (uint)(32 - (n)))
+#define CH(e, f, g) bitselect((g), (f), (e))
+#define MAJ(a, b, c) bitselect((a), (b), ((a) ^ (c)))
+#define BSWAP(x) (rotate((x) & 0x00ff00ffu, 24u) | rotate((x) & 0xff00ff00u, 8u))
+
+__constant uint K[64] = {
+	0x428a2f98, 0x71374491, 0xb5c0fbcf, 0xe9b5dba5, 0x3956c25b, 0x59f111f1, 0x923f82a4, 0xab1c5ed5,
+	0xd807aa98, 0x12835b01, 0x243185be, 0x550c7dc3, 0x72be5d74, 0x80deb1fe, 0x9bdc06a7, 0xc19bf174,
+	0xe49b69c1, 0xefbe4786, 0x0fc19dc6, 0x240ca1cc, 0x2de92c6f, 0x4a7484aa, 0x5cb0a9dc, 0x76f988da,
+	0x983e5152, 0xa831c66d, 0xb00327c8, 0xbf597fc7, 0xc6e00bf3, 0xd5a79147, 0x06ca6351, 0x14292967,
+	0x27b70a85, 0x2e1b2138, 0x4d2c6dfc, 0x53380d13, 0x650a7354, 0x766a0abb, 0x81c2c92e, 0x92722c85,
+	0xa2bfe8a1, 0xa81a664b, 0xc24b8b70, 0xc76c51a3, 0xd192e819, 0xd6990624, 0xf40e3585, 0x106aa070,
+	0x19a4c116, 0x1e376c08, 0x2748774c, 0x34b0bcb5, 0x391c0cb3, 0x4ed8aa4a, 0x5b9cca4f, 0x682e6ff3,
+	0x748f82ee, 0x78a5636f, 0x84c87814, 0x8cc70208, 0x90befffa, 0xa4506ceb, 0xbef9a3f7, 0xc67178f2,
+};
+
+// sha256_block runs the compression function on the 16 words at the
+// start of w, using the rest of w for the message schedule
+void sha256_block(uint *h, uint *w)
+{
+	for (int i = 16; i < 64; i++) {
+		uint s0 = ROTR(w[i - 15], 7) ^ ROTR(w[i - 15], 18) ^ (w[i - 15] >> 3);
+		uint s1 = ROTR(w[i - 2], 17) ^ ROTR(w[i - 2], 19) ^ (w[i - 2] >> 10);
+		w[i] = w[i - 16] + s0 + w[i - 7] + s1;
+	}
+
+	uint a = h[0], b = h[1], c = h[2], d = h[3];
+	uint e = h[4], f = h[5], g = h[6], hh = h[7];
+	for (int i = 0; i < 64; i++) {
+		uint t1 = hh + (ROTR(e, 6) ^ ROTR(e, 11) ^ ROTR(e, 25)) + CH(e, f, g) + K[i] + w[i];
+		uint t2 = (ROTR(a, 2) ^ ROTR(a, 13) ^ ROTR(a, 22)) + MAJ(a, b, c);
+		hh = g;
+		g = f;
+		f = e;
+		e = d + t1;
+		d = c;
+		c = b;
+		b = a;
+		a = t1 + t2;
+	}
+
+	h[0] += a;
+	h[1] += b;
+	h[2] += c;
+	h[3] += d;
+	h[4] += e;
+	h[5] += f;
+	h[6] += g;
+	h[7] += hh;
+}
+
+// scan hashes nonces start to start+count-1. midstate is the state after
+// the header's first 64 bytes, tail its next 12 as big-endian words and
+// target the 32-byte big-endian target as words. The first work item to
+// meet the target sets result[0] and stores its nonce in result[1].
+__kernel void scan(__constant uint *midstate, __constant uint *tail, __constant uint *target,
+                   const uint start, const uint count, __global uint *result)
+{
+	uint gid = get_global_id(0);
+	if (gid >= count) {
+		return;
+	}
+	uint nonce = start + gid;
+
+	uint w[64];
+	uint h[8];
+	for (int i = 0; i < 8; i++) {
+		h[i] = midstate[i];
+	}
+	w[0] = tail[0];
+	w[1] = tail[1];
+	w[2] = tail[2];
+	// The header stores the nonce little-endian
+	w[3] = BSWAP(nonce);
+	w[4] = 0x80000000u;
+	for (int i = 5; i < 15; i++) {
+		w[i] = 0;
+	}
+	w[15] = 640;
+	sha256_block(h, w);
+
+	for (int i = 0; i < 8; i++) {
+		w[i] = h[i];
+	}
+	w[8] = 0x80000000u;
+	for (int i = 9; i < 15; i++) {
+		w[i] = 0;
+	}
+	w[15] = 256;
+	h[0] = 0x6a09e667;
+	h[1] = 0xbb67ae85;
+	h[2] = 0x3c6ef372;
+	h[3] = 0xa54ff53a;
+	h[4] = 0x510e527f;
+	h[5] = 0x9b05688c;
+	h[6] = 0x1f83d9ab;
+	h[7] = 0x5be0cd19;
+	sha256_block(h, w);
+
+	// The hash is compared as a little-endian number: its most
+	// significant word is the last one, byte-swapped
+	for (int j = 0; j < 8; j++) {
+		uint v = BSWAP(h[7 - j]);
+		if (v < target[j]) {
+			break;
+		}
+		if (v > target[j]) {
+			return;
+		}
+	}
+
+	if (atomic_cmpxchg(&result[0], 0u, 1u) == 0u) {
+		result[1] = nonce;
+	}
+}
@@ -0,0 +1,50 @@
+package miner
+
+import (
+	"log"
+	"runtime"
+)
+
+// Scheduling priorities for mining threads
+const (
+	// PriorityNormal leaves mining threads at the process's priority
+	PriorityNormal = "normal"
+	// PriorityLow lowers them below other work on the host
+	PriorityLow = "low"
+	// PriorityIdle runs them only when the CPU would otherwise be idle
+	PriorityIdle = "idle"
+)
+
+// SetPriority sets the scheduling priority of mining threads. Workers
+// take it when they start, so it applies from the next StartAll.
+func (m *Manager) SetPriority(priority string) {
+	m.mu.Lock()
+	m.priority = priority
+	m.mu.Unlock()
+
+	for _, w := range m.GetAllWorkers() {
+		w.SetPriority(priority)
+	}
+}
+
+// SetPriority sets the scheduling priority the worker's mining thread
+// takes when it starts
+func (w *Worker) SetPriority(priority string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.priority = priority
+}
+
+// lowerPriority lowers the mining goroutine's thread to priority. As with
+// pinCPU the goroutine stays locked to the thread, which exits with the
+// mining loop, so the priority never reaches the API or storage and
+// needs no restoring, which an unprivileged process couldn't do.
+func (w *Worker) lowerPriority(priority string) {
+	if priority == "" || priority == PriorityNormal {
+		return
+	}
+	runtime.LockOSThread()
+	if err := setThreadPriority(priority); err != nil {
+		log.Printf("Worker %d: priority %s: %v", w.ID, priority, err)
+	}
+}
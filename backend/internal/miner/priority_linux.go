@@ -0,0 +1,36 @@
+package miner
+
+import "golang.org/x/sys/unix"
+
+// I/O scheduling classes for ioprio_set
+const (
+	ioprioClassBestEffort = 2
+	ioprioClassIdle       = 3
+	ioprioClassShift      = 13
+	ioprioWhoProcess      = 1
+)
+
+// setThreadPriority lowers the calling thread's CPU and I/O priority. Low
+// is nice 10 with the lowest best-effort I/O priority; idle is nice 19
+// under SCHED_IDLE with the idle I/O class. On Linux both apply to the
+// thread alone.
+func setThreadPriority(priority string) error {
+	nice, ioprio := 10, ioprioClassBestEffort<<ioprioClassShift|7
+	if priority == PriorityIdle {
+		nice, ioprio = 19, ioprioClassIdle<<ioprioClassShift
+	}
+
+	if err := unix.Setpriority(unix.PRIO_PROCESS, 0, nice); err != nil {
+		return err
+	}
+	if priority == PriorityIdle {
+		attr := unix.SchedAttr{Size: unix.SizeofSchedAttr, Policy: unix.SCHED_IDLE, Nice: int32(nice)}
+		if err := unix.SchedSetAttr(0, &attr, 0); err != nil {
+			return err
+		}
+	}
+	if _, _, errno := unix.Syscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, 0, uintptr(ioprio)); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !windows
+
+package miner
+
+import "errors"
+
+// setThreadPriority always fails, priorities are only set on Linux and
+// Windows
+func setThreadPriority(priority string) error {
+	return errors.New("not supported on this platform")
+}
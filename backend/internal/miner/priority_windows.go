@@ -0,0 +1,25 @@
+package miner
+
+import "golang.org/x/sys/windows"
+
+// Thread priority levels for SetThreadPriority
+const (
+	threadPriorityLowest = -2
+	threadPriorityIdle   = -15
+)
+
+var procSetThreadPriority = windows.NewLazySystemDLL("kernel32.dll").NewProc("SetThreadPriority")
+
+// setThreadPriority lowers the calling thread's priority within the
+// process's priority class, lowest for low and idle for idle, leaving
+// the rest of the process at its class
+func setThreadPriority(priority string) error {
+	level := threadPriorityLowest
+	if priority == PriorityIdle {
+		level = threadPriorityIdle
+	}
+	if ok, _, err := procSetThreadPriority.Call(uintptr(windows.CurrentThread()), uintptr(level)); ok == 0 {
+		return err
+	}
+	return nil
+}
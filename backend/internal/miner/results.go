@@ -0,0 +1,257 @@
+package miner
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Workers queue found nonces for the submitter instead of handling them
+// on the mining goroutine. Up to resultQueueSize shares wait; past that
+// they are dropped rather than stalling hashing. A block is submitted
+// from the mining goroutine straight away and then queued on its own
+// queue, served first, which waits for room instead. Shares already
+// handled among the last resultDedupeSize are skipped.
+const (
+	resultQueueSize   = 256
+	blockQueueSize    = 16
+	resultDedupeSize  = 1024
+	resultDedupeShift = resultDedupeSize / 2
+)
+
+// foundShare is a nonce a worker found, with the header it completes and
+// the targets in force, for the submitter to check
+type foundShare struct {
+	worker        *Worker
+	epoch         uint64
+	jobID         string
+	extranonce2   string
+	ntime         string
+	nonce         string
+	versionBits   string
+	header        [80]byte
+	target        [32]byte
+	networkTarget [32]byte
+	// Set once the block callback has taken it on the mining goroutine
+	blockSubmitted bool
+}
+
+// ResultStats counts what became of the nonces workers found
+type ResultStats struct {
+	// Waiting for the submitter now
+	Queued int `json:"queued"`
+	// Handed to the share callback, and those that were blocks
+	Submitted uint64 `json:"submitted"`
+	Blocks    uint64 `json:"blocks"`
+	// Already handled, failing the target on the recheck, or dropped
+	// because the queue was full
+	Duplicates uint64 `json:"duplicates"`
+	Invalid    uint64 `json:"invalid"`
+	Dropped    uint64 `json:"dropped"`
+}
+
+// resultPipeline is the queue between the workers and the submitter
+type resultPipeline struct {
+	shares chan foundShare
+	blocks chan foundShare
+
+	// The running submitter's stop channel and exit, nil while stopped
+	stop chan struct{}
+	done chan struct{}
+
+	// Recently handled shares, shared by the submitter and the block
+	// fast path
+	seenMu    sync.Mutex
+	seen      map[string]struct{}
+	seenOrder []string
+
+	submitted  atomic.Uint64
+	blocksSeen atomic.Uint64
+	duplicates atomic.Uint64
+	invalid    atomic.Uint64
+	dropped    atomic.Uint64
+
+	// Set while shares are being dropped, so a full queue logs once
+	overflowing atomic.Bool
+}
+
+// newResultPipeline creates an empty, stopped pipeline
+func newResultPipeline() *resultPipeline {
+	return &resultPipeline{
+		shares: make(chan foundShare, resultQueueSize),
+		blocks: make(chan foundShare, blockQueueSize),
+		seen:   make(map[string]struct{}),
+	}
+}
+
+// queueShare hands a found nonce to the submitter without waiting, unless
+// it is a block and the block queue is full. Called by mining goroutines,
+// which submit a block themselves first so it never waits behind shares.
+func (m *Manager) queueShare(s foundShare) {
+	hash := doubleSHA256Sum(s.header[:])
+	if meetsTarget(&hash, &s.networkTarget) {
+		if !m.submitBlock(s) {
+			return
+		}
+		s.blockSubmitted = true
+		m.results.blocks <- s
+		return
+	}
+	select {
+	case m.results.shares <- s:
+		m.results.overflowing.Store(false)
+	default:
+		m.results.dropped.Add(1)
+		if !m.results.overflowing.Swap(true) {
+			log.Printf("Worker %d: share queue full, dropping shares until the submitter catches up", s.worker.ID)
+		}
+	}
+}
+
+// submitBlock passes a block solution to the block callback, returning
+// false for a duplicate. The caller checked the hash, so it goes out
+// before the recheck; the submitter only does the share bookkeeping.
+func (m *Manager) submitBlock(s foundShare) bool {
+	if !m.results.remember(s.key()) {
+		m.results.duplicates.Add(1)
+		return false
+	}
+
+	m.mu.RLock()
+	onBlock := m.onBlockFound
+	m.mu.RUnlock()
+
+	m.results.blocksSeen.Add(1)
+	if onBlock != nil {
+		onBlock(s.epoch, s.jobID, s.extranonce2, s.ntime, s.nonce, s.versionBits)
+	}
+	return true
+}
+
+// startResults starts the submitter unless it runs already
+func (m *Manager) startResults() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.results.stop != nil {
+		return
+	}
+	stop, done := make(chan struct{}), make(chan struct{})
+	m.results.stop, m.results.done = stop, done
+	go m.submitLoop(stop, done)
+}
+
+// stopResults stops the submitter once it has handled everything queued
+func (m *Manager) stopResults() {
+	m.mu.Lock()
+	stop, done := m.results.stop, m.results.done
+	m.results.stop, m.results.done = nil, nil
+	m.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// submitLoop handles queued nonces, blocks before shares, until stopped,
+// then drains both queues
+func (m *Manager) submitLoop(stop, done chan struct{}) {
+	defer close(done)
+	for {
+		select {
+		case s := <-m.results.blocks:
+			m.handleFound(s)
+			continue
+		default:
+		}
+
+		select {
+		case s := <-m.results.blocks:
+			m.handleFound(s)
+		case s := <-m.results.shares:
+			m.handleFound(s)
+		case <-stop:
+			for {
+				select {
+				case s := <-m.results.blocks:
+					m.handleFound(s)
+				case s := <-m.results.shares:
+					m.handleFound(s)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// handleFound checks a found nonce against its target, so a device
+// returning a wrong one cannot get it submitted, skips duplicates and
+// passes the rest to the share callback. Blocks were submitted by the
+// mining goroutine already.
+func (m *Manager) handleFound(s foundShare) {
+	hash := doubleSHA256Sum(s.header[:])
+	block := s.blockSubmitted
+	if !block {
+		if !meetsTarget(&hash, &s.target) {
+			m.results.invalid.Add(1)
+			s.worker.reportError(WorkerErrorDevice, s.jobID, fmt.Errorf("nonce %s does not meet the target", s.nonce))
+			return
+		}
+		if !m.results.remember(s.key()) {
+			m.results.duplicates.Add(1)
+			return
+		}
+	}
+
+	difficulty := hashDifficulty(hash[:])
+	s.worker.recordShare(difficulty)
+
+	m.mu.RLock()
+	onShare := m.onShareFound
+	m.mu.RUnlock()
+
+	m.results.submitted.Add(1)
+	if onShare != nil {
+		onShare(s.worker.ID, s.epoch, s.jobID, s.extranonce2, s.ntime, s.nonce, s.versionBits, difficulty, block)
+	}
+}
+
+// key identifies a found share for duplicate checks
+func (s foundShare) key() string {
+	return strings.Join([]string{fmt.Sprint(s.epoch), s.jobID, s.extranonce2, s.ntime, s.nonce, s.versionBits}, ":")
+}
+
+// remember records a share key, returning false if it was seen recently.
+// The oldest half is forgotten when the set fills.
+func (p *resultPipeline) remember(key string) bool {
+	p.seenMu.Lock()
+	defer p.seenMu.Unlock()
+	if _, ok := p.seen[key]; ok {
+		return false
+	}
+	if len(p.seenOrder) >= resultDedupeSize {
+		for _, old := range p.seenOrder[:resultDedupeShift] {
+			delete(p.seen, old)
+		}
+		p.seenOrder = append(p.seenOrder[:0], p.seenOrder[resultDedupeShift:]...)
+	}
+	p.seen[key] = struct{}{}
+	p.seenOrder = append(p.seenOrder, key)
+	return true
+}
+
+// GetResultStats returns what became of the nonces workers found
+func (m *Manager) GetResultStats() ResultStats {
+	p := m.results
+	return ResultStats{
+		Queued:     len(p.shares) + len(p.blocks),
+		Submitted:  p.submitted.Load(),
+		Blocks:     p.blocksSeen.Load(),
+		Duplicates: p.duplicates.Load(),
+		Invalid:    p.invalid.Load(),
+		Dropped:    p.dropped.Load(),
+	}
+}
@@ -0,0 +1,182 @@
+package miner
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+
+	"github.com/soloforge/backend/internal/stratum"
+)
+
+// SelfTestStep is the outcome of one self-test check
+type SelfTestStep struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Pass        bool   `json:"pass"`
+	Expected    string `json:"expected,omitempty"`
+	Got         string `json:"got,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// genesisCoinbase is the coinbase transaction of block 0
+const genesisCoinbase = "01000000010000000000000000000000000000000000000000000000000000000000000000ffffffff4d04ffff001d0104455468652054696d65732030332f4a616e2f32303039204368616e63656c6c6f72206f6e206272696e6b206f66207365636f6e64206261696c6f757420666f722062616e6b73ffffffff0100f2052a01000000434104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac00000000"
+
+// Known mainnet blocks, hashes in explorer byte order
+const (
+	genesisHash       = "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f"
+	genesisMerkleRoot = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+	genesisNonce      = 2083236893
+
+	// Block 170, the first with a payment, has two transactions
+	block170Coinbase   = "b1fea52486ce0c62bb442b530a3f0132b826c74e473d1f2c220bfa78111c5082"
+	block170Payment    = "f4184fc596403b9d638783cf57adfe4c75c605f6356fbc91338530e9831e9e16"
+	block170MerkleRoot = "7dac2c5666815c17a3b36427de37bb9d2e2c5ccec3f8633eb91a4205cb4c10ff"
+
+	// Block 125552, the worked example of the block hashing algorithm
+	block125552PrevHash   = "00000000000008a3a41b85b8b29ad444def299fee21793cd8b9e567eab02cd81"
+	block125552MerkleRoot = "2b12fcf1b09288fcaff797d71e950e71ae42b91e8bdb2304758dfcffc2b620e3"
+	block125552Hash       = "00000000000000001e8d6829a8a21adc5d38d0a473b144b6765798e61f98bd1d"
+	block125552Bits       = "1a44b9f2"
+)
+
+// scanWindow is how many nonces around a winning one the scan step
+// searches
+const scanWindow = 64
+
+// genesisJob is block 0 as a pool would send it over Stratum, the
+// coinbase split around an 8-byte extranonce
+func genesisJob() (*stratum.Job, string, string) {
+	split := len(genesisCoinbase) - 16 - 2*60
+	return &stratum.Job{
+		ID:        "genesis",
+		PrevHash:  stratumPrevHash(make([]byte, 32)),
+		Coinbase1: genesisCoinbase[:split],
+		Coinbase2: genesisCoinbase[split+16:],
+		Version:   "00000001",
+		NBits:     "1d00ffff",
+		NTime:     "495fab29",
+	}, genesisCoinbase[split : split+8], genesisCoinbase[split+8 : split+16]
+}
+
+// stratumPrevHash encodes a hash in internal byte order the way Stratum
+// sends prevhash, each 32-bit word byte-swapped
+func stratumPrevHash(hash []byte) string {
+	b := append([]byte(nil), hash...)
+	SwapWords(b)
+	return hex.EncodeToString(b)
+}
+
+// explorerHex decodes an explorer-order hash into internal byte order
+func explorerHex(s string) []byte {
+	b, _ := hex.DecodeString(s)
+	return reverseBytes(b)
+}
+
+// SelfTest checks the hashing, merkle, header and target code against
+// known mainnet blocks, and that every device finds a known winning
+// nonce, returning one step per check
+func SelfTest() []SelfTestStep {
+	steps := []SelfTestStep{
+		selfTestSHA256(),
+		selfTestCoinbase(),
+		selfTestMerkle(),
+		selfTestHeader(),
+		selfTestBuildHeader(),
+		selfTestTarget(),
+	}
+	for _, device := range Devices() {
+		steps = append(steps, selfTestScan(device.ID))
+	}
+	return steps
+}
+
+// selfTestStep compares a result with the expected value
+func selfTestStep(name, description, expected, got string, err error) SelfTestStep {
+	step := SelfTestStep{Name: name, Description: description, Expected: expected, Got: got}
+	if err != nil {
+		step.Error = err.Error()
+		return step
+	}
+	step.Pass = got == expected
+	return step
+}
+
+func selfTestSHA256() SelfTestStep {
+	job, extranonce1, extranonce2 := genesisJob()
+	header, _, err := BuildHeader(job, extranonce1, extranonce2, job.NTime, fmt.Sprintf("%08x", genesisNonce), "")
+	if err != nil {
+		return selfTestStep("sha256d", "", "", "", err)
+	}
+	reference := referenceDoubleSHA256(header)
+	return selfTestStep("sha256d", "Double SHA-256 on the "+selectedBackend.Name+" backend matches the reference implementation",
+		hex.EncodeToString(reference[:]), hex.EncodeToString(doubleSHA256(header)), nil)
+}
+
+func selfTestCoinbase() SelfTestStep {
+	coinbase, _ := hex.DecodeString(genesisCoinbase)
+	return selfTestStep("coinbase_txid", "Genesis coinbase hashes to its transaction ID",
+		genesisMerkleRoot, hex.EncodeToString(reverseBytes(doubleSHA256(coinbase))), nil)
+}
+
+func selfTestMerkle() SelfTestStep {
+	root := doubleSHA256(append(explorerHex(block170Coinbase), explorerHex(block170Payment)...))
+	return selfTestStep("merkle_root", "Block 170's coinbase and payment combine to its merkle root",
+		block170MerkleRoot, hex.EncodeToString(reverseBytes(root)), nil)
+}
+
+func selfTestHeader() SelfTestStep {
+	header, err := layoutHeader("00000001", stratumPrevHash(explorerHex(block125552PrevHash)),
+		explorerHex(block125552MerkleRoot), "4dd7f5c7", block125552Bits, "9546a142")
+	if err != nil {
+		return selfTestStep("header", "", "", "", err)
+	}
+	return selfTestStep("header", "Block 125552's fields, encoded as Stratum sends them, lay out a header with its hash",
+		block125552Hash, hex.EncodeToString(reverseBytes(doubleSHA256(header))), nil)
+}
+
+func selfTestBuildHeader() SelfTestStep {
+	job, extranonce1, extranonce2 := genesisJob()
+	header, _, err := BuildHeader(job, extranonce1, extranonce2, job.NTime, fmt.Sprintf("%08x", genesisNonce), "")
+	if err != nil {
+		return selfTestStep("build_header", "", "", "", err)
+	}
+	return selfTestStep("build_header", "The genesis block as a Stratum job, coinbase split around the extranonce, builds its header",
+		genesisHash, hex.EncodeToString(reverseBytes(doubleSHA256(header))), nil)
+}
+
+func selfTestTarget() SelfTestStep {
+	// Difficulty 244112.49 at block 125552, rounded to hundredths
+	difficulty := math.Round(NetworkDifficulty(block125552Bits)*100) / 100
+	hash := [32]byte(explorerHex(block125552Hash))
+	target := targetBytes(block125552Bits)
+	got := fmt.Sprintf("difficulty %.2f, meets target %t", difficulty, meetsTarget(&hash, &target))
+	return selfTestStep("target", "Block 125552's bits decode to its difficulty and its hash meets the target",
+		"difficulty 244112.49, meets target true", got, nil)
+}
+
+// selfTestScan scans a window of nonces around the genesis nonce on a
+// device, which must stop at exactly that nonce
+func selfTestScan(device string) SelfTestStep {
+	name := "scan:" + device
+	description := "Scanning around the genesis nonce on " + device + " stops at the winning nonce"
+
+	scanner, err := openScanner(device)
+	if err != nil {
+		return selfTestStep(name, description, "", "", err)
+	}
+	defer scanner.Close()
+
+	job, extranonce1, extranonce2 := genesisJob()
+	t, err := newHeaderTemplate(job, extranonce1, extranonce2, 0, 0)
+	if err != nil {
+		return selfTestStep(name, description, "", "", err)
+	}
+
+	start := uint32(genesisNonce - scanWindow/2)
+	scanned, found, err := scanner.Scan(t.header, &t.target, start, scanWindow, func() bool { return false })
+	got := "no nonce found"
+	if found {
+		got = fmt.Sprintf("%08x", start+uint32(scanned-1))
+	}
+	return selfTestStep(name, description, fmt.Sprintf("%08x", genesisNonce), got, err)
+}
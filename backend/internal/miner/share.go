@@ -0,0 +1,186 @@
+package miner
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/soloforge/backend/internal/stratum"
+)
+
+// difficulty1Target is the target of a difficulty 1 share
+var difficulty1Target, _ = new(big.Int).SetString("00000000FFFF0000000000000000000000000000000000000000000000000000", 16)
+
+// ShareDifficulty hashes a share found by an external miner, laid out the
+// same way the workers build headers, and returns its difficulty. version
+// is the rolled version, or empty for the job's version.
+func ShareDifficulty(job *stratum.Job, extranonce1, extranonce2, ntime, nonce, version string) (float64, error) {
+	header, _, err := BuildHeader(job, extranonce1, extranonce2, ntime, nonce, version)
+	if err != nil {
+		return 0, err
+	}
+	return hashDifficulty(doubleSHA256(header)), nil
+}
+
+// BuildHeader assembles a share's coinbase and 80-byte header the same way
+// the workers do. version is the rolled version, or empty for the job's.
+func BuildHeader(job *stratum.Job, extranonce1, extranonce2, ntime, nonce, version string) (header, coinbase []byte, err error) {
+	coinbase, err = hex.DecodeString(job.Coinbase1 + extranonce1 + extranonce2 + job.Coinbase2)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid coinbase: %w", err)
+	}
+
+	merkleRoot := doubleSHA256(coinbase)
+	for i, branch := range job.MerkleBranch {
+		branchBytes, err := decodeHexField(fmt.Sprintf("merkle branch %d", i), branch, 32)
+		if err != nil {
+			return nil, nil, err
+		}
+		merkleRoot = doubleSHA256(append(merkleRoot, branchBytes...))
+	}
+
+	if version == "" {
+		version = job.Version
+	}
+	header, err = layoutHeader(version, job.PrevHash, merkleRoot, ntime, job.NBits, nonce)
+	if err != nil {
+		return nil, nil, err
+	}
+	return header, coinbase, nil
+}
+
+// layoutHeader serializes a block header from fields encoded as Stratum
+// sends them: version, ntime, nbits and nonce as big-endian hex numbers,
+// prevhash with each 32-bit word byte-swapped. The merkle root is the raw
+// double SHA-256 output.
+func layoutHeader(version, prevHash string, merkleRoot []byte, ntime, nbits, nonce string) ([]byte, error) {
+	header := make([]byte, 80)
+	fields := []struct {
+		name  string
+		value string
+		size  int
+		at    int
+	}{
+		{"version", version, 4, 0},
+		{"prevhash", prevHash, 32, 4},
+		{"ntime", ntime, 4, 68},
+		{"nbits", nbits, 4, 72},
+		{"nonce", nonce, 4, 76},
+	}
+	for _, f := range fields {
+		b, err := decodeHexField(f.name, f.value, f.size)
+		if err != nil {
+			return nil, err
+		}
+		// Byte-swapping each word makes the numbers little-endian and
+		// puts prevhash back in internal byte order
+		SwapWords(b)
+		copy(header[f.at:], b)
+	}
+	copy(header[36:68], merkleRoot)
+	return header, nil
+}
+
+// SwapWords byte-swaps every 32-bit word in place
+func SwapWords(b []byte) {
+	for i := 0; i+4 <= len(b); i += 4 {
+		b[i], b[i+1], b[i+2], b[i+3] = b[i+3], b[i+2], b[i+1], b[i]
+	}
+}
+
+// decodeHexField decodes a hex job field that must be size bytes long
+func decodeHexField(name, value string, size int) ([]byte, error) {
+	b, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	if len(b) != size {
+		return nil, fmt.Errorf("invalid %s: %d bytes, want %d", name, len(b), size)
+	}
+	return b, nil
+}
+
+// RolledVersion applies BIP 310 version bits to a job's version, returning
+// empty when no bits were rolled
+func RolledVersion(jobVersion, versionBits string, mask uint32) (string, error) {
+	if versionBits == "" {
+		return "", nil
+	}
+
+	bits, err := strconv.ParseUint(versionBits, 16, 32)
+	if err != nil {
+		return "", fmt.Errorf("invalid version bits")
+	}
+	if uint32(bits)&^mask != 0 {
+		return "", fmt.Errorf("version bits outside mask")
+	}
+
+	base, err := hex.DecodeString(jobVersion)
+	if err != nil || len(base) != 4 {
+		return "", fmt.Errorf("invalid job version")
+	}
+
+	rolled := make([]byte, 4)
+	binary.BigEndian.PutUint32(rolled, binary.BigEndian.Uint32(base)&^mask|uint32(bits)&mask)
+	return hex.EncodeToString(rolled), nil
+}
+
+// hashDifficulty returns the share difficulty a header hash meets
+func hashDifficulty(hash []byte) float64 {
+	hashInt := new(big.Int).SetBytes(reverseBytes(hash))
+	if hashInt.Sign() == 0 {
+		return 0
+	}
+
+	diff, _ := new(big.Float).Quo(new(big.Float).SetInt(difficulty1Target), new(big.Float).SetInt(hashInt)).Float64()
+	return diff
+}
+
+// MeetsNetworkTarget reports whether a header's hash meets the network
+// target encoded by nbits, making it a block solution
+func MeetsNetworkTarget(header []byte, nbits string) bool {
+	hash := doubleSHA256Sum(header)
+	target := targetBytes(nbits)
+	return meetsTarget(&hash, &target)
+}
+
+// HashesPerDifficulty is the expected number of hashes per difficulty-1 share
+const HashesPerDifficulty = 4294967296.0
+
+// ShareTarget returns the target a share of the given difficulty must
+// meet as 32 big-endian bytes, saturated below difficulty 1/2^32
+func ShareTarget(difficulty float64) [32]byte {
+	var b [32]byte
+	if difficulty <= 0 {
+		difficulty = 1
+	}
+
+	target, _ := new(big.Float).Quo(new(big.Float).SetInt(difficulty1Target), big.NewFloat(difficulty)).Int(nil)
+	if target.BitLen() > 256 {
+		target = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	}
+	target.FillBytes(b[:])
+	return b
+}
+
+// Midstate returns the SHA-256 state after the first 64 bytes of a
+// header, which miners hashing the tail themselves start from
+func Midstate(header []byte) [8]uint32 {
+	state := sha256IV
+	sha256Block(&state, header[:64])
+	return state
+}
+
+// NetworkDifficulty returns the block difficulty encoded in a job's nBits,
+// or 0 if nBits is malformed
+func NetworkDifficulty(nbits string) float64 {
+	target := calculateTarget(nbits)
+	if target.Sign() == 0 {
+		return 0
+	}
+
+	diff, _ := new(big.Float).Quo(new(big.Float).SetInt(difficulty1Target), new(big.Float).SetInt(target)).Float64()
+	return diff
+}
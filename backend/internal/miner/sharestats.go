@@ -0,0 +1,52 @@
+package miner
+
+import "time"
+
+// WorkerShares counts the shares a worker found and the pool's verdicts
+// on them. Stale shares count as rejected, shares never submitted (found
+// for a previous session) as neither.
+type WorkerShares struct {
+	Found          uint64     `json:"found"`
+	Accepted       uint64     `json:"accepted"`
+	Rejected       uint64     `json:"rejected"`
+	BestDifficulty float64    `json:"best_difficulty"`
+	LastShareAt    *time.Time `json:"last_share_at,omitempty"`
+}
+
+// recordShare counts a share the worker found, called by the submitter
+// once the share is verified
+func (w *Worker) recordShare(difficulty float64) {
+	now := time.Now()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.shares.Found++
+	w.shares.BestDifficulty = max(w.shares.BestDifficulty, difficulty)
+	w.shares.LastShareAt = &now
+}
+
+// RecordVerdict counts the pool's verdict on one of the worker's shares
+func (w *Worker) RecordVerdict(accepted bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if accepted {
+		w.shares.Accepted++
+	} else {
+		w.shares.Rejected++
+	}
+}
+
+// GetShares returns the worker's share counts
+func (w *Worker) GetShares() WorkerShares {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.shares
+}
+
+// RecordShareVerdict credits the pool's verdict to the worker that found
+// the share, if it still exists
+func (m *Manager) RecordShareVerdict(workerID int, accepted bool) {
+	if worker := m.GetWorker(workerID); worker != nil {
+		worker.RecordVerdict(accepted)
+	}
+}
@@ -0,0 +1,109 @@
+package miner
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/soloforge/backend/internal/stratum"
+)
+
+// headerTemplate is a header with everything but ntime and the nonce
+// filled in. The coinbase, merkle root and hex decoding are done once per
+// job, extranonce and version instead of on every batch.
+type headerTemplate struct {
+	job         *stratum.Job
+	extranonce1 string
+	extranonce2 string
+	versionMask uint32
+	versionBits uint32
+
+	header []byte
+	ntime  string
+
+	// Scans stop at target, the easier of the share target and the
+	// network target a block must meet
+	target          [32]byte
+	networkTarget   [32]byte
+	shareDifficulty float64
+}
+
+// newHeaderTemplate builds the header for a job, or returns an error if
+// the job is malformed. The bits of versionBits selected by versionMask
+// replace those bits of the job version.
+func newHeaderTemplate(job *stratum.Job, extranonce1, extranonce2 string, versionMask, versionBits uint32) (*headerTemplate, error) {
+	var version string
+	if versionMask != 0 {
+		var err error
+		version, err = RolledVersion(job.Version, fmt.Sprintf("%08x", versionBits&versionMask), versionMask)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	header, _, err := BuildHeader(job, extranonce1, extranonce2, job.NTime, "00000000", version)
+	if err != nil {
+		return nil, err
+	}
+
+	target := targetBytes(job.NBits)
+	return &headerTemplate{
+		job:           job,
+		extranonce1:   extranonce1,
+		extranonce2:   extranonce2,
+		versionMask:   versionMask,
+		versionBits:   versionBits,
+		header:        header,
+		ntime:         job.NTime,
+		target:        target,
+		networkTarget: target,
+	}, nil
+}
+
+// setShareDifficulty makes scans stop at shares of the pool difficulty
+// as well as at blocks, whichever target is easier. 0 scans for blocks
+// only.
+func (t *headerTemplate) setShareDifficulty(difficulty float64) {
+	if difficulty == t.shareDifficulty {
+		return
+	}
+	t.shareDifficulty = difficulty
+	t.target = t.networkTarget
+	if difficulty > 0 {
+		if share := ShareTarget(difficulty); bytes.Compare(share[:], t.target[:]) > 0 {
+			t.target = share
+		}
+	}
+}
+
+// targetBytes returns the target from nBits as 32 big-endian bytes,
+// saturated if nBits encodes more than 256 bits
+func targetBytes(nbits string) [32]byte {
+	var b [32]byte
+	target := calculateTarget(nbits)
+	if target.BitLen() > 256 {
+		target = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	}
+	target.FillBytes(b[:])
+	return b
+}
+
+// matches reports whether the template was built for this work
+func (t *headerTemplate) matches(job *stratum.Job, extranonce1, extranonce2 string, versionMask, versionBits uint32) bool {
+	return t != nil && t.job == job && t.extranonce1 == extranonce1 && t.extranonce2 == extranonce2 &&
+		t.versionMask == versionMask && t.versionBits == versionBits
+}
+
+// setNTime writes a rolled ntime into the header, decoding it only when
+// it changed
+func (t *headerTemplate) setNTime(ntime string) {
+	if ntime == t.ntime {
+		return
+	}
+	if b, err := hex.DecodeString(ntime); err == nil && len(b) == 4 {
+		SwapWords(b)
+		copy(t.header[68:72], b)
+		t.ntime = ntime
+	}
+}
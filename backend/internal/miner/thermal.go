@@ -0,0 +1,99 @@
+package miner
+
+import "time"
+
+// Thermal throttling steps the CPU cap down by thermalCutStep while the
+// host is over the limit, and back up by thermalRestoreStep once it is
+// thermalHysteresis degrees below it, at most once per thermalInterval so
+// each step shows in the temperature before the next.
+const (
+	thermalCutStep     = 10
+	thermalRestoreStep = 5
+	minThermalPercent  = 10
+	thermalHysteresis  = 3.0
+	thermalInterval    = 5 * time.Second
+)
+
+// ThermalStatus is the last temperature reading and the CPU cap it set
+type ThermalStatus struct {
+	Celsius      float64 `json:"celsius"`
+	LimitCelsius float64 `json:"limit_celsius"`
+	// Cap on every worker's CPU percent, 100 when not throttled
+	CapPercent int  `json:"cap_percent"`
+	Throttled  bool `json:"throttled"`
+}
+
+// effectiveCPUPercentLocked returns the CPU percent workers run at: the
+// requested one, lowered to the thermal cap
+func (m *Manager) effectiveCPUPercentLocked() int {
+	if m.thermalCap > 0 {
+		return min(m.cpuPercent, m.thermalCap)
+	}
+	return m.cpuPercent
+}
+
+// RegulateTemperature records a CPU temperature reading and moves the
+// thermal cap towards keeping it under limitCelsius. A limit of 0 lifts
+// the cap. It returns the cap, 100 when not throttled.
+func (m *Manager) RegulateTemperature(celsius, limitCelsius float64) int {
+	m.mu.Lock()
+	m.celsius = celsius
+	m.limitCelsius = limitCelsius
+
+	capPercent := m.thermalCap
+	switch {
+	case limitCelsius <= 0:
+		capPercent = 0
+	case time.Since(m.thermalAdjusted) < thermalInterval:
+	case celsius > limitCelsius:
+		// Start cutting from what workers actually run at
+		if capPercent == 0 {
+			capPercent = m.cpuPercent
+		}
+		capPercent = max(capPercent-thermalCutStep, minThermalPercent)
+	case capPercent > 0 && celsius < limitCelsius-thermalHysteresis:
+		capPercent += thermalRestoreStep
+		if capPercent >= m.cpuPercent {
+			capPercent = 0
+		}
+	}
+
+	changed := capPercent != m.thermalCap
+	if changed {
+		m.thermalCap = capPercent
+		m.thermalAdjusted = time.Now()
+	}
+	percent := m.effectiveCPUPercentLocked()
+	workers := make([]*Worker, 0, len(m.workers))
+	for _, w := range m.workers {
+		workers = append(workers, w)
+	}
+	m.mu.Unlock()
+
+	if changed {
+		for _, w := range workers {
+			w.SetCPUPercent(percent)
+		}
+	}
+	return thermalCapPercent(capPercent)
+}
+
+// GetThermalStatus returns the last temperature reading and thermal cap
+func (m *Manager) GetThermalStatus() ThermalStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return ThermalStatus{
+		Celsius:      m.celsius,
+		LimitCelsius: m.limitCelsius,
+		CapPercent:   thermalCapPercent(m.thermalCap),
+		Throttled:    m.thermalCap > 0,
+	}
+}
+
+// thermalCapPercent reports an unset cap as 100
+func thermalCapPercent(capPercent int) int {
+	if capPercent == 0 {
+		return 100
+	}
+	return capPercent
+}
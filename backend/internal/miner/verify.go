@@ -0,0 +1,204 @@
+package miner
+
+import (
+	"bytes"
+	"encoding/binary"
+	"log"
+	"math/bits"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Hash verification sampling and alert thresholds
+const (
+	// verifyInterval is the average number of hashes between checks
+	verifyInterval = 100000
+
+	// unstableMismatches within mismatchWindow mark the hardware or the
+	// optimized backend as unreliable
+	unstableMismatches = 3
+	mismatchWindow     = 10 * time.Minute
+)
+
+// HashCheck reports how sampled hashes compared with the reference path
+type HashCheck struct {
+	Checked    uint64 `json:"checked"`
+	Mismatches uint64 `json:"mismatches"`
+	// Mismatches as a fraction of checked hashes
+	MismatchRate float64   `json:"mismatch_rate"`
+	LastMismatch time.Time `json:"last_mismatch,omitempty"`
+	// Persistent mismatches within the window
+	Unstable bool `json:"unstable"`
+	// Hashing switched to the reference implementation
+	SafeMode bool `json:"safe_mode"`
+}
+
+// hashChecker collects verification results from every worker
+type hashChecker struct {
+	mu         sync.Mutex
+	checked    uint64
+	mismatches uint64
+	recent     []time.Time
+}
+
+var checker hashChecker
+
+// safeHashing routes all hashing through the reference implementation
+var safeHashing atomic.Bool
+
+// SetSafeHashing switches hashing to the slow reference implementation
+// and back
+func SetSafeHashing(enabled bool) {
+	if safeHashing.Swap(enabled) != enabled {
+		if enabled {
+			log.Printf("Hashing switched to the reference SHA-256 implementation")
+		} else {
+			log.Printf("Hashing switched to the %s backend", selectedBackend)
+		}
+	}
+}
+
+// GetHashCheck returns the hash verification results so far
+func GetHashCheck() HashCheck {
+	checker.mu.Lock()
+	defer checker.mu.Unlock()
+
+	result := HashCheck{
+		Checked:    checker.checked,
+		Mismatches: checker.mismatches,
+		SafeMode:   safeHashing.Load(),
+	}
+	if checker.checked > 0 {
+		result.MismatchRate = float64(checker.mismatches) / float64(checker.checked)
+	}
+
+	cutoff := time.Now().Add(-mismatchWindow)
+	inWindow := 0
+	for _, t := range checker.recent {
+		if t.After(cutoff) {
+			inWindow++
+		}
+	}
+	result.Unstable = inWindow >= unstableMismatches
+	if n := len(checker.recent); n > 0 {
+		result.LastMismatch = checker.recent[n-1]
+	}
+	return result
+}
+
+// nextVerify returns how many hashes to skip before the next check,
+// randomized so checks don't line up with a nonce pattern
+func nextVerify() int {
+	return 1 + rand.Intn(2*verifyInterval)
+}
+
+// verifyHash recomputes a header's hash on the reference path and records
+// whether the optimized result matched
+func verifyHash(header, hash []byte) {
+	reference := referenceDoubleSHA256(header)
+	mismatch := !bytes.Equal(hash, reference[:])
+
+	checker.mu.Lock()
+	defer checker.mu.Unlock()
+
+	checker.checked++
+	if !mismatch {
+		return
+	}
+
+	checker.mismatches++
+	now := time.Now()
+	checker.recent = append(checker.recent, now)
+
+	// Only the window matters for the alert, keep the last mismatch
+	cutoff := now.Add(-mismatchWindow)
+	for len(checker.recent) > 1 && !checker.recent[0].After(cutoff) {
+		checker.recent = checker.recent[1:]
+	}
+	log.Printf("Hash mismatch against the reference SHA-256 (%d of %d checked)", checker.mismatches, checker.checked)
+}
+
+// sha256K are the SHA-256 round constants
+var sha256K = [64]uint32{
+	0x428a2f98, 0x71374491, 0xb5c0fbcf, 0xe9b5dba5, 0x3956c25b, 0x59f111f1, 0x923f82a4, 0xab1c5ed5,
+	0xd807aa98, 0x12835b01, 0x243185be, 0x550c7dc3, 0x72be5d74, 0x80deb1fe, 0x9bdc06a7, 0xc19bf174,
+	0xe49b69c1, 0xefbe4786, 0x0fc19dc6, 0x240ca1cc, 0x2de92c6f, 0x4a7484aa, 0x5cb0a9dc, 0x76f988da,
+	0x983e5152, 0xa831c66d, 0xb00327c8, 0xbf597fc7, 0xc6e00bf3, 0xd5a79147, 0x06ca6351, 0x14292967,
+	0x27b70a85, 0x2e1b2138, 0x4d2c6dfc, 0x53380d13, 0x650a7354, 0x766a0abb, 0x81c2c92e, 0x92722c85,
+	0xa2bfe8a1, 0xa81a664b, 0xc24b8b70, 0xc76c51a3, 0xd192e819, 0xd6990624, 0xf40e3585, 0x106aa070,
+	0x19a4c116, 0x1e376c08, 0x2748774c, 0x34b0bcb5, 0x391c0cb3, 0x4ed8aa4a, 0x5b9cca4f, 0x682e6ff3,
+	0x748f82ee, 0x78a5636f, 0x84c87814, 0x8cc70208, 0x90befffa, 0xa4506ceb, 0xbef9a3f7, 0xc67178f2,
+}
+
+// sha256IV is the SHA-256 initial hash value
+var sha256IV = [8]uint32{
+	0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a,
+	0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19,
+}
+
+// referenceSHA256 is a plain FIPS 180-4 implementation without any
+// assembly, used to check and stand in for the optimized backend
+func referenceSHA256(data []byte) [32]byte {
+	h := sha256IV
+
+	// Pad to a multiple of 64 bytes with the bit length at the end
+	msg := make([]byte, len(data), len(data)+72)
+	copy(msg, data)
+	msg = append(msg, 0x80)
+	for len(msg)%64 != 56 {
+		msg = append(msg, 0)
+	}
+	msg = binary.BigEndian.AppendUint64(msg, uint64(len(data))*8)
+
+	for block := 0; block < len(msg); block += 64 {
+		sha256Block(&h, msg[block:block+64])
+	}
+
+	var digest [32]byte
+	for i, v := range h {
+		binary.BigEndian.PutUint32(digest[4*i:], v)
+	}
+	return digest
+}
+
+// sha256Block runs the SHA-256 compression function on one 64-byte block
+func sha256Block(h *[8]uint32, block []byte) {
+	var w [64]uint32
+	for i := 0; i < 16; i++ {
+		w[i] = binary.BigEndian.Uint32(block[4*i:])
+	}
+	for i := 16; i < 64; i++ {
+		s0 := bits.RotateLeft32(w[i-15], -7) ^ bits.RotateLeft32(w[i-15], -18) ^ w[i-15]>>3
+		s1 := bits.RotateLeft32(w[i-2], -17) ^ bits.RotateLeft32(w[i-2], -19) ^ w[i-2]>>10
+		w[i] = w[i-16] + s0 + w[i-7] + s1
+	}
+
+	a, b, c, d, e, f, g, hh := h[0], h[1], h[2], h[3], h[4], h[5], h[6], h[7]
+	for i := 0; i < 64; i++ {
+		s1 := bits.RotateLeft32(e, -6) ^ bits.RotateLeft32(e, -11) ^ bits.RotateLeft32(e, -25)
+		ch := e&f ^ ^e&g
+		t1 := hh + s1 + ch + sha256K[i] + w[i]
+		s0 := bits.RotateLeft32(a, -2) ^ bits.RotateLeft32(a, -13) ^ bits.RotateLeft32(a, -22)
+		maj := a&b ^ a&c ^ b&c
+		t2 := s0 + maj
+
+		hh, g, f, e, d, c, b, a = g, f, e, d+t1, c, b, a, t1+t2
+	}
+
+	h[0] += a
+	h[1] += b
+	h[2] += c
+	h[3] += d
+	h[4] += e
+	h[5] += f
+	h[6] += g
+	h[7] += hh
+}
+
+// referenceDoubleSHA256 computes SHA256(SHA256(data)) on the reference path
+func referenceDoubleSHA256(data []byte) [32]byte {
+	first := referenceSHA256(data)
+	return referenceSHA256(first[:])
+}
@@ -5,8 +5,8 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
+	"log"
 	"math/big"
-	"math/rand"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -14,10 +14,18 @@ import (
 	"github.com/soloforge/backend/internal/stratum"
 )
 
+// sparklineInterval and sparklineSize bound the per-worker hashrate history
+const (
+	sparklineInterval = 10 * time.Second
+	sparklineSize     = 60
+)
+
 // Worker represents a single mining worker
 type Worker struct {
 	ID   int    `json:"id"`
 	Name string `json:"name"`
+	// Device the worker mines on, see Devices
+	Device string `json:"device"`
 
 	mu sync.RWMutex
 
@@ -25,55 +33,157 @@ type Worker struct {
 	running   bool
 	hashCount uint64
 	startTime time.Time
+	starts    int
+
+	// Hashrate history for sparklines
+	sparkline       []float64
+	lastSampleCount uint64
+	lastSampleAt    time.Time
+
+	// Hash counts for windowed hashrates
+	window hashWindow
+
+	// Time spent mining in runs before the current one
+	minedFor time.Duration
 
 	// Current job
-	job          *stratum.Job
-	extranonce1  string
-	extranonce2  string
+	job         *stratum.Job
+	extranonce1 string
+	extranonce2 string
+	epoch       uint64
+
+	// Passes made on the current extranonce1, numbering extranonce2
+	extranonce2Counter uint64
+
+	// Header version bits allowed to roll (BIP 310), zero disables rolling
+	versionMask uint32
+
+	// Pool share difficulty, 0 to report blocks only
+	shareDifficulty float64
+
+	// Roll ntime with the local clock plus ntimeOffset, instead of using
+	// the job's ntime as sent
+	rollNTime   bool
+	ntimeOffset time.Duration
+
+	// Throttling, the sleep for cpuPercent scaled by the manager's
+	// measured CPU usage controller
+	cpuPercent    int
+	throttleScale float64
+
+	// Stopped by the user, StartAll leaves the worker stopped until Resume
+	paused bool
+
+	// Shares found and the pool's verdicts on them
+	shares WorkerShares
+
+	// CPU the mining goroutine's thread is pinned to, noCPU for none
+	cpu int
+
+	// Scheduling priority the mining goroutine's thread takes
+	priority string
+
+	// Job adopted but not hashed yet, only touched by the mining goroutine
+	latencyJob *stratum.Job
+
+	// Part of the nonce space this worker searches, disjoint from the
+	// other workers'
+	nonceRange NonceRange
+
+	// Next nonce and the version bits of the current pass over nonceRange,
+	// only touched by the mining goroutine
+	nonceCursor  uint64
+	nonceVersion uint32
+
+	// Header of the current pass, only touched by the mining goroutine
+	template *headerTemplate
+
+	// Nonce coverage of the current and previous job, and the job the
+	// current one is for
+	coverage     NonceCoverage
+	lastCoverage NonceCoverage
+	coverageJob  *stratum.Job
+
+	// Last job reported malformed, so each is reported once; only touched
+	// by the mining goroutine
+	malformedJob *stratum.Job
 
-	// Throttling
-	cpuPercent int
+	// Nonces per batch, tuned to targetBatchTime by the mining goroutine
+	batchSize int
+
+	// Bumped by every clean_jobs job, so the batch in flight stops
+	cleanGen atomic.Uint64
 
 	// Channels
 	shutdown   chan struct{}
 	jobChannel chan *stratum.Job
 
+	// Closed when the latest mining goroutine exits
+	loopDone chan struct{}
+
+	// Submits found blocks and hands found nonces to the manager's
+	// submitter, set before the worker starts
+	submit func(foundShare)
+
 	// Callbacks
-	onShareFound func(workerID int, jobID, extranonce2, ntime, nonce string, difficulty float64)
+	onJobStarted func(latency time.Duration)
+	onError      func(WorkerError)
 }
 
 // NewWorker creates a new mining worker
 func NewWorker(id int, name string, cpuPercent int) *Worker {
 	return &Worker{
-		ID:         id,
-		Name:       name,
-		cpuPercent: cpuPercent,
-		shutdown:   make(chan struct{}),
-		jobChannel: make(chan *stratum.Job, 10),
+		ID:            id,
+		Name:          name,
+		Device:        DeviceCPU,
+		cpuPercent:    cpuPercent,
+		throttleScale: 1,
+		cpu:           noCPU,
+		nonceRange:    fullNonceRange,
+		shutdown:      make(chan struct{}),
+		jobChannel:    make(chan *stratum.Job, 10),
 	}
 }
 
-// SetShareCallback sets the callback for found shares
-func (w *Worker) SetShareCallback(cb func(workerID int, jobID, extranonce2, ntime, nonce string, difficulty float64)) {
+// SetJobLatencyCallback sets the callback for the time from a job's
+// mining.notify to the worker's first hash on it
+func (w *Worker) SetJobLatencyCallback(cb func(latency time.Duration)) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	w.onShareFound = cb
+	w.onJobStarted = cb
 }
 
-// Start begins mining
-func (w *Worker) Start(extranonce1 string, extranonce2Size int) {
+// Start begins mining for the session identified by epoch
+func (w *Worker) Start(extranonce1 string, extranonce2Size int, epoch uint64) {
 	w.mu.Lock()
 	if w.running {
 		w.mu.Unlock()
 		return
 	}
 	w.running = true
+	w.starts++
 	w.startTime = time.Now()
 	w.extranonce1 = extranonce1
-	w.extranonce2 = generateExtranonce2(extranonce2Size)
+	w.extranonce2Counter = 0
+	w.extranonce2 = w.nextExtranonce2Locked(extranonce2Size)
+	w.epoch = epoch
+	// Fresh channel so a stopped worker can be started again
+	w.shutdown = make(chan struct{})
+	shutdown := w.shutdown
+	prevDone := w.loopDone
+	done := make(chan struct{})
+	w.loopDone = done
 	w.mu.Unlock()
 
-	go w.mineLoop()
+	// A quick restart must not overlap the previous loop, which only sees
+	// the shutdown between batches
+	go func() {
+		defer close(done)
+		if prevDone != nil {
+			<-prevDone
+		}
+		w.mineLoop(shutdown)
+	}()
 }
 
 // Stop halts mining
@@ -84,9 +194,46 @@ func (w *Worker) Stop() {
 		return
 	}
 	w.running = false
+	w.minedFor += time.Since(w.startTime)
+	shutdown := w.shutdown
+	w.mu.Unlock()
+
+	close(shutdown)
+}
+
+// Pause stops mining and keeps the worker stopped through StartAll, with
+// its name, counters and nonce range intact, until Resume
+func (w *Worker) Pause() {
+	w.mu.Lock()
+	w.paused = true
 	w.mu.Unlock()
 
-	close(w.shutdown)
+	w.Stop()
+}
+
+// Resume clears a pause, the worker mines again from its next Start
+func (w *Worker) Resume() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.paused = false
+}
+
+// IsPaused returns whether the worker was paused
+func (w *Worker) IsPaused() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.paused
+}
+
+// Wait blocks until the latest mining goroutine has exited, after Stop
+func (w *Worker) Wait() {
+	w.mu.RLock()
+	done := w.loopDone
+	w.mu.RUnlock()
+
+	if done != nil {
+		<-done
+	}
 }
 
 // IsRunning returns whether the worker is running
@@ -96,28 +243,95 @@ func (w *Worker) IsRunning() bool {
 	return w.running
 }
 
-// GetHashrate returns the current hashrate in H/s
+// GetHashrate returns the average hashrate over the time spent mining in
+// H/s, see GetHashrates for recent rates
 func (w *Worker) GetHashrate() float64 {
+	count := atomic.LoadUint64(&w.hashCount)
+
 	w.mu.RLock()
-	startTime := w.startTime
-	w.mu.RUnlock()
+	defer w.mu.RUnlock()
+	return w.lifetimeHashrateLocked(time.Now(), count)
+}
+
+// GetHashCount returns the total number of hashes computed
+func (w *Worker) GetHashCount() uint64 {
+	return atomic.LoadUint64(&w.hashCount)
+}
 
-	elapsed := time.Since(startTime).Seconds()
-	if elapsed == 0 {
+// GetRestarts returns how many times the worker was started after the first
+func (w *Worker) GetRestarts() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.starts == 0 {
 		return 0
 	}
+	return w.starts - 1
+}
 
+// GetCurrentJobID returns the ID of the job being mined
+func (w *Worker) GetCurrentJobID() string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.job == nil {
+		return ""
+	}
+	return w.job.ID
+}
+
+// GetCPUPercent returns the effective CPU throttling percentage
+func (w *Worker) GetCPUPercent() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.cpuPercent
+}
+
+// SampleHashrate records the hashrate since the previous sample, at most
+// once per sparklineInterval, and the hash count for GetHashrates
+func (w *Worker) SampleHashrate(now time.Time) {
 	count := atomic.LoadUint64(&w.hashCount)
-	return float64(count) / elapsed
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.window.add(now, count)
+	if w.lastSampleAt.IsZero() {
+		w.lastSampleCount, w.lastSampleAt = count, now
+		return
+	}
+
+	elapsed := now.Sub(w.lastSampleAt)
+	if elapsed < sparklineInterval {
+		return
+	}
+
+	var rate float64
+	if count > w.lastSampleCount {
+		rate = float64(count-w.lastSampleCount) / elapsed.Seconds()
+	}
+
+	w.sparkline = append(w.sparkline, rate)
+	if len(w.sparkline) > sparklineSize {
+		w.sparkline = w.sparkline[1:]
+	}
+	w.lastSampleCount, w.lastSampleAt = count, now
 }
 
-// GetHashCount returns the total number of hashes computed
-func (w *Worker) GetHashCount() uint64 {
-	return atomic.LoadUint64(&w.hashCount)
+// GetSparkline returns recent hashrate samples, oldest first
+func (w *Worker) GetSparkline() []float64 {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	result := make([]float64, len(w.sparkline))
+	copy(result, w.sparkline)
+	return result
 }
 
-// UpdateJob sends a new job to the worker
+// UpdateJob sends a new job to the worker. A clean_jobs job also aborts
+// the batch in flight and any share it finds.
 func (w *Worker) UpdateJob(job *stratum.Job) {
+	if job.CleanJobs {
+		w.cleanGen.Add(1)
+	}
 	select {
 	case w.jobChannel <- job:
 	default:
@@ -130,6 +344,31 @@ func (w *Worker) UpdateJob(job *stratum.Job) {
 	}
 }
 
+// SetExtranonce replaces the extranonce1 and restarts extranonce2
+func (w *Worker) SetExtranonce(extranonce1 string, extranonce2Size int, epoch uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.extranonce1 = extranonce1
+	w.extranonce2Counter = 0
+	w.extranonce2 = w.nextExtranonce2Locked(extranonce2Size)
+	w.epoch = epoch
+}
+
+// SetVersionMask sets the header version bits the worker may roll
+func (w *Worker) SetVersionMask(mask uint32) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.versionMask = mask
+}
+
+// SetShareDifficulty sets the pool share difficulty, so the worker
+// reports shares meeting it as well as blocks; 0 reports blocks only
+func (w *Worker) SetShareDifficulty(difficulty float64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.shareDifficulty = difficulty
+}
+
 // SetCPUPercent updates the CPU throttling percentage
 func (w *Worker) SetCPUPercent(percent int) {
 	w.mu.Lock()
@@ -137,126 +376,248 @@ func (w *Worker) SetCPUPercent(percent int) {
 	w.cpuPercent = percent
 }
 
+// SetThrottleScale sets the factor on the worker's throttle sleep
+func (w *Worker) SetThrottleScale(scale float64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.throttleScale = scale
+}
+
 // mineLoop is the main mining goroutine
-func (w *Worker) mineLoop() {
+func (w *Worker) mineLoop(shutdown chan struct{}) {
+	scanner, err := openScanner(w.Device)
+	if err != nil {
+		log.Printf("Worker %d: %v", w.ID, err)
+		// Only this run failed, a Start since then owns running
+		w.mu.Lock()
+		if w.shutdown == shutdown {
+			w.running = false
+			w.minedFor += time.Since(w.startTime)
+		}
+		w.mu.Unlock()
+		return
+	}
+	defer scanner.Close()
+
+	w.mu.RLock()
+	priority := w.priority
+	w.mu.RUnlock()
+	w.lowerPriority(priority)
+
+	// Each mining goroutine starts on a fresh, unpinned thread
+	pinned := noCPU
 	for {
 		select {
-		case <-w.shutdown:
+		case <-shutdown:
 			return
 		case job := <-w.jobChannel:
 			w.mu.Lock()
 			w.job = job
-			w.extranonce2 = generateExtranonce2(len(w.extranonce2) / 2)
 			w.mu.Unlock()
+			w.latencyJob = job
+			// The new pass brings a fresh extranonce2 and header
+			w.nonceCursor = nonceRestart
 		default:
 			w.mu.RLock()
 			job := w.job
 			extranonce1 := w.extranonce1
 			extranonce2 := w.extranonce2
+			epoch := w.epoch
+			versionMask := w.versionMask
+			shareDifficulty := w.shareDifficulty
+			rollNTime := w.rollNTime
+			ntimeOffset := w.ntimeOffset
 			cpuPercent := w.cpuPercent
+			throttleScale := w.throttleScale
+			nonceRange := w.nonceRange
+			cpu := w.cpu
 			w.mu.RUnlock()
 
+			if cpu != pinned {
+				w.pinCPU(cpu)
+				pinned = cpu
+			}
+
 			if job == nil {
-				time.Sleep(100 * time.Millisecond)
+				if !sleepUntilShutdown(shutdown, 100*time.Millisecond) {
+					return
+				}
+				continue
+			}
+
+			// Each pass over the nonce range searches the next version
+			// bits the pool allows rolling, then a fresh extranonce2 once
+			// they wrap, so no header is hashed twice. A pass cut short
+			// by a new job or range starts over with a fresh extranonce2.
+			if w.batchSize == 0 {
+				w.batchSize = minBatchSize
+			}
+			exhausted := w.nonceCursor == uint64(nonceRange.End)+1
+			startNonce, batchSize, newPass := w.nextNonceBatch(nonceRange, w.batchSize)
+			versionRolled := false
+			if newPass {
+				w.nonceVersion = nextVersionBits(w.nonceVersion, versionMask)
+				versionRolled = exhausted && w.nonceVersion != 0
+				if !versionRolled {
+					w.nonceVersion = 0
+					w.mu.Lock()
+					w.extranonce2 = w.nextExtranonce2Locked(len(w.extranonce2) / 2)
+					extranonce2 = w.extranonce2
+					w.mu.Unlock()
+				}
+			}
+			versionBits := w.nonceVersion & versionMask
+			if newPass {
+				w.startCoveragePass(job, nonceRange, exhausted, versionRolled)
+			}
+
+			ntime := job.NTime
+			if rollNTime {
+				ntime = correctedNTime(job.NTime, time.Now().Add(ntimeOffset))
+			}
+
+			if !w.template.matches(job, extranonce1, extranonce2, versionMask, versionBits) {
+				var err error
+				w.template, err = newHeaderTemplate(job, extranonce1, extranonce2, versionMask, versionBits)
+				if err != nil && w.malformedJob != job {
+					w.malformedJob = job
+					w.reportError(WorkerErrorMalformedJob, job.ID, fmt.Errorf("job %s: %w", job.ID, err))
+				}
+			}
+			if w.template == nil {
+				// Malformed job, wait for the next one
+				if !sleepUntilShutdown(shutdown, 100*time.Millisecond) {
+					return
+				}
 				continue
 			}
 
-			// Mine a batch of nonces
-			found, nonce, difficulty := w.mineBatch(job, extranonce1, extranonce2, 1000)
-			if found {
-				if w.onShareFound != nil {
-					w.onShareFound(w.ID, job.ID, extranonce2, job.NTime, nonce, difficulty)
+			// Mine a batch of nonces, abandoned as soon as a clean job
+			// arrives
+			gen := w.cleanGen.Load()
+			abort := func() bool { return w.cleanGen.Load() != gen }
+			batchStart := time.Now()
+			w.template.setShareDifficulty(shareDifficulty)
+			found, nonce, err := w.mineBatch(scanner, w.template, ntime, startNonce, batchSize, abort)
+			elapsed := time.Since(batchStart)
+			aborted := abort()
+			if err != nil {
+				w.reportError(WorkerErrorDevice, job.ID, err)
+				if !sleepUntilShutdown(shutdown, time.Second) {
+					return
 				}
+				continue
+			}
+
+			// A batch cut short by a solution, the end of the range or a
+			// clean job says nothing about the rate
+			if !found && !aborted && batchSize == w.batchSize {
+				w.batchSize = tuneBatchSize(batchSize, elapsed)
+			}
+			// A share on work the pool discarded would only be rejected.
+			// A block is submitted here and now; checking and submitting
+			// a share is the submitter's job, hashing goes on.
+			if found && !aborted {
+				share := foundShare{
+					worker:        w,
+					epoch:         epoch,
+					jobID:         job.ID,
+					extranonce2:   extranonce2,
+					ntime:         ntime,
+					nonce:         fmt.Sprintf("%08x", nonce),
+					target:        w.template.target,
+					networkTarget: w.template.networkTarget,
+				}
+				if versionMask != 0 {
+					share.versionBits = fmt.Sprintf("%08x", versionBits)
+				}
+				copy(share.header[:], w.template.header)
+				w.submit(share)
 			}
 
-			// CPU throttling
-			if cpuPercent < 100 {
-				sleepTime := time.Duration((100-cpuPercent)*10) * time.Microsecond
-				time.Sleep(sleepTime)
+			// CPU throttling, idle in proportion to the time spent hashing
+			// as corrected by the measured CPU usage
+			delay := time.Duration(float64(throttleDelay(elapsed, cpuPercent)) * throttleScale)
+			if delay > 0 {
+				if !sleepUntilShutdown(shutdown, delay) {
+					return
+				}
 			}
 		}
 	}
 }
 
-// mineBatch attempts to mine a batch of nonces
-func (w *Worker) mineBatch(job *stratum.Job, extranonce1, extranonce2 string, batchSize int) (bool, string, float64) {
-	// Calculate target from nBits
-	target := calculateTarget(job.NBits)
+// sleepUntilShutdown sleeps for d and returns true, or returns false as
+// soon as the run is shut down
+func sleepUntilShutdown(shutdown chan struct{}, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
 
-	// Build coinbase
-	coinbase := job.Coinbase1 + extranonce1 + extranonce2 + job.Coinbase2
-	coinbaseBytes, _ := hex.DecodeString(coinbase)
+	select {
+	case <-shutdown:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
 
-	// Double SHA256 of coinbase
-	coinbaseHash := doubleSHA256(coinbaseBytes)
+// mineBatch scans batchSize consecutive nonces from startNonce on a
+// prepared header at the given ntime, until abort returns true, returning
+// the first nonce the scanner says meets the target, written into the
+// header for the submitter to check.
+func (w *Worker) mineBatch(scanner Scanner, t *headerTemplate, ntimeHex string, startNonce uint32, batchSize int, abort func() bool) (bool, uint32, error) {
+	t.setNTime(ntimeHex)
+	if w.latencyJob == t.job {
+		w.latencyJob = nil
+		w.reportJobLatency(t.job)
+	}
 
-	// Calculate Merkle root
-	merkleRoot := coinbaseHash
-	for _, branch := range job.MerkleBranch {
-		branchBytes, _ := hex.DecodeString(branch)
-		merkleRoot = doubleSHA256(append(merkleRoot, branchBytes...))
+	scanned, found, err := scanner.Scan(t.header, &t.target, startNonce, batchSize, abort)
+	if err != nil {
+		return false, 0, err
 	}
 
-	// Reverse merkle root for block header (little endian)
-	merkleRootHex := reverseBytes(merkleRoot)
-
-	// Parse version, prevhash, ntime, nbits
-	version, _ := hex.DecodeString(job.Version)
-	prevHash, _ := hex.DecodeString(job.PrevHash)
-	ntime, _ := hex.DecodeString(job.NTime)
-	nbits, _ := hex.DecodeString(job.NBits)
-
-	// Build block header (without nonce and padding)
-	header := make([]byte, 80)
-	copy(header[0:4], version)
-	copy(header[4:36], prevHash)
-	copy(header[36:68], merkleRootHex)
-	copy(header[68:72], ntime)
-	copy(header[72:76], nbits)
-
-	var bestDifficulty float64
-	var bestNonce string
-
-	difficulty1Target := new(big.Int)
-	difficulty1Target.SetString("00000000FFFF0000000000000000000000000000000000000000000000000000", 16)
-
-	for i := 0; i < batchSize; i++ {
-		// Generate random nonce
-		nonce := rand.Uint32()
-		binary.LittleEndian.PutUint32(header[76:80], nonce)
-
-		// Double SHA256
-		hash := doubleSHA256(header)
-		atomic.AddUint64(&w.hashCount, 1)
-
-		// Convert hash to big.Int (reverse for comparison)
-		hashInt := new(big.Int).SetBytes(reverseBytes(hash))
-
-		// Calculate difficulty
-		if hashInt.Sign() > 0 {
-			diff := new(big.Int).Div(difficulty1Target, hashInt)
-			diffFloat := float64(diff.Int64())
-			if diffFloat > bestDifficulty {
-				bestDifficulty = diffFloat
-				bestNonce = fmt.Sprintf("%08x", nonce)
-			}
-		}
+	atomic.AddUint64(&w.hashCount, uint64(scanned))
+	w.recordCoverage(scanned)
+	if !found {
+		return false, 0, nil
+	}
+	nonce := startNonce + uint32(scanned-1)
+	binary.LittleEndian.PutUint32(t.header[76:80], nonce)
+	return true, nonce, nil
+}
 
-		// Check if hash meets target
-		if hashInt.Cmp(target) <= 0 {
-			nonceHex := fmt.Sprintf("%08x", nonce)
-			return true, nonceHex, bestDifficulty
-		}
+// reportJobLatency reports how long a job took from notify to first hash
+func (w *Worker) reportJobLatency(job *stratum.Job) {
+	if job.ReceivedAt.IsZero() {
+		return
 	}
 
-	return false, bestNonce, bestDifficulty
+	w.mu.RLock()
+	cb := w.onJobStarted
+	w.mu.RUnlock()
+
+	if cb != nil {
+		cb(time.Since(job.ReceivedAt))
+	}
 }
 
-// doubleSHA256 computes SHA256(SHA256(data))
+// doubleSHA256 computes SHA256(SHA256(data)), on the reference path
+// when safe hashing is on
 func doubleSHA256(data []byte) []byte {
+	hash := doubleSHA256Sum(data)
+	return hash[:]
+}
+
+// doubleSHA256Sum is doubleSHA256 returning an array, which stays on the
+// stack in the hashing loop
+func doubleSHA256Sum(data []byte) [32]byte {
+	if safeHashing.Load() {
+		return referenceDoubleSHA256(data)
+	}
+
 	first := sha256.Sum256(data)
-	second := sha256.Sum256(first[:])
-	return second[:]
+	return sha256.Sum256(first[:])
 }
 
 // reverseBytes reverses a byte slice
@@ -276,16 +637,12 @@ func calculateTarget(nbits string) *big.Int {
 	}
 
 	exp := int(nbitsBytes[0])
+	if exp < 3 {
+		return new(big.Int)
+	}
 	coeff := new(big.Int).SetBytes(nbitsBytes[1:4])
 
 	// target = coeff * 2^(8*(exp-3))
 	target := new(big.Int).Lsh(coeff, uint(8*(exp-3)))
 	return target
 }
-
-// generateExtranonce2 generates a random extranonce2
-func generateExtranonce2(size int) string {
-	bytes := make([]byte, size)
-	rand.Read(bytes)
-	return hex.EncodeToString(bytes)
-}
@@ -0,0 +1,60 @@
+package miner
+
+import (
+	"log"
+	"time"
+)
+
+// Kinds of WorkerError
+const (
+	// The job can't be laid out into a header, so the worker idles until
+	// the next one
+	WorkerErrorMalformedJob = "malformed_job"
+	// The device failed a batch, the worker retries after a second
+	WorkerErrorDevice = "device"
+)
+
+// WorkerError is a failure that stopped a worker from hashing
+type WorkerError struct {
+	WorkerID int       `json:"worker_id"`
+	Device   string    `json:"device"`
+	JobID    string    `json:"job_id,omitempty"`
+	Kind     string    `json:"kind"`
+	Error    string    `json:"error"`
+	Time     time.Time `json:"time"`
+}
+
+// SetErrorCallback sets the callback for errors that stop the worker
+// from hashing
+func (w *Worker) SetErrorCallback(cb func(WorkerError)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onError = cb
+}
+
+// reportError logs an error and passes it to the error callback
+func (w *Worker) reportError(kind, jobID string, err error) {
+	log.Printf("Worker %d: %s: %v", w.ID, w.Device, err)
+
+	w.mu.RLock()
+	cb := w.onError
+	w.mu.RUnlock()
+
+	if cb != nil {
+		cb(WorkerError{
+			WorkerID: w.ID,
+			Device:   w.Device,
+			JobID:    jobID,
+			Kind:     kind,
+			Error:    err.Error(),
+			Time:     time.Now(),
+		})
+	}
+}
+
+// SetErrorCallback sets the callback workers report errors to
+func (m *Manager) SetErrorCallback(cb func(WorkerError)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onError = cb
+}
@@ -0,0 +1,29 @@
+package power
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// loadAvgPath is where Linux exposes the load averages
+const loadAvgPath = "/proc/loadavg"
+
+// ReadLoadAverage returns the host's 1-minute load average: runnable and
+// uninterruptible threads, averaged, whatever process they belong to.
+// It is only available on Linux.
+func ReadLoadAverage() (float64, bool) {
+	data, err := os.ReadFile(loadAvgPath)
+	if err != nil {
+		return 0, false
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, false
+	}
+	load, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, false
+	}
+	return load, true
+}
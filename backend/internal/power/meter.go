@@ -0,0 +1,155 @@
+package power
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Power sources
+const (
+	SourceNone       = "none"
+	SourceConfigured = "configured"
+	SourceRAPL       = "rapl"
+)
+
+// raplRoot is where Linux exposes Intel/AMD RAPL energy counters
+const raplRoot = "/sys/class/powercap"
+
+// Efficiency describes mining efficiency at the current power draw
+type Efficiency struct {
+	Watts          float64 `json:"watts"`
+	Source         string  `json:"source"`
+	Hashrate       float64 `json:"hashrate"`
+	HashesPerJoule float64 `json:"hashes_per_joule"`
+	JoulesPerTH    float64 `json:"joules_per_th"`
+}
+
+// Meter measures package power from RAPL counters when available
+type Meter struct {
+	mu sync.Mutex
+
+	// RAPL package domains, e.g. intel-rapl:0
+	domains []string
+
+	lastEnergy map[string]uint64
+	lastRead   time.Time
+	watts      float64
+}
+
+// NewMeter creates a power meter, detecting RAPL domains on Linux
+func NewMeter() *Meter {
+	return &Meter{
+		domains:    detectRAPLDomains(),
+		lastEnergy: make(map[string]uint64),
+	}
+}
+
+// HasRAPL reports whether hardware energy counters are readable
+func (m *Meter) HasRAPL() bool {
+	return len(m.domains) > 0
+}
+
+// ReadWatts returns the average package power since the previous call.
+// Calls closer than a second apart return the cached value.
+func (m *Meter) ReadWatts() (float64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.domains) == 0 {
+		return 0, false
+	}
+
+	now := time.Now()
+	if !m.lastRead.IsZero() && now.Sub(m.lastRead) < time.Second {
+		return m.watts, m.watts > 0
+	}
+
+	var joules float64
+	for _, domain := range m.domains {
+		energy, err := readUint(filepath.Join(domain, "energy_uj"))
+		if err != nil {
+			continue
+		}
+
+		if last, ok := m.lastEnergy[domain]; ok {
+			delta := energy - last
+			if energy < last {
+				// Counter wrapped around
+				maxRange, err := readUint(filepath.Join(domain, "max_energy_range_uj"))
+				if err != nil {
+					continue
+				}
+				delta = maxRange - last + energy
+			}
+			joules += float64(delta) / 1e6
+		}
+		m.lastEnergy[domain] = energy
+	}
+
+	if !m.lastRead.IsZero() {
+		m.watts = joules / now.Sub(m.lastRead).Seconds()
+	}
+	m.lastRead = now
+
+	return m.watts, m.watts > 0
+}
+
+// Estimate computes efficiency, preferring the configured wall draw over
+// RAPL since the latter only covers the CPU package
+func (m *Meter) Estimate(configuredWatts, hashrate float64) Efficiency {
+	eff := Efficiency{
+		Source:   SourceNone,
+		Hashrate: hashrate,
+	}
+
+	if configuredWatts > 0 {
+		eff.Watts = configuredWatts
+		eff.Source = SourceConfigured
+	} else if watts, ok := m.ReadWatts(); ok {
+		eff.Watts = watts
+		eff.Source = SourceRAPL
+	}
+
+	if eff.Watts > 0 && hashrate > 0 {
+		eff.HashesPerJoule = hashrate / eff.Watts
+		eff.JoulesPerTH = eff.Watts / (hashrate / 1e12)
+	}
+
+	return eff
+}
+
+// detectRAPLDomains lists readable top-level RAPL package domains
+func detectRAPLDomains() []string {
+	entries, err := os.ReadDir(raplRoot)
+	if err != nil {
+		return nil
+	}
+
+	var domains []string
+	for _, entry := range entries {
+		name := entry.Name()
+		// Top-level packages only, subdomains (core, dram) are included in them
+		if !strings.HasPrefix(name, "intel-rapl:") || strings.Count(name, ":") != 1 {
+			continue
+		}
+
+		domain := filepath.Join(raplRoot, name)
+		if _, err := readUint(filepath.Join(domain, "energy_uj")); err == nil {
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
+
+// readUint reads a sysfs file containing a single unsigned integer
+func readUint(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
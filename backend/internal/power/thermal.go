@@ -0,0 +1,116 @@
+package power
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Where Linux exposes temperature sensors
+const (
+	hwmonRoot   = "/sys/class/hwmon"
+	thermalRoot = "/sys/class/thermal"
+)
+
+// cpuHwmonDrivers are hwmon drivers reporting CPU package or core
+// temperatures
+var cpuHwmonDrivers = map[string]bool{
+	"coretemp":    true,
+	"k10temp":     true,
+	"zenpower":    true,
+	"cpu_thermal": true,
+	"soc_thermal": true,
+}
+
+// cpuThermalZones are thermal zone types covering the CPU, used when no
+// hwmon driver is found
+var cpuThermalZones = map[string]bool{
+	"x86_pkg_temp": true,
+	"cpu-thermal":  true,
+	"cpu_thermal":  true,
+	"soc_thermal":  true,
+}
+
+// Thermometer reads the CPU temperature from hwmon or thermal zones
+type Thermometer struct {
+	// Sensor files reporting millidegrees Celsius
+	sensors []string
+}
+
+// NewThermometer creates a thermometer, detecting CPU sensors on Linux
+func NewThermometer() *Thermometer {
+	sensors := detectHwmonSensors()
+	if len(sensors) == 0 {
+		sensors = detectThermalZones()
+	}
+	return &Thermometer{sensors: sensors}
+}
+
+// Available reports whether a CPU temperature sensor is readable
+func (t *Thermometer) Available() bool {
+	return len(t.sensors) > 0
+}
+
+// Sensors returns the sensor files the thermometer reads
+func (t *Thermometer) Sensors() []string {
+	return append([]string{}, t.sensors...)
+}
+
+// ReadCelsius returns the hottest CPU sensor reading
+func (t *Thermometer) ReadCelsius() (float64, bool) {
+	hottest, ok := 0.0, false
+	for _, sensor := range t.sensors {
+		milli, err := readUint(sensor)
+		if err != nil {
+			continue
+		}
+		if celsius := float64(milli) / 1000; !ok || celsius > hottest {
+			hottest, ok = celsius, true
+		}
+	}
+	return hottest, ok
+}
+
+// detectHwmonSensors lists readable temp*_input files of CPU hwmon drivers
+func detectHwmonSensors() []string {
+	entries, err := os.ReadDir(hwmonRoot)
+	if err != nil {
+		return nil
+	}
+
+	var sensors []string
+	for _, entry := range entries {
+		dir := filepath.Join(hwmonRoot, entry.Name())
+		name, err := os.ReadFile(filepath.Join(dir, "name"))
+		if err != nil || !cpuHwmonDrivers[strings.TrimSpace(string(name))] {
+			continue
+		}
+
+		inputs, _ := filepath.Glob(filepath.Join(dir, "temp*_input"))
+		for _, input := range inputs {
+			if _, err := readUint(input); err == nil {
+				sensors = append(sensors, input)
+			}
+		}
+	}
+	return sensors
+}
+
+// detectThermalZones lists readable CPU thermal zone temperatures
+func detectThermalZones() []string {
+	zones, _ := filepath.Glob(filepath.Join(thermalRoot, "thermal_zone*"))
+
+	var sensors []string
+	for _, zone := range zones {
+		kind, err := os.ReadFile(filepath.Join(zone, "type"))
+		if err != nil || !cpuThermalZones[strings.TrimSpace(string(kind))] {
+			continue
+		}
+
+		input := filepath.Join(zone, "temp")
+		if _, err := readUint(input); err == nil {
+			sensors = append(sensors, input)
+		}
+	}
+	return sensors
+}
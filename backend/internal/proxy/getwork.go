@@ -0,0 +1,335 @@
+package proxy
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/soloforge/backend/internal/miner"
+)
+
+// getworkPrefix starts the extranonce2 of all getwork units, after the
+// Stratum miners' prefixes 01 to maxMiners
+const getworkPrefix = "ff"
+
+// Getwork units handed out are kept for checking solutions, at most
+// maxGetworkUnits of them; a long poll returns new work after at most
+// getworkLongPollTimeout even without a new job
+const (
+	maxGetworkUnits        = 4096
+	getworkLongPollTimeout = 60 * time.Second
+)
+
+// getworkHash1 is the pre-padded second hash block legacy miners expect
+const getworkHash1 = "00000000000000000000000000000000000000000000000000000000000000000000008000000000000000000000000000000000000000000000000000010000"
+
+// GetworkStatus counts the work handed to getwork miners and their
+// solutions
+type GetworkStatus struct {
+	Units        int       `json:"units"`
+	Accepted     int       `json:"accepted"`
+	Rejected     int       `json:"rejected"`
+	LongPolls    int       `json:"long_polls"`
+	LastSubmitAt time.Time `json:"last_submit_at,omitempty"`
+}
+
+// getworkUnit is the Stratum work behind one getwork response
+type getworkUnit struct {
+	jobID       string
+	extranonce1 string
+	extranonce2 string
+	epoch       uint64
+	difficulty  float64
+	// Solutions submitted or being submitted, by ntime and nonce
+	submitted map[string]bool
+}
+
+// getwork hands out classic getwork units cut from the upstream job, each
+// with its own extranonce2, and checks the solutions sent back
+type getwork struct {
+	mu sync.Mutex
+
+	// Units by merkle root, oldest first in order
+	units   map[string]*getworkUnit
+	order   []string
+	counter uint64
+
+	// Upstream job and extranonce1 units are being cut from, and how many
+	// were handed out for it
+	job      string
+	jobUnits uint64
+
+	// Closed and replaced when a job arrives, waking long polls
+	newJob chan struct{}
+
+	status GetworkStatus
+}
+
+// newGetwork creates an empty getwork bridge
+func newGetwork() *getwork {
+	return &getwork{
+		units:  make(map[string]*getworkUnit),
+		newJob: make(chan struct{}),
+	}
+}
+
+// jobArrived wakes the long polls waiting for new work
+func (g *getwork) jobArrived() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	close(g.newJob)
+	g.newJob = make(chan struct{})
+}
+
+// GetworkStatus returns the getwork bridge counters
+func (s *Server) GetworkStatus() GetworkStatus {
+	s.getwork.mu.Lock()
+	defer s.getwork.mu.Unlock()
+	return s.getwork.status
+}
+
+// GetworkHandler serves the getwork JSON-RPC method, answering with a
+// work unit, or checking and submitting a solution when given one. Work
+// requested on longPollPath is held until the next job, as the
+// X-Long-Polling header returned with every unit advertises. The worker
+// name is the HTTP basic auth user.
+func (s *Server) GetworkHandler(longPollPath string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params []string        `json:"params"`
+		}
+		if r.Method == http.MethodPost {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				getworkReply(w, nil, nil, fmt.Errorf("invalid JSON-RPC request"))
+				return
+			}
+		}
+		if req.Method != "" && req.Method != "getwork" {
+			getworkReply(w, req.ID, nil, fmt.Errorf("method %q not found", req.Method))
+			return
+		}
+
+		if len(req.Params) > 0 {
+			worker, _, _ := r.BasicAuth()
+			if worker == "" {
+				worker = "getwork"
+			}
+			err := s.submitGetwork(worker, r.RemoteAddr, req.Params[0])
+			getworkReply(w, req.ID, err == nil, nil)
+			return
+		}
+
+		if r.URL.Path == longPollPath {
+			s.waitGetworkJob(r)
+		}
+		work, err := s.nextGetwork()
+		if err == nil {
+			w.Header().Set("X-Long-Polling", longPollPath)
+		}
+		getworkReply(w, req.ID, work, err)
+	})
+}
+
+// getworkReply writes a JSON-RPC response
+func getworkReply(w http.ResponseWriter, id json.RawMessage, result interface{}, err error) {
+	resp := map[string]interface{}{"id": id, "result": result, "error": nil}
+	if err != nil {
+		resp["result"] = nil
+		resp["error"] = map[string]interface{}{"code": -1, "message": err.Error()}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// waitGetworkJob blocks a long poll until the next job, the timeout or
+// the client leaving
+func (s *Server) waitGetworkJob(r *http.Request) {
+	g := s.getwork
+	g.mu.Lock()
+	newJob := g.newJob
+	g.status.LongPolls++
+	g.mu.Unlock()
+
+	timer := time.NewTimer(getworkLongPollTimeout)
+	defer timer.Stop()
+	select {
+	case <-newJob:
+	case <-timer.C:
+	case <-r.Context().Done():
+	}
+
+	g.mu.Lock()
+	g.status.LongPolls--
+	g.mu.Unlock()
+}
+
+// nextGetwork cuts a getwork unit from the current upstream job
+func (s *Server) nextGetwork() (map[string]string, error) {
+	extranonce1 := s.upstream.GetExtranonce1()
+	size := s.upstream.GetExtranonce2Size()
+	job := s.upstream.GetCurrentJob()
+	if extranonce1 == "" || size < 2 || job == nil {
+		return nil, fmt.Errorf("no work from the upstream pool")
+	}
+	s.rememberJob(job)
+	difficulty := s.shareDifficulty()
+
+	// The counter only moves here, so a job's units take consecutive
+	// counters and share no extranonce2 until the space is used up
+	g := s.getwork
+	g.mu.Lock()
+	if key := extranonce1 + ":" + job.ID; key != g.job {
+		g.job = key
+		g.jobUnits = 0
+	}
+	if g.jobUnits >= getworkSpace(size-1) {
+		g.mu.Unlock()
+		return nil, fmt.Errorf("extranonce2 space for job %s used up, wait for the next job", job.ID)
+	}
+	g.jobUnits++
+	counter := g.counter
+	g.counter++
+	g.mu.Unlock()
+
+	extranonce2 := getworkPrefix + getworkCounter(counter, size-1)
+	header, _, err := miner.BuildHeader(job, extranonce1, extranonce2, job.NTime, "00000000", "")
+	if err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	key := hex.EncodeToString(header[36:68])
+	g.units[key] = &getworkUnit{
+		jobID:       job.ID,
+		extranonce1: extranonce1,
+		extranonce2: extranonce2,
+		epoch:       s.upstream.SessionEpoch(),
+		difficulty:  difficulty,
+		submitted:   make(map[string]bool),
+	}
+	g.order = append(g.order, key)
+	if len(g.order) > maxGetworkUnits {
+		delete(g.units, g.order[0])
+		g.order = g.order[1:]
+	}
+	g.status.Units++
+	g.mu.Unlock()
+
+	// The header padded to two SHA-256 blocks, then every 32-bit word
+	// byte-swapped as getwork miners expect
+	data := make([]byte, 128)
+	copy(data, header)
+	data[80] = 0x80
+	binary.BigEndian.PutUint64(data[120:], 80*8)
+	miner.SwapWords(data)
+
+	var midstate [32]byte
+	for i, v := range miner.Midstate(header) {
+		binary.LittleEndian.PutUint32(midstate[4*i:], v)
+	}
+
+	target := miner.ShareTarget(difficulty)
+	for i, j := 0, len(target)-1; i < j; i, j = i+1, j-1 {
+		target[i], target[j] = target[j], target[i]
+	}
+
+	return map[string]string{
+		"data":     hex.EncodeToString(data),
+		"midstate": hex.EncodeToString(midstate[:]),
+		"hash1":    getworkHash1,
+		"target":   hex.EncodeToString(target[:]),
+	}, nil
+}
+
+// submitGetwork checks a solved getwork unit and submits it upstream
+func (s *Server) submitGetwork(worker, device, dataHex string) error {
+	err := s.checkGetwork(worker, device, dataHex)
+
+	g := s.getwork
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.status.LastSubmitAt = time.Now()
+	if err != nil {
+		g.status.Rejected++
+	} else {
+		g.status.Accepted++
+	}
+	return err
+}
+
+// checkGetwork finds the unit a solution is for and submits it if it
+// meets the share difficulty the unit was handed out at
+func (s *Server) checkGetwork(worker, device, dataHex string) error {
+	data, err := hex.DecodeString(dataHex)
+	if err != nil || len(data) < 80 {
+		return fmt.Errorf("invalid data")
+	}
+	header := data[:80]
+	miner.SwapWords(header)
+
+	g := s.getwork
+	g.mu.Lock()
+	unit := g.units[hex.EncodeToString(header[36:68])]
+	g.mu.Unlock()
+	if unit == nil {
+		return fmt.Errorf("unknown work")
+	}
+	job := s.Job(unit.jobID)
+	if job == nil {
+		return fmt.Errorf("stale work")
+	}
+
+	ntime := fmt.Sprintf("%08x", binary.LittleEndian.Uint32(header[68:72]))
+	nonce := fmt.Sprintf("%08x", binary.LittleEndian.Uint32(header[76:80]))
+	difficulty, err := miner.ShareDifficulty(job, unit.extranonce1, unit.extranonce2, ntime, nonce, "")
+	if err != nil {
+		return err
+	}
+	if difficulty < unit.difficulty {
+		return fmt.Errorf("low difficulty share")
+	}
+
+	// Hold the solution while it is submitted so a concurrent copy is
+	// caught, and release it again if the submit fails
+	key := ntime + nonce
+	g.mu.Lock()
+	duplicate := unit.submitted[key]
+	unit.submitted[key] = true
+	g.mu.Unlock()
+	if duplicate {
+		return fmt.Errorf("duplicate share")
+	}
+
+	err = s.submit(worker, device, unit.epoch, job.ID, unit.extranonce2, ntime, nonce, "", difficulty)
+	if err != nil {
+		g.mu.Lock()
+		delete(unit.submitted, key)
+		g.mu.Unlock()
+	}
+	return err
+}
+
+// getworkSpace returns how many distinct counters fit in size bytes
+func getworkSpace(size int) uint64 {
+	if size >= 8 {
+		return math.MaxUint64
+	}
+	return 1 << (8 * size)
+}
+
+// getworkCounter lays out a unit counter in size bytes, wrapping
+func getworkCounter(counter uint64, size int) string {
+	var be [8]byte
+	binary.BigEndian.PutUint64(be[:], counter)
+	buf := make([]byte, size)
+	n := min(size, 8)
+	copy(buf[size-n:], be[8-n:])
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,284 @@
+package proxy
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/soloforge/backend/internal/stratum"
+)
+
+// maxMiners is the number of extranonce1 prefixes available, one byte
+// each. Prefix 00 is miner.LocalExtranoncePrefix, kept for local workers,
+// and ff is getworkPrefix.
+const maxMiners = 254
+
+// maxRecentJobs bounds the jobs miners may still submit shares for
+const maxRecentJobs = 16
+
+// ShareFunc records and submits a share found by a downstream miner,
+// identified by its worker name and device address. The returned error is
+// reported back to the miner as a rejection.
+type ShareFunc func(worker, device string, epoch uint64, jobID, extranonce2, ntime, nonce, versionBits string, difficulty float64) error
+
+// MinerStatus describes a connected downstream miner
+type MinerStatus struct {
+	ID          int       `json:"id"`
+	Worker      string    `json:"worker"`
+	Address     string    `json:"address"`
+	ConnectedAt time.Time `json:"connected_at"`
+	Difficulty  float64   `json:"difficulty"`
+	Accepted    int       `json:"accepted"`
+	Rejected    int       `json:"rejected"`
+	BestShare   float64   `json:"best_share"`
+	LastShareAt time.Time `json:"last_share_at,omitempty"`
+	Hashrate    float64   `json:"hashrate"`
+}
+
+// Server speaks Stratum V1 to miners on the LAN and forwards their work to
+// the upstream pool session. Each miner gets the upstream extranonce1 plus
+// a one-byte prefix, so their extranonce2 spaces never overlap.
+type Server struct {
+	mu sync.RWMutex
+
+	upstream *stratum.Client
+	listener net.Listener
+	addr     string
+
+	sessions map[int]*session
+
+	// Recent upstream jobs by ID, oldest first in jobOrder
+	jobs     map[string]*stratum.Job
+	jobOrder []string
+
+	// Work for getwork miners, served over HTTP
+	getwork *getwork
+
+	onShare ShareFunc
+}
+
+// NewServer creates a proxy server forwarding to upstream
+func NewServer(upstream *stratum.Client) *Server {
+	return &Server{
+		upstream: upstream,
+		sessions: make(map[int]*session),
+		jobs:     make(map[string]*stratum.Job),
+		getwork:  newGetwork(),
+	}
+}
+
+// SetShareCallback sets the callback for shares from downstream miners
+func (s *Server) SetShareCallback(cb ShareFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onShare = cb
+}
+
+// Start listens for miners on addr, e.g. ":3333". Starting an already
+// listening server is a no-op.
+func (s *Server) Start(addr string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener != nil {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	s.listener = listener
+	s.addr = listener.Addr().String()
+
+	go s.acceptLoop(listener)
+
+	log.Printf("Stratum proxy listening on %s", s.addr)
+	return nil
+}
+
+// Stop closes the listener and disconnects every miner
+func (s *Server) Stop() {
+	s.mu.Lock()
+	listener := s.listener
+	s.listener = nil
+	sessions := make([]*session, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		sessions = append(sessions, sess)
+	}
+	s.mu.Unlock()
+
+	if listener != nil {
+		listener.Close()
+	}
+	for _, sess := range sessions {
+		sess.close()
+	}
+}
+
+// IsListening returns whether the proxy accepts miners
+func (s *Server) IsListening() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.listener != nil
+}
+
+// Addr returns the address the proxy listens on
+func (s *Server) Addr() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.addr
+}
+
+// acceptLoop accepts miner connections until the listener is closed
+func (s *Server) acceptLoop(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		sess, err := s.register(conn)
+		if err != nil {
+			log.Printf("Rejecting miner %s: %v", conn.RemoteAddr(), err)
+			conn.Close()
+			continue
+		}
+
+		go sess.serve()
+	}
+}
+
+// register creates a session with the lowest free extranonce prefix
+func (s *Server) register(conn net.Conn) (*session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id := 1; id <= maxMiners; id++ {
+		if _, used := s.sessions[id]; used {
+			continue
+		}
+		sess := newSession(s, id, conn)
+		s.sessions[id] = sess
+		return sess, nil
+	}
+	return nil, fmt.Errorf("all %d miner slots in use", maxMiners)
+}
+
+// unregister removes a closed session
+func (s *Server) unregister(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+}
+
+// allSessions returns the connected sessions
+func (s *Server) allSessions() []*session {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sessions := make([]*session, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		sessions = append(sessions, sess)
+	}
+	return sessions
+}
+
+// BroadcastJob forwards an upstream job to every subscribed miner
+func (s *Server) BroadcastJob(job *stratum.Job) {
+	s.rememberJob(job)
+	s.getwork.jobArrived()
+
+	for _, sess := range s.allSessions() {
+		sess.sendJob(job)
+	}
+}
+
+// rememberJob records a job miners may submit shares for
+func (s *Server) rememberJob(job *stratum.Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, known := s.jobs[job.ID]; known {
+		return
+	}
+	if job.CleanJobs {
+		s.jobs = make(map[string]*stratum.Job)
+		s.jobOrder = s.jobOrder[:0]
+	}
+	s.jobs[job.ID] = job
+	s.jobOrder = append(s.jobOrder, job.ID)
+	if len(s.jobOrder) > maxRecentJobs {
+		delete(s.jobs, s.jobOrder[0])
+		s.jobOrder = s.jobOrder[1:]
+	}
+}
+
+// SetDifficulty forwards the upstream share difficulty to every miner
+func (s *Server) SetDifficulty(difficulty float64) {
+	for _, sess := range s.allSessions() {
+		sess.sendDifficulty(difficulty)
+	}
+}
+
+// UpdateExtranonce moves every miner onto the upstream session's current
+// extranonce1. Miners that didn't subscribe to extranonce changes are
+// disconnected so they resubscribe.
+func (s *Server) UpdateExtranonce() {
+	for _, sess := range s.allSessions() {
+		sess.updateExtranonce()
+	}
+}
+
+// Miners returns the connected miners, ordered by ID
+func (s *Server) Miners() []MinerStatus {
+	sessions := s.allSessions()
+	miners := make([]MinerStatus, 0, len(sessions))
+	for _, sess := range sessions {
+		miners = append(miners, sess.status())
+	}
+
+	sort.Slice(miners, func(i, j int) bool { return miners[i].ID < miners[j].ID })
+	return miners
+}
+
+// Hashrate returns the combined hashrate estimated from miners' shares
+func (s *Server) Hashrate() float64 {
+	var total float64
+	for _, sess := range s.allSessions() {
+		total += sess.hashrate(time.Now())
+	}
+	return total
+}
+
+// Job returns a recent upstream job by ID, nil once it was replaced by a
+// clean job or aged out
+func (s *Server) Job(id string) *stratum.Job {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.jobs[id]
+}
+
+// shareDifficulty returns the difficulty to hand to miners, the pool's
+// if it set one
+func (s *Server) shareDifficulty() float64 {
+	if d := s.upstream.GetDifficulty(); d > 0 {
+		return d
+	}
+	return 1
+}
+
+// submit hands a miner's share to the callback
+func (s *Server) submit(worker, device string, epoch uint64, jobID, extranonce2, ntime, nonce, versionBits string, difficulty float64) error {
+	s.mu.RLock()
+	cb := s.onShare
+	s.mu.RUnlock()
+
+	if cb == nil {
+		return fmt.Errorf("proxy not attached to a pool session")
+	}
+	return cb(worker, device, epoch, jobID, extranonce2, ntime, nonce, versionBits, difficulty)
+}
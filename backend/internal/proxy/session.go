@@ -0,0 +1,454 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/soloforge/backend/internal/miner"
+	"github.com/soloforge/backend/internal/stratum"
+)
+
+// hashrateWindow is how far back shares count towards a miner's hashrate
+const hashrateWindow = 10 * time.Minute
+
+// Stratum error codes sent to miners
+const (
+	errCodeOther         = 20
+	errCodeJobNotFound   = 21
+	errCodeLowDifficulty = 23
+	errCodeUnauthorized  = 24
+	errCodeNotSubscribed = 25
+)
+
+// request is a JSON-RPC request from a miner. IDs are echoed back as-is
+// since firmwares differ on numbers versus strings.
+type request struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// response is a JSON-RPC response to a miner
+type response struct {
+	ID     json.RawMessage `json:"id"`
+	Result interface{}     `json:"result"`
+	Error  interface{}     `json:"error"`
+}
+
+// notification is a JSON-RPC notification to a miner
+type notification struct {
+	ID     interface{}   `json:"id"`
+	Method string        `json:"method"`
+	Params []interface{} `json:"params"`
+}
+
+// shareSample is an accepted share counted towards hashrate
+type shareSample struct {
+	at         time.Time
+	difficulty float64
+}
+
+// session is one downstream miner connection
+type session struct {
+	mu sync.Mutex
+
+	server  *Server
+	id      int
+	conn    net.Conn
+	writeMu sync.Mutex
+
+	worker      string
+	connectedAt time.Time
+
+	// Extranonce assignment, valid for the upstream epoch it was made in
+	subscribed         bool
+	extranonceUpdates  bool
+	epoch              uint64
+	upstreamExtranonce string
+	extranonce2Size    int
+	difficulty         float64
+
+	accepted    int
+	rejected    int
+	bestShare   float64
+	lastShareAt time.Time
+	samples     []shareSample
+}
+
+// newSession creates a session for a miner connection
+func newSession(server *Server, id int, conn net.Conn) *session {
+	return &session{
+		server:      server,
+		id:          id,
+		conn:        conn,
+		connectedAt: time.Now(),
+	}
+}
+
+// prefix returns the miner's extranonce1 suffix within the upstream space
+func (s *session) prefix() string {
+	return fmt.Sprintf("%02x", s.id)
+}
+
+// device returns the miner's IP address, which stays the same across
+// reconnects where its session ID and port may not
+func (s *session) device() string {
+	addr := s.conn.RemoteAddr().String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// serve handles requests until the miner disconnects
+func (s *session) serve() {
+	defer func() {
+		s.server.unregister(s.id)
+		s.conn.Close()
+		log.Printf("Miner %d (%s) disconnected", s.id, s.conn.RemoteAddr())
+	}()
+
+	log.Printf("Miner %d connected from %s", s.id, s.conn.RemoteAddr())
+
+	reader := bufio.NewReader(s.conn)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			return
+		}
+
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil || req.Method == "" {
+			continue
+		}
+		s.handle(&req)
+	}
+}
+
+// close disconnects the miner
+func (s *session) close() {
+	s.conn.Close()
+}
+
+// handle dispatches a miner request
+func (s *session) handle(req *request) {
+	switch req.Method {
+	case "mining.configure":
+		s.handleConfigure(req)
+	case "mining.subscribe":
+		s.handleSubscribe(req)
+	case "mining.extranonce.subscribe":
+		s.mu.Lock()
+		s.extranonceUpdates = true
+		s.mu.Unlock()
+		s.reply(req.ID, true, nil)
+	case "mining.authorize":
+		s.handleAuthorize(req)
+	case "mining.suggest_difficulty":
+		// The pool sets the difficulty for the whole session
+		s.reply(req.ID, true, nil)
+	case "mining.submit":
+		s.handleSubmit(req)
+	default:
+		s.reply(req.ID, nil, []interface{}{errCodeOther, "Unsupported method", nil})
+	}
+}
+
+// handleConfigure offers the upstream version-rolling mask
+func (s *session) handleConfigure(req *request) {
+	mask := s.server.upstream.GetVersionMask()
+	result := map[string]interface{}{
+		"version-rolling": mask != 0,
+	}
+	if mask != 0 {
+		result["version-rolling.mask"] = fmt.Sprintf("%08x", mask)
+	}
+	s.reply(req.ID, result, nil)
+}
+
+// handleSubscribe assigns the miner its slice of the upstream extranonce
+func (s *session) handleSubscribe(req *request) {
+	upstream := s.server.upstream
+	extranonce1 := upstream.GetExtranonce1()
+	size := upstream.GetExtranonce2Size()
+	if extranonce1 == "" || size < 2 {
+		s.reply(req.ID, nil, []interface{}{errCodeOther, "Upstream pool not ready", nil})
+		return
+	}
+
+	difficulty := s.server.shareDifficulty()
+
+	s.mu.Lock()
+	s.subscribed = true
+	s.epoch = upstream.SessionEpoch()
+	s.upstreamExtranonce = extranonce1
+	s.extranonce2Size = size - 1
+	s.difficulty = difficulty
+	s.mu.Unlock()
+
+	subID := strconv.Itoa(s.id)
+	s.reply(req.ID, []interface{}{
+		[]interface{}{
+			[]interface{}{"mining.set_difficulty", subID},
+			[]interface{}{"mining.notify", subID},
+		},
+		extranonce1 + s.prefix(),
+		size - 1,
+	}, nil)
+
+	s.sendDifficulty(difficulty)
+	if job := upstream.GetCurrentJob(); job != nil {
+		s.server.rememberJob(job)
+		clean := *job
+		clean.CleanJobs = true
+		s.sendJob(&clean)
+	}
+}
+
+// handleAuthorize accepts any worker name, the upstream session is
+// authorized with the configured wallet
+func (s *session) handleAuthorize(req *request) {
+	var p []interface{}
+	json.Unmarshal(req.Params, &p)
+
+	worker := ""
+	if len(p) > 0 {
+		worker, _ = p[0].(string)
+	}
+	if worker == "" {
+		worker = s.conn.RemoteAddr().String()
+	}
+
+	s.mu.Lock()
+	s.worker = worker
+	s.mu.Unlock()
+
+	log.Printf("Miner %d authorized as %s", s.id, worker)
+	s.reply(req.ID, true, nil)
+}
+
+// handleSubmit checks a miner's share and forwards it upstream
+func (s *session) handleSubmit(req *request) {
+	var p []string
+	if err := json.Unmarshal(req.Params, &p); err != nil || len(p) < 5 {
+		s.reply(req.ID, nil, []interface{}{errCodeOther, "Invalid params", nil})
+		return
+	}
+	jobID, extranonce2, ntime, nonce := p[1], p[2], p[3], p[4]
+	versionBits := ""
+	if len(p) > 5 {
+		versionBits = p[5]
+	}
+
+	s.mu.Lock()
+	subscribed, worker := s.subscribed, s.worker
+	epoch, extranonce1, difficulty := s.epoch, s.upstreamExtranonce, s.difficulty
+	extranonce2Size := s.extranonce2Size
+	s.mu.Unlock()
+
+	if !subscribed {
+		s.reply(req.ID, nil, []interface{}{errCodeNotSubscribed, "Not subscribed", nil})
+		return
+	}
+	if worker == "" {
+		s.reply(req.ID, nil, []interface{}{errCodeUnauthorized, "Unauthorized worker", nil})
+		return
+	}
+
+	if len(extranonce2) != extranonce2Size*2 {
+		s.reject(req.ID, errCodeOther, "Invalid extranonce2 size")
+		return
+	}
+
+	job := s.server.Job(jobID)
+	if job == nil {
+		s.reject(req.ID, errCodeJobNotFound, "Job not found")
+		return
+	}
+
+	version, err := miner.RolledVersion(job.Version, versionBits, s.server.upstream.GetVersionMask())
+	if err != nil {
+		s.reject(req.ID, errCodeOther, err.Error())
+		return
+	}
+
+	// The upstream extranonce2 is our prefix followed by the miner's
+	upstreamExtranonce2 := s.prefix() + extranonce2
+	shareDiff, err := miner.ShareDifficulty(job, extranonce1, upstreamExtranonce2, ntime, nonce, version)
+	if err != nil {
+		s.reject(req.ID, errCodeOther, err.Error())
+		return
+	}
+	if shareDiff < difficulty {
+		s.reject(req.ID, errCodeLowDifficulty, "Low difficulty share")
+		return
+	}
+
+	if err := s.server.submit(worker, s.device(), epoch, jobID, upstreamExtranonce2, ntime, nonce, versionBits, shareDiff); err != nil {
+		s.reject(req.ID, errCodeOther, err.Error())
+		return
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	s.accepted++
+	s.lastShareAt = now
+	if shareDiff > s.bestShare {
+		s.bestShare = shareDiff
+	}
+	s.samples = append(s.samples, shareSample{at: now, difficulty: difficulty})
+	s.pruneSamplesLocked(now)
+	s.mu.Unlock()
+
+	s.reply(req.ID, true, nil)
+}
+
+// reject answers a submit with an error and counts it
+func (s *session) reject(id json.RawMessage, code int, message string) {
+	s.mu.Lock()
+	s.rejected++
+	s.mu.Unlock()
+
+	s.reply(id, nil, []interface{}{code, message, nil})
+}
+
+// sendJob forwards a job if the miner is subscribed
+func (s *session) sendJob(job *stratum.Job) {
+	s.mu.Lock()
+	subscribed := s.subscribed
+	s.mu.Unlock()
+	if !subscribed {
+		return
+	}
+
+	s.notify("mining.notify", []interface{}{
+		job.ID, job.PrevHash, job.Coinbase1, job.Coinbase2, job.MerkleBranch,
+		job.Version, job.NBits, job.NTime, job.CleanJobs,
+	})
+}
+
+// sendDifficulty sets the miner's share difficulty
+func (s *session) sendDifficulty(difficulty float64) {
+	s.mu.Lock()
+	subscribed := s.subscribed
+	s.difficulty = difficulty
+	s.mu.Unlock()
+	if !subscribed {
+		return
+	}
+
+	s.notify("mining.set_difficulty", []interface{}{difficulty})
+}
+
+// updateExtranonce reassigns the miner's extranonce after the upstream
+// one changed
+func (s *session) updateExtranonce() {
+	upstream := s.server.upstream
+	extranonce1 := upstream.GetExtranonce1()
+	size := upstream.GetExtranonce2Size()
+
+	s.mu.Lock()
+	if !s.subscribed {
+		s.mu.Unlock()
+		return
+	}
+	if !s.extranonceUpdates || size < 2 {
+		s.mu.Unlock()
+		s.close()
+		return
+	}
+	s.epoch = upstream.SessionEpoch()
+	s.upstreamExtranonce = extranonce1
+	s.extranonce2Size = size - 1
+	s.mu.Unlock()
+
+	s.notify("mining.set_extranonce", []interface{}{extranonce1 + s.prefix(), size - 1})
+}
+
+// status returns a snapshot of the miner
+func (s *session) status() MinerStatus {
+	now := time.Now()
+	rate := s.hashrate(now)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return MinerStatus{
+		ID:          s.id,
+		Worker:      s.worker,
+		Address:     s.conn.RemoteAddr().String(),
+		ConnectedAt: s.connectedAt,
+		Difficulty:  s.difficulty,
+		Accepted:    s.accepted,
+		Rejected:    s.rejected,
+		BestShare:   s.bestShare,
+		LastShareAt: s.lastShareAt,
+		Hashrate:    rate,
+	}
+}
+
+// hashrate estimates the miner's hashrate from recent share difficulty
+func (s *session) hashrate(now time.Time) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneSamplesLocked(now)
+
+	window := now.Sub(s.connectedAt)
+	if window > hashrateWindow {
+		window = hashrateWindow
+	}
+	if window <= 0 {
+		return 0
+	}
+
+	var total float64
+	for _, sample := range s.samples {
+		total += sample.difficulty
+	}
+	return total * miner.HashesPerDifficulty / window.Seconds()
+}
+
+// pruneSamplesLocked drops shares older than the hashrate window. Caller
+// must hold s.mu.
+func (s *session) pruneSamplesLocked(now time.Time) {
+	cutoff := now.Add(-hashrateWindow)
+	i := 0
+	for i < len(s.samples) && s.samples[i].at.Before(cutoff) {
+		i++
+	}
+	s.samples = s.samples[i:]
+}
+
+// reply sends a response to the miner
+func (s *session) reply(id json.RawMessage, result interface{}, errValue interface{}) {
+	s.write(response{ID: id, Result: result, Error: errValue})
+}
+
+// notify sends a notification to the miner
+func (s *session) notify(method string, params []interface{}) {
+	s.write(notification{ID: nil, Method: method, Params: params})
+}
+
+// write sends one JSON line to the miner
+func (s *session) write(msg interface{}) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if _, err := s.conn.Write(data); err != nil {
+		s.conn.Close()
+	}
+}
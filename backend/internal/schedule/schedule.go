@@ -0,0 +1,233 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/soloforge/backend/internal/clockwindow"
+)
+
+// dayNames are the accepted day names, matched by their first three
+// letters, in time.Weekday order
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// dayGroups are day names standing for several days
+var dayGroups = map[string][]time.Weekday{
+	"weekdays": {time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	"weekend":  {time.Saturday, time.Sunday},
+}
+
+// Rule is a time-of-day window to mine in. Rules may span midnight
+// (22:00-07:00); Days ("mon", "friday", "weekdays", "weekend") limits a
+// rule to the days it starts on, none meaning every day, and equal start
+// and end cover the whole day.
+type Rule struct {
+	ID    string   `json:"id"`
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+	Days  []string `json:"days,omitempty"`
+}
+
+// Validate checks the clock times and day names
+func (r Rule) Validate() error {
+	if _, err := clockwindow.Parse(r.Start); err != nil {
+		return fmt.Errorf("invalid start %q, want HH:MM", r.Start)
+	}
+	if _, err := clockwindow.Parse(r.End); err != nil {
+		return fmt.Errorf("invalid end %q, want HH:MM", r.End)
+	}
+	for _, d := range r.Days {
+		if weekdays(d) == nil {
+			return fmt.Errorf("invalid day %q", d)
+		}
+	}
+	return nil
+}
+
+// Contains reports whether t falls in the rule
+func (r Rule) Contains(t time.Time) bool {
+	in, err := clockwindow.Contains(r.Start, r.End, t, r.onDay)
+	return err == nil && in
+}
+
+// onDay reports whether the rule starts on day
+func (r Rule) onDay(day time.Weekday) bool {
+	if len(r.Days) == 0 {
+		return true
+	}
+	for _, d := range r.Days {
+		for _, wd := range weekdays(d) {
+			if wd == day {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Active returns the first rule t falls in
+func Active(rules []Rule, t time.Time) (Rule, bool) {
+	for _, r := range rules {
+		if r.Contains(t) {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// NextChange returns the next minute after t at which mining starts or
+// stops, or the zero time if it never changes within a week
+func NextChange(rules []Rule, t time.Time) time.Time {
+	_, active := Active(rules, t)
+	next := t.Truncate(time.Minute)
+	for i := 0; i < 7*24*60; i++ {
+		next = next.Add(time.Minute)
+		if _, ok := Active(rules, next); ok != active {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// Decision is whether to mine at a point in time, and the rule that says so
+type Decision struct {
+	Mine       bool      `json:"mine"`
+	Rule       string    `json:"rule,omitempty"`
+	NextChange time.Time `json:"next_change,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+// Scheduler periodically evaluates the rules and reports when mining
+// should start or stop. Without rules it never reports anything, so
+// mining is left to the operator.
+type Scheduler struct {
+	mu sync.RWMutex
+
+	rules []Rule
+	last  Decision
+
+	interval time.Duration
+	onChange func(Decision)
+
+	running  bool
+	shutdown chan struct{}
+}
+
+// NewScheduler creates a scheduler evaluating every 30 seconds
+func NewScheduler() *Scheduler {
+	return &Scheduler{interval: 30 * time.Second}
+}
+
+// SetChangeCallback sets the callback invoked when mining should start or
+// stop
+func (s *Scheduler) SetChangeCallback(cb func(Decision)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange = cb
+}
+
+// SetRules replaces the rules. The next evaluation reports the decision
+// under the new rules even if it did not change.
+func (s *Scheduler) SetRules(rules []Rule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = rules
+	s.last = Decision{}
+}
+
+// GetRules returns a copy of the rules
+func (s *Scheduler) GetRules() []Rule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rules := make([]Rule, len(s.rules))
+	copy(rules, s.rules)
+	return rules
+}
+
+// Current returns the most recent decision
+func (s *Scheduler) Current() Decision {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.last
+}
+
+// Start begins periodic evaluation
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.shutdown = make(chan struct{})
+	shutdown := s.shutdown
+	interval := s.interval
+	s.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		s.Evaluate(time.Now())
+		for {
+			select {
+			case <-shutdown:
+				return
+			case now := <-ticker.C:
+				s.Evaluate(now)
+			}
+		}
+	}()
+}
+
+// Stop halts periodic evaluation
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.running {
+		return
+	}
+	s.running = false
+	close(s.shutdown)
+}
+
+// Evaluate computes the decision for now and fires the callback if it
+// changed
+func (s *Scheduler) Evaluate(now time.Time) Decision {
+	rules := s.GetRules()
+
+	d := Decision{At: now}
+	if rule, ok := Active(rules, now); ok {
+		d.Mine, d.Rule = true, rule.ID
+	}
+	d.NextChange = NextChange(rules, now)
+
+	s.mu.Lock()
+	changed := d.Mine != s.last.Mine || s.last.At.IsZero()
+	s.last = d
+	cb := s.onChange
+	s.mu.Unlock()
+
+	if changed && cb != nil && len(rules) > 0 {
+		cb(d)
+	}
+	return d
+}
+
+// weekdays returns the weekdays a day name or group stands for, nil if
+// it is unknown
+func weekdays(day string) []time.Weekday {
+	day = strings.ToLower(strings.TrimSpace(day))
+	if group, ok := dayGroups[day]; ok {
+		return group
+	}
+	for i, name := range dayNames {
+		if strings.HasPrefix(day, name) {
+			return []time.Weekday{time.Weekday(i)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package stats
+
+import (
+	"sort"
+	"time"
+)
+
+// maxActivityDays bounds how many daily activity buckets are kept
+const maxActivityDays = 400
+
+// dateLayout is the key format for daily buckets
+const dateLayout = "2006-01-02"
+
+// DayActivity is the mining activity sampled over one calendar day
+type DayActivity struct {
+	Date          string  `json:"date"`
+	MiningSeconds float64 `json:"mining_seconds"`
+	Hashes        uint64  `json:"hashes"`
+}
+
+// ActivityDay is one cell of the activity calendar
+type ActivityDay struct {
+	Date        string  `json:"date"`
+	MiningHours float64 `json:"mining_hours"`
+	Hashes      uint64  `json:"hashes"`
+	Level       int     `json:"level"`
+}
+
+// recordActivityLocked adds the hashes done since the previous sample to
+// today's bucket. Caller must hold c.mu.
+func (c *Collector) recordActivityLocked(count uint64) {
+	now := time.Now()
+	lastCount, lastAt := c.lastSampleCount, c.lastSampleAt
+	c.lastSampleCount, c.lastSampleAt = count, now
+
+	// Counter went backwards (workers removed) or first sample
+	if lastAt.IsZero() || count <= lastCount {
+		return
+	}
+
+	date := now.Format(dateLayout)
+	n := len(c.dailyActivity)
+	if n == 0 || c.dailyActivity[n-1].Date != date {
+		c.dailyActivity = append(c.dailyActivity, DayActivity{Date: date})
+		if len(c.dailyActivity) > maxActivityDays {
+			c.dailyActivity = c.dailyActivity[1:]
+		}
+		n = len(c.dailyActivity)
+	}
+
+	day := &c.dailyActivity[n-1]
+	day.Hashes += count - lastCount
+	day.MiningSeconds += now.Sub(lastAt).Seconds()
+
+	hour := c.hourBucketLocked(now)
+	hour.Hashes += count - lastCount
+	hour.MiningSeconds += now.Sub(lastAt).Seconds()
+}
+
+// GetActivity returns per-day activity for the last n days ending today,
+// shaped for a contribution-calendar widget. Days predating hashrate
+// sampling fall back to session history.
+func (c *Collector) GetActivity(n int) []ActivityDay {
+	if n <= 0 {
+		n = 365
+	}
+
+	c.mu.RLock()
+	sampled := make(map[string]DayActivity, len(c.dailyActivity))
+	for _, d := range c.dailyActivity {
+		sampled[d.Date] = d
+	}
+	fromSessions := sessionActivity(c.sessionHistory)
+	c.mu.RUnlock()
+
+	today := time.Now()
+	days := make([]ActivityDay, 0, n)
+	for i := n - 1; i >= 0; i-- {
+		date := today.AddDate(0, 0, -i).Format(dateLayout)
+
+		d, ok := sampled[date]
+		if !ok {
+			d = fromSessions[date]
+		}
+
+		days = append(days, ActivityDay{
+			Date:        date,
+			MiningHours: d.MiningSeconds / 3600,
+			Hashes:      d.Hashes,
+		})
+	}
+
+	assignLevels(days)
+	return days
+}
+
+// sessionActivity spreads each session's hashes over the days it covered
+func sessionActivity(sessions []Session) map[string]DayActivity {
+	result := make(map[string]DayActivity)
+
+	for _, s := range sessions {
+		total := s.EndTime.Sub(s.StartTime).Seconds()
+		if total <= 0 {
+			continue
+		}
+
+		start := s.StartTime
+		for start.Before(s.EndTime) {
+			y, m, d := start.Date()
+			nextDay := time.Date(y, m, d+1, 0, 0, 0, 0, start.Location())
+			end := nextDay
+			if s.EndTime.Before(end) {
+				end = s.EndTime
+			}
+
+			seconds := end.Sub(start).Seconds()
+			date := start.Format(dateLayout)
+			day := result[date]
+			day.Date = date
+			day.MiningSeconds += seconds
+			day.Hashes += uint64(float64(s.TotalHashes) * seconds / total)
+			result[date] = day
+
+			start = end
+		}
+	}
+
+	return result
+}
+
+// assignLevels buckets active days into levels 1-4 by hash quartile
+func assignLevels(days []ActivityDay) {
+	var active []uint64
+	for _, d := range days {
+		if d.Hashes > 0 {
+			active = append(active, d.Hashes)
+		}
+	}
+	if len(active) == 0 {
+		return
+	}
+
+	sort.Slice(active, func(i, j int) bool { return active[i] < active[j] })
+	quartile := func(q int) uint64 {
+		return active[(len(active)-1)*q/4]
+	}
+	q1, q2, q3 := quartile(1), quartile(2), quartile(3)
+
+	for i := range days {
+		h := days[i].Hashes
+		switch {
+		case h == 0:
+			days[i].Level = 0
+		case h <= q1:
+			days[i].Level = 1
+		case h <= q2:
+			days[i].Level = 2
+		case h <= q3:
+			days[i].Level = 3
+		default:
+			days[i].Level = 4
+		}
+	}
+}
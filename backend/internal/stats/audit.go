@@ -0,0 +1,55 @@
+package stats
+
+import "time"
+
+// maxAuditEntries bounds the audit trail
+const maxAuditEntries = 500
+
+// AuditEntry records an operator action and the request that caused it
+type AuditEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	RequestID string    `json:"request_id"`
+	Action    string    `json:"action"`
+	Source    string    `json:"source"`
+	Detail    string    `json:"detail,omitempty"`
+}
+
+// AddAudit appends an action to the audit trail
+func (c *Collector) AddAudit(requestID, action, source, detail string) AuditEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := AuditEntry{
+		Timestamp: time.Now(),
+		RequestID: requestID,
+		Action:    action,
+		Source:    source,
+		Detail:    detail,
+	}
+
+	c.auditLog = append(c.auditLog, entry)
+	if len(c.auditLog) > maxAuditEntries {
+		c.auditLog = c.auditLog[1:]
+	}
+
+	return entry
+}
+
+// GetAuditLog returns the most recent audit entries, newest first. A
+// non-empty requestID returns only the entries for that request.
+func (c *Collector) GetAuditLog(requestID string, limit int) []AuditEntry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]AuditEntry, 0)
+	for i := len(c.auditLog) - 1; i >= 0; i-- {
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+		if requestID == "" || c.auditLog[i].RequestID == requestID {
+			result = append(result, c.auditLog[i])
+		}
+	}
+
+	return result
+}
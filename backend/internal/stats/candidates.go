@@ -0,0 +1,62 @@
+package stats
+
+import "time"
+
+// maxCandidates bounds the stored block candidates
+const maxCandidates = 200
+
+// Candidate is a share kept with its full header and coinbase so it can be
+// decoded later. ID is the header hash.
+type Candidate struct {
+	ID             string    `json:"id"`
+	Timestamp      time.Time `json:"timestamp"`
+	WorkerName     string    `json:"worker_name"`
+	Pool           string    `json:"pool"`
+	JobID          string    `json:"job_id"`
+	Difficulty     float64   `json:"difficulty"`
+	PoolDifficulty float64   `json:"pool_difficulty"`
+	Header         string    `json:"header"`
+	Coinbase       string    `json:"coinbase"`
+	MerkleBranch   []string  `json:"merkle_branch"`
+}
+
+// AddCandidate stores a block candidate, dropping the oldest beyond
+// maxCandidates
+func (c *Collector) AddCandidate(candidate Candidate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.candidates = append(c.candidates, candidate)
+	if len(c.candidates) > maxCandidates {
+		c.candidates = c.candidates[1:]
+	}
+}
+
+// GetCandidates returns the most recent candidates, newest first
+func (c *Collector) GetCandidates(limit int) []Candidate {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if limit <= 0 || limit > len(c.candidates) {
+		limit = len(c.candidates)
+	}
+
+	result := make([]Candidate, 0, limit)
+	for i := len(c.candidates) - 1; i >= 0 && len(result) < limit; i-- {
+		result = append(result, c.candidates[i])
+	}
+	return result
+}
+
+// GetCandidate returns the candidate with the given ID
+func (c *Collector) GetCandidate(id string) (Candidate, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, candidate := range c.candidates {
+		if candidate.ID == id {
+			return candidate, true
+		}
+	}
+	return Candidate{}, false
+}
@@ -1,13 +1,29 @@
 package stats
 
 import (
-	"encoding/json"
-	"os"
-	"path/filepath"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// Share statuses as reported by the pool
+const (
+	ShareStatusPending  = "pending"
+	ShareStatusAccepted = "accepted"
+	ShareStatusRejected = "rejected"
+	ShareStatusStale    = "stale"
+	ShareStatusDropped  = "dropped"
+)
+
+// Share origins: found by a local worker, a device on the Stratum proxy,
+// or imported from another miner's log
+const (
+	ShareOriginLocal  = "local"
+	ShareOriginProxy  = "proxy"
+	ShareOriginImport = "import"
+)
+
 // ShareEntry represents a found share in history
 type ShareEntry struct {
 	Timestamp  time.Time `json:"timestamp"`
@@ -17,6 +33,24 @@ type ShareEntry struct {
 	Nonce      string    `json:"nonce"`
 	Difficulty float64   `json:"difficulty"`
 	Accepted   bool      `json:"accepted"`
+	Status     string    `json:"status"`
+	// Why the pool refused the share: stale, duplicate, low_difficulty,
+	// unauthorized or other
+	RejectCategory string `json:"reject_category,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+	Source         string `json:"source,omitempty"`
+	// Where the share was found, and the proxied device's address
+	Origin string `json:"origin,omitempty"`
+	Device string `json:"device,omitempty"`
+	// Shares this entry stands for when bookkeeping was sampled, 0 means 1
+	Weight int `json:"weight,omitempty"`
+	// The share also met the network target, a block solution
+	Block bool `json:"block,omitempty"`
+}
+
+// Count returns how many shares the entry stands for
+func (e ShareEntry) Count() int {
+	return max(e.Weight, 1)
 }
 
 // BlockEntry represents a block detection event
@@ -26,6 +60,13 @@ type BlockEntry struct {
 	PrevHash  string    `json:"prev_hash"`
 }
 
+// PoolMessage is a notice sent by a pool via client.show_message
+type PoolMessage struct {
+	Timestamp time.Time `json:"timestamp"`
+	Pool      string    `json:"pool"`
+	Message   string    `json:"message"`
+}
+
 // Session represents a mining session
 type Session struct {
 	ID             string    `json:"id"`
@@ -34,20 +75,34 @@ type Session struct {
 	Duration       string    `json:"duration"`
 	TotalHashes    uint64    `json:"total_hashes"`
 	BestDifficulty float64   `json:"best_difficulty"`
+	EffortPercent  float64   `json:"effort_percent"`
 }
 
 // PersistentData represents the data structure for JSON persistence
 type PersistentData struct {
-	TotalHashes        uint64       `json:"total_hashes"`
-	TotalShares        int          `json:"total_shares"`
-	AcceptedShares     int          `json:"accepted_shares"`
-	RejectedShares     int          `json:"rejected_shares"`
-	BestDifficulty     float64      `json:"best_difficulty"`
-	TotalMiningSeconds float64      `json:"total_mining_seconds"`
-	ShareHistory       []ShareEntry `json:"share_history"`
-	BlockHistory       []BlockEntry `json:"block_history"`
-	SessionHistory     []Session    `json:"session_history"`
-	LastSaved          time.Time    `json:"last_saved"`
+	TotalHashes         uint64              `json:"total_hashes"`
+	TotalShares         int                 `json:"total_shares"`
+	AcceptedShares      int                 `json:"accepted_shares"`
+	RejectedShares      int                 `json:"rejected_shares"`
+	RejectCategories    map[string]int      `json:"reject_categories"`
+	OriginShares        map[string]int      `json:"origin_shares"`
+	StaleShares         int                 `json:"stale_shares"`
+	DroppedShares       int                 `json:"dropped_shares"`
+	BestDifficulty      float64             `json:"best_difficulty"`
+	TotalMiningSeconds  float64             `json:"total_mining_seconds"`
+	Effort              float64             `json:"effort"`
+	ShareHistory        []ShareEntry        `json:"share_history"`
+	BlockHistory        []BlockEntry        `json:"block_history"`
+	SessionHistory      []Session           `json:"session_history"`
+	PoolReports         []PoolReport        `json:"pool_reports"`
+	DailyActivity       []DayActivity       `json:"daily_activity"`
+	HourlyActivity      []HourActivity      `json:"hourly_activity"`
+	Digests             []Digest            `json:"digests"`
+	PoolMessages        []PoolMessage       `json:"pool_messages"`
+	AuditLog            []AuditEntry        `json:"audit_log"`
+	Candidates          []Candidate         `json:"candidates"`
+	DifficultyHistogram DifficultyHistogram `json:"difficulty_histogram"`
+	LastSaved           time.Time           `json:"last_saved"`
 }
 
 // Collector collects and stores mining statistics
@@ -59,8 +114,17 @@ type Collector struct {
 	totalShares    int
 	acceptedShares int
 	rejectedShares int
-	bestDifficulty float64
-	startTime      time.Time
+	// Refused shares per reject category, stale ones included
+	rejectCategories map[string]int
+	staleShares      int
+	droppedShares    int
+	bestDifficulty   float64
+	// Found shares per origin, sampled entries counted by weight
+	originShares map[string]int
+	startTime    time.Time
+
+	// Worker errors per kind, not persisted
+	workerErrors map[string]int
 
 	// Session tracking
 	startHashes uint64 // Hashes at start of session
@@ -68,33 +132,84 @@ type Collector struct {
 	// Accumulated time from previous sessions
 	previousMiningSeconds float64
 
+	// Effort in expected blocks, lifetime and at the start of this session
+	effort            float64
+	startEffort       float64
+	lastEffortCount   uint64
+	networkDifficulty float64
+
 	// History
 	shareHistory   []ShareEntry
 	blockHistory   []BlockEntry
 	sessionHistory []Session
+	poolMessages   []PoolMessage
+	auditLog       []AuditEntry
+	candidates     []Candidate
+
+	// Every share ever found by difficulty, under its own lock as it is
+	// updated on the share path
+	histMu    sync.Mutex
+	histogram DifficultyHistogram
+
+	// Monthly per-pool service reports
+	poolReports []PoolReport
+
+	// Daily activity sampled from hash count updates
+	dailyActivity   []DayActivity
+	lastSampleCount uint64
+	lastSampleAt    time.Time
+
+	// Hourly activity for digests, and the digests generated so far
+	hourlyActivity []HourActivity
+	digests        []Digest
 
 	// Limits
 	maxHistorySize int
 
+	// Share-path updates buffered per worker, merged by flush
+	shards      [numShards]shard
+	shardCursor atomic.Uint32
+	flushMu     sync.Mutex
+	stop        chan struct{}
+	stopOnce    sync.Once
+
+	// Shares left out of the history under load, counted at the next flush
+	sampler       shareSampler
+	skippedShares atomic.Uint64
+
 	// Persistence
-	dataDir  string
-	dataFile string
+	store Store
 }
 
-// NewCollector creates a new stats collector
+// NewCollector creates a new stats collector persisted to the JSON file
 func NewCollector(maxHistorySize int) *Collector {
+	return NewCollectorWithStore(maxHistorySize, NewFileStore("/app/data", "stats.json")) // Use absolute path in container
+}
+
+// NewCollectorWithStore creates a new stats collector persisted to store
+func NewCollectorWithStore(maxHistorySize int, store Store) *Collector {
 	if maxHistorySize <= 0 {
 		maxHistorySize = 1000
 	}
 
 	c := &Collector{
-		maxHistorySize: maxHistorySize,
-		shareHistory:   make([]ShareEntry, 0),
-		blockHistory:   make([]BlockEntry, 0),
-		sessionHistory: make([]Session, 0),
-		startTime:      time.Now(),
-		dataDir:        "/app/data", // Use absolute path in container
-		dataFile:       "stats.json",
+		maxHistorySize:   maxHistorySize,
+		shareHistory:     make([]ShareEntry, 0),
+		blockHistory:     make([]BlockEntry, 0),
+		sessionHistory:   make([]Session, 0),
+		poolMessages:     make([]PoolMessage, 0),
+		auditLog:         make([]AuditEntry, 0),
+		candidates:       make([]Candidate, 0),
+		poolReports:      make([]PoolReport, 0),
+		rejectCategories: make(map[string]int),
+		originShares:     make(map[string]int),
+		workerErrors:     make(map[string]int),
+		dailyActivity:    make([]DayActivity, 0),
+		hourlyActivity:   make([]HourActivity, 0),
+		digests:          make([]Digest, 0),
+		startTime:        time.Now(),
+		stop:             make(chan struct{}),
+		store:            store,
 	}
 
 	// Try to load existing data
@@ -102,12 +217,17 @@ func NewCollector(maxHistorySize int) *Collector {
 
 	// Record hashes at start of this session (loaded from persistence)
 	c.startHashes = c.totalHashes
+	c.startEffort = c.effort
+
+	go c.aggregateLoop(c.stop)
 
 	return c
 }
 
 // EndSession records the current session to history
 func (c *Collector) EndSession() {
+	c.flush()
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -123,6 +243,7 @@ func (c *Collector) EndSession() {
 		Duration:       duration.String(),
 		TotalHashes:    sessionHashes,
 		BestDifficulty: c.bestDifficulty,
+		EffortPercent:  (c.effort - c.startEffort) * 100,
 	}
 
 	c.sessionHistory = append(c.sessionHistory, session)
@@ -132,57 +253,71 @@ func (c *Collector) EndSession() {
 	}
 }
 
-// Save persists the current statistics to disk
+// Save persists the current statistics to the store
 func (c *Collector) Save() error {
+	return c.store.Save(c.snapshot())
+}
+
+// snapshot gathers everything persisted, buffered updates included
+func (c *Collector) snapshot() *PersistentData {
+	c.flush()
+
+	histogram := c.getHistogram()
+
 	c.mu.RLock()
 	data := PersistentData{
-		TotalHashes:        c.totalHashes,
-		TotalShares:        c.totalShares,
-		AcceptedShares:     c.acceptedShares,
-		RejectedShares:     c.rejectedShares,
-		BestDifficulty:     c.bestDifficulty,
-		TotalMiningSeconds: c.previousMiningSeconds + time.Since(c.startTime).Seconds(),
-		ShareHistory:       c.shareHistory,
-		BlockHistory:       c.blockHistory,
-		SessionHistory:     c.sessionHistory,
-		LastSaved:          time.Now(),
+		TotalHashes:         c.totalHashes,
+		TotalShares:         c.totalShares,
+		AcceptedShares:      c.acceptedShares,
+		RejectedShares:      c.rejectedShares,
+		RejectCategories:    c.rejectCategories,
+		OriginShares:        c.originShares,
+		StaleShares:         c.staleShares,
+		DroppedShares:       c.droppedShares,
+		BestDifficulty:      c.bestDifficulty,
+		TotalMiningSeconds:  c.previousMiningSeconds + time.Since(c.startTime).Seconds(),
+		Effort:              c.effort,
+		ShareHistory:        c.shareHistory,
+		BlockHistory:        c.blockHistory,
+		SessionHistory:      c.sessionHistory,
+		PoolReports:         c.poolReports,
+		DailyActivity:       c.dailyActivity,
+		HourlyActivity:      c.hourlyActivity,
+		Digests:             c.digests,
+		PoolMessages:        c.poolMessages,
+		AuditLog:            c.auditLog,
+		Candidates:          c.candidates,
+		DifficultyHistogram: histogram,
+		LastSaved:           time.Now(),
 	}
 	c.mu.RUnlock()
 
-	// Ensure data directory exists
-	if err := os.MkdirAll(c.dataDir, 0755); err != nil {
-		return err
-	}
+	return &data
+}
 
-	filePath := filepath.Join(c.dataDir, c.dataFile)
-	file, err := os.Create(filePath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
+// ReadOnly reports whether the store refuses to save, because another
+// instance owns it
+func (c *Collector) ReadOnly() bool {
+	ro, ok := c.store.(interface{ ReadOnly() bool })
+	return ok && ro.ReadOnly()
+}
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(data)
+// Close stops aggregation and releases the underlying store
+func (c *Collector) Close() error {
+	c.stopOnce.Do(func() { close(c.stop) })
+	c.flush()
+	return c.store.Close()
 }
 
-// Load restores statistics from disk
+// Load restores statistics from the store
 func (c *Collector) Load() error {
-	filePath := filepath.Join(c.dataDir, c.dataFile)
-
-	file, err := os.Open(filePath)
+	data, err := c.store.Load()
 	if err != nil {
-		if os.IsNotExist(err) {
-			// No previous data, start fresh
-			return nil
-		}
 		return err
 	}
-	defer file.Close()
-
-	var data PersistentData
-	if err := json.NewDecoder(file).Decode(&data); err != nil {
-		return err
+	if data == nil {
+		// No previous data, start fresh
+		return nil
 	}
 
 	c.mu.Lock()
@@ -192,12 +327,30 @@ func (c *Collector) Load() error {
 	c.totalShares = data.TotalShares
 	c.acceptedShares = data.AcceptedShares
 	c.rejectedShares = data.RejectedShares
+	c.rejectCategories = data.RejectCategories
+	c.originShares = data.OriginShares
+	c.staleShares = data.StaleShares
+	c.droppedShares = data.DroppedShares
 	c.bestDifficulty = data.BestDifficulty
 	c.previousMiningSeconds = data.TotalMiningSeconds
+	c.effort = data.Effort
 	c.shareHistory = data.ShareHistory
 	c.blockHistory = data.BlockHistory
 	c.sessionHistory = data.SessionHistory
-
+	c.poolReports = data.PoolReports
+	c.dailyActivity = data.DailyActivity
+	c.hourlyActivity = data.HourlyActivity
+	c.digests = data.Digests
+	c.poolMessages = data.PoolMessages
+	c.auditLog = data.AuditLog
+	c.candidates = data.Candidates
+
+	if c.rejectCategories == nil {
+		c.rejectCategories = make(map[string]int)
+	}
+	if c.originShares == nil {
+		c.originShares = make(map[string]int)
+	}
 	if c.shareHistory == nil {
 		c.shareHistory = make([]ShareEntry, 0)
 	}
@@ -207,39 +360,205 @@ func (c *Collector) Load() error {
 	if c.sessionHistory == nil {
 		c.sessionHistory = make([]Session, 0)
 	}
+	if c.poolReports == nil {
+		c.poolReports = make([]PoolReport, 0)
+	}
+	if c.dailyActivity == nil {
+		c.dailyActivity = make([]DayActivity, 0)
+	}
+	if c.hourlyActivity == nil {
+		c.hourlyActivity = make([]HourActivity, 0)
+	}
+	if c.digests == nil {
+		c.digests = make([]Digest, 0)
+	}
+	if c.poolMessages == nil {
+		c.poolMessages = make([]PoolMessage, 0)
+	}
+	if c.auditLog == nil {
+		c.auditLog = make([]AuditEntry, 0)
+	}
+	if c.candidates == nil {
+		c.candidates = make([]Candidate, 0)
+	}
+
+	// Data saved before the histogram existed seeds it from the history
+	c.histMu.Lock()
+	c.histogram = data.DifficultyHistogram
+	if len(c.histogram.Counts) != histogramBuckets {
+		c.histogram = DifficultyHistogram{}
+		for _, e := range c.shareHistory {
+			c.histogram.add(e.Difficulty, e.Count())
+		}
+	}
+	c.histMu.Unlock()
 
 	return nil
 }
 
-// AddShare records a new share
-func (c *Collector) AddShare(workerID int, workerName, jobID, nonce string, difficulty float64, accepted bool) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// AddShare records a newly found share as pending until the pool answers,
+// and returns how it ranks among every share found. device is the address
+// of the proxied device that found it, empty for local workers.
+func (c *Collector) AddShare(workerID int, workerName, device, jobID, nonce string, difficulty float64) ShareRank {
+	return c.addShare(workerID, workerName, device, jobID, nonce, difficulty, false)
+}
+
+// AddBlockSolution records a share that also met the network target like
+// AddShare, flagged as a block and never sampled away
+func (c *Collector) AddBlockSolution(workerID int, workerName, device, jobID, nonce string, difficulty float64) ShareRank {
+	return c.addShare(workerID, workerName, device, jobID, nonce, difficulty, true)
+}
+
+// addShare records a share for AddShare and AddBlockSolution
+func (c *Collector) addShare(workerID int, workerName, device, jobID, nonce string, difficulty float64, block bool) ShareRank {
+	rank := c.rankShare(difficulty)
 
-	entry := ShareEntry{
+	origin := ShareOriginLocal
+	if device != "" {
+		origin = ShareOriginProxy
+	}
+
+	weight := 0
+	if !block {
+		var record bool
+		record, weight = c.sampler.sample(difficulty)
+		if !record {
+			c.skippedShares.Add(1)
+			return rank
+		}
+		if weight == 1 {
+			weight = 0
+		}
+	}
+
+	c.bufferShare(ShareEntry{
 		Timestamp:  time.Now(),
 		WorkerID:   workerID,
 		WorkerName: workerName,
 		JobID:      jobID,
 		Nonce:      nonce,
 		Difficulty: difficulty,
-		Accepted:   accepted,
+		Status:     ShareStatusPending,
+		Origin:     origin,
+		Device:     device,
+		Weight:     weight,
+		Block:      block,
+	})
+	return rank
+}
+
+// ImportShares merges shares recorded elsewhere (e.g. ckpool or AxeOS
+// logs) into the history, tagged with source. Shares already present are
+// skipped so the same log can be imported twice. Returns how many were added.
+func (c *Collector) ImportShares(entries []ShareEntry, source string) int {
+	c.flush()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	type shareKey struct {
+		timestamp int64
+		nonce     string
+		source    string
+	}
+	seen := make(map[shareKey]bool, len(c.shareHistory))
+	for _, e := range c.shareHistory {
+		seen[shareKey{e.Timestamp.UnixNano(), e.Nonce, e.Source}] = true
 	}
 
-	c.shareHistory = append(c.shareHistory, entry)
+	added := 0
+	for _, e := range entries {
+		e.Source = source
+		e.Origin = ShareOriginImport
+		key := shareKey{e.Timestamp.UnixNano(), e.Nonce, e.Source}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		switch e.Status {
+		case ShareStatusAccepted:
+			c.acceptedShares++
+		case ShareStatusStale:
+			c.staleShares++
+		default:
+			e.Status = ShareStatusRejected
+			c.rejectedShares++
+		}
+		e.Accepted = e.Status == ShareStatusAccepted
+
+		c.totalShares++
+		c.originShares[e.Origin] += e.Count()
+		if e.Difficulty > c.bestDifficulty {
+			c.bestDifficulty = e.Difficulty
+		}
+		c.histMu.Lock()
+		c.histogram.add(e.Difficulty, e.Count())
+		c.histMu.Unlock()
+
+		c.shareHistory = append(c.shareHistory, e)
+		added++
+	}
+
+	// Imported shares are usually older, keep the history chronological
+	sort.SliceStable(c.shareHistory, func(i, j int) bool {
+		return c.shareHistory[i].Timestamp.Before(c.shareHistory[j].Timestamp)
+	})
 	if len(c.shareHistory) > c.maxHistorySize {
-		c.shareHistory = c.shareHistory[1:]
+		c.shareHistory = c.shareHistory[len(c.shareHistory)-c.maxHistorySize:]
+	}
+
+	return added
+}
+
+// RecordSubmitResult applies the pool's verdict to a pending share.
+// Counters are updated even if the share has already left the history.
+// Refused shares carry the pool's reject category and message.
+func (c *Collector) RecordSubmitResult(jobID, nonce, status, category, reason string) {
+	switch status {
+	case ShareStatusAccepted:
+		category = ""
+	case ShareStatusStale:
+	default:
+		status = ShareStatusRejected
 	}
 
-	c.totalShares++
-	if accepted {
+	c.bufferVerdict(verdict{jobID: jobID, nonce: nonce, status: status, category: category, reason: reason})
+}
+
+// RecordDroppedShare marks a pending share that was never submitted
+// because it was mined for a previous session's extranonce1
+func (c *Collector) RecordDroppedShare(jobID, nonce string) {
+	c.bufferVerdict(verdict{jobID: jobID, nonce: nonce, status: ShareStatusDropped, reason: "previous session"})
+}
+
+// applyVerdictLocked counts a verdict and applies it to its pending
+// share. Caller must hold c.mu.
+func (c *Collector) applyVerdictLocked(v verdict) {
+	switch v.status {
+	case ShareStatusAccepted:
 		c.acceptedShares++
-	} else {
+	case ShareStatusStale:
+		c.staleShares++
+	case ShareStatusDropped:
+		c.droppedShares++
+	default:
 		c.rejectedShares++
 	}
+	if v.category != "" {
+		c.rejectCategories[v.category]++
+	}
 
-	if difficulty > c.bestDifficulty {
-		c.bestDifficulty = difficulty
+	// Search newest first, the verdict is usually for a recent share
+	for i := len(c.shareHistory) - 1; i >= 0; i-- {
+		entry := &c.shareHistory[i]
+		if entry.JobID == v.jobID && entry.Nonce == v.nonce && entry.Status == ShareStatusPending {
+			entry.Status = v.status
+			entry.Accepted = v.status == ShareStatusAccepted
+			entry.RejectCategory = v.category
+			entry.Reason = v.reason
+			return
+		}
 	}
 }
 
@@ -260,15 +579,56 @@ func (c *Collector) AddBlock(height int64, prevHash string) {
 	}
 }
 
+// AddPoolMessage records a pool notice
+func (c *Collector) AddPoolMessage(pool, message string) PoolMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := PoolMessage{
+		Timestamp: time.Now(),
+		Pool:      pool,
+		Message:   message,
+	}
+
+	c.poolMessages = append(c.poolMessages, entry)
+	// Keep last 100 messages
+	if len(c.poolMessages) > 100 {
+		c.poolMessages = c.poolMessages[1:]
+	}
+
+	return entry
+}
+
+// GetPoolMessages returns the most recent pool notices, newest first
+func (c *Collector) GetPoolMessages(limit int) []PoolMessage {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if limit <= 0 || limit > len(c.poolMessages) {
+		limit = len(c.poolMessages)
+	}
+
+	result := make([]PoolMessage, 0, limit)
+	for i := len(c.poolMessages) - 1; i >= 0 && len(result) < limit; i-- {
+		result = append(result, c.poolMessages[i])
+	}
+
+	return result
+}
+
 // UpdateHashes updates the total hash count
 func (c *Collector) UpdateHashes(count uint64) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.totalHashes = count
+	c.recordEffortLocked(count)
+	c.recordActivityLocked(count)
 }
 
 // GetStats returns the current statistics
 func (c *Collector) GetStats() map[string]interface{} {
+	c.flush()
+
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
@@ -277,19 +637,26 @@ func (c *Collector) GetStats() map[string]interface{} {
 	totalUptime := c.previousMiningSeconds + currentUptime
 
 	return map[string]interface{}{
-		"total_hashes":    c.totalHashes,
-		"total_shares":    c.totalShares,
-		"accepted_shares": c.acceptedShares,
-		"rejected_shares": c.rejectedShares,
-		"best_difficulty": c.bestDifficulty,
-		"uptime_seconds":  totalUptime,
-		"session_uptime":  currentUptime,
-		"start_time":      c.startTime,
+		"total_hashes":      c.totalHashes,
+		"total_shares":      c.totalShares,
+		"accepted_shares":   c.acceptedShares,
+		"rejected_shares":   c.rejectedShares,
+		"reject_categories": c.getRejectCategoriesLocked(),
+		"shares_by_origin":  c.getOriginSharesLocked(),
+		"stale_shares":      c.staleShares,
+		"dropped_shares":    c.droppedShares,
+		"worker_errors":     c.getWorkerErrorsLocked(),
+		"best_difficulty":   c.bestDifficulty,
+		"uptime_seconds":    totalUptime,
+		"session_uptime":    currentUptime,
+		"start_time":        c.startTime,
 	}
 }
 
 // GetShareHistory returns the share history
 func (c *Collector) GetShareHistory(limit int) []ShareEntry {
+	c.flush()
+
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
@@ -314,6 +681,26 @@ func (c *Collector) GetShareHistory(limit int) []ShareEntry {
 	return result
 }
 
+// GetWorkerShareHistory returns a worker's most recent shares, newest first
+func (c *Collector) GetWorkerShareHistory(workerID, limit int) []ShareEntry {
+	c.flush()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]ShareEntry, 0)
+	for i := len(c.shareHistory) - 1; i >= 0; i-- {
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+		if c.shareHistory[i].WorkerID == workerID {
+			result = append(result, c.shareHistory[i])
+		}
+	}
+
+	return result
+}
+
 // GetBlockHistory returns the block history
 func (c *Collector) GetBlockHistory(limit int) []BlockEntry {
 	c.mu.RLock()
@@ -366,6 +753,8 @@ func (c *Collector) GetSessionHistory(limit int) []Session {
 
 // GetBestDifficulty returns the best difficulty achieved
 func (c *Collector) GetBestDifficulty() float64 {
+	c.flush()
+
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	return c.bestDifficulty
@@ -373,6 +762,8 @@ func (c *Collector) GetBestDifficulty() float64 {
 
 // Reset resets all statistics
 func (c *Collector) Reset() {
+	c.flush()
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -380,9 +771,67 @@ func (c *Collector) Reset() {
 	c.totalShares = 0
 	c.acceptedShares = 0
 	c.rejectedShares = 0
+	c.rejectCategories = make(map[string]int)
+	c.originShares = make(map[string]int)
+	c.workerErrors = make(map[string]int)
+	c.staleShares = 0
+	c.droppedShares = 0
 	c.bestDifficulty = 0
 	c.previousMiningSeconds = 0
+	c.effort = 0
+	c.startEffort = 0
 	c.shareHistory = make([]ShareEntry, 0)
 	c.blockHistory = make([]BlockEntry, 0)
+	c.poolReports = make([]PoolReport, 0)
+	c.dailyActivity = make([]DayActivity, 0)
+	c.hourlyActivity = make([]HourActivity, 0)
+	c.digests = make([]Digest, 0)
+	c.candidates = make([]Candidate, 0)
 	c.startTime = time.Now()
+
+	c.histMu.Lock()
+	c.histogram = DifficultyHistogram{}
+	c.histMu.Unlock()
+
+	c.sampler.mu.Lock()
+	c.sampler.best, c.sampler.pending, c.sampler.skipped = 0, 0, 0
+	c.sampler.mu.Unlock()
+}
+
+// getRejectCategoriesLocked copies the reject category counts. Caller must
+// hold c.mu.
+func (c *Collector) getRejectCategoriesLocked() map[string]int {
+	result := make(map[string]int, len(c.rejectCategories))
+	for category, n := range c.rejectCategories {
+		result[category] = n
+	}
+	return result
+}
+
+// RecordWorkerError counts an error that stopped a worker from hashing,
+// by kind
+func (c *Collector) RecordWorkerError(kind string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.workerErrors[kind]++
+}
+
+// getWorkerErrorsLocked copies the worker error counts. Caller must hold
+// c.mu.
+func (c *Collector) getWorkerErrorsLocked() map[string]int {
+	result := make(map[string]int, len(c.workerErrors))
+	for kind, n := range c.workerErrors {
+		result[kind] = n
+	}
+	return result
+}
+
+// getOriginSharesLocked copies the found shares per origin. Caller must
+// hold c.mu.
+func (c *Collector) getOriginSharesLocked() map[string]int {
+	result := make(map[string]int, len(c.originShares))
+	for origin, n := range c.originShares {
+		result[origin] = n
+	}
+	return result
 }
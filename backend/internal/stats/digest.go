@@ -0,0 +1,180 @@
+package stats
+
+import (
+	"time"
+)
+
+// Digest periods
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// maxActivityHours covers the longest digest period plus the current hour
+const maxActivityHours = 7*24 + 1
+
+// maxDigests bounds the digest history
+const maxDigests = 100
+
+// HourActivity is the mining activity sampled over one clock hour
+type HourActivity struct {
+	Hour          time.Time `json:"hour"`
+	MiningSeconds float64   `json:"mining_seconds"`
+	Hashes        uint64    `json:"hashes"`
+	Effort        float64   `json:"effort"`
+}
+
+// Digest summarizes the last day or week of mining
+type Digest struct {
+	Period      string    `json:"period"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	GeneratedAt time.Time `json:"generated_at"`
+	// Highest difficulty share found in the period, nil if none
+	BestShare      *ShareEntry `json:"best_share"`
+	Shares         int         `json:"shares"`
+	AcceptedShares int         `json:"accepted_shares"`
+	Hashes         uint64      `json:"hashes"`
+	// Effort over the period, 100% is one block's worth of hashes
+	LuckPercent   float64 `json:"luck_percent"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
+	UptimePercent float64 `json:"uptime_percent"`
+}
+
+// digestPeriod returns the length of a digest period
+func digestPeriod(period string) (time.Duration, bool) {
+	switch period {
+	case DigestDaily:
+		return 24 * time.Hour, true
+	case DigestWeekly:
+		return 7 * 24 * time.Hour, true
+	}
+	return 0, false
+}
+
+// hourBucketLocked returns the bucket for the hour containing now,
+// starting a new one if needed. Caller must hold c.mu.
+func (c *Collector) hourBucketLocked(now time.Time) *HourActivity {
+	hour := now.Truncate(time.Hour)
+	n := len(c.hourlyActivity)
+	if n == 0 || !c.hourlyActivity[n-1].Hour.Equal(hour) {
+		c.hourlyActivity = append(c.hourlyActivity, HourActivity{Hour: hour})
+		if len(c.hourlyActivity) > maxActivityHours {
+			c.hourlyActivity = c.hourlyActivity[1:]
+		}
+		n = len(c.hourlyActivity)
+	}
+	return &c.hourlyActivity[n-1]
+}
+
+// GenerateDigest summarizes the period ending now and adds it to the
+// digest history. Share counts only see the retained share history.
+func (c *Collector) GenerateDigest(period string) (Digest, bool) {
+	length, ok := digestPeriod(period)
+	if !ok {
+		return Digest{}, false
+	}
+
+	c.flush()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	digest := c.buildDigestLocked(period, length, time.Now())
+	c.digests = append(c.digests, digest)
+	if len(c.digests) > maxDigests {
+		c.digests = c.digests[1:]
+	}
+	return digest, true
+}
+
+// GenerateDueDigests generates every digest whose period has elapsed since
+// the previous one, or since sampling began for the first
+func (c *Collector) GenerateDueDigests() []Digest {
+	var due []string
+	now := time.Now()
+
+	c.mu.RLock()
+	for _, period := range []string{DigestDaily, DigestWeekly} {
+		length, _ := digestPeriod(period)
+		since := time.Time{}
+		for i := len(c.digests) - 1; i >= 0; i-- {
+			if c.digests[i].Period == period {
+				since = c.digests[i].End
+				break
+			}
+		}
+		if since.IsZero() && len(c.hourlyActivity) > 0 {
+			since = c.hourlyActivity[0].Hour
+		}
+		if !since.IsZero() && now.Sub(since) >= length {
+			due = append(due, period)
+		}
+	}
+	c.mu.RUnlock()
+
+	digests := make([]Digest, 0, len(due))
+	for _, period := range due {
+		digest, _ := c.GenerateDigest(period)
+		digests = append(digests, digest)
+	}
+	return digests
+}
+
+// buildDigestLocked summarizes [end-length, end]. Caller must hold c.mu.
+func (c *Collector) buildDigestLocked(period string, length time.Duration, end time.Time) Digest {
+	start := end.Add(-length)
+	digest := Digest{
+		Period:      period,
+		Start:       start,
+		End:         end,
+		GeneratedAt: time.Now(),
+	}
+
+	var effort float64
+	for _, h := range c.hourlyActivity {
+		// Buckets straddling the start count in full
+		if h.Hour.Add(time.Hour).After(start) && !h.Hour.After(end) {
+			digest.Hashes += h.Hashes
+			digest.UptimeSeconds += h.MiningSeconds
+			effort += h.Effort
+		}
+	}
+	digest.LuckPercent = effort * 100
+	digest.UptimePercent = min(digest.UptimeSeconds/length.Seconds()*100, 100)
+
+	for i := range c.shareHistory {
+		share := c.shareHistory[i]
+		if share.Timestamp.Before(start) || share.Timestamp.After(end) {
+			continue
+		}
+		digest.Shares += share.Count()
+		if share.Status == ShareStatusAccepted {
+			digest.AcceptedShares += share.Count()
+		}
+		if digest.BestShare == nil || share.Difficulty > digest.BestShare.Difficulty {
+			digest.BestShare = &share
+		}
+	}
+
+	return digest
+}
+
+// GetDigests returns up to limit digests, newest first, optionally of one
+// period only
+func (c *Collector) GetDigests(period string, limit int) []Digest {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = len(c.digests)
+	}
+
+	result := make([]Digest, 0, min(limit, len(c.digests)))
+	for i := len(c.digests) - 1; i >= 0 && len(result) < limit; i-- {
+		if period == "" || c.digests[i].Period == period {
+			result = append(result, c.digests[i])
+		}
+	}
+	return result
+}
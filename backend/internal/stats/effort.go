@@ -0,0 +1,51 @@
+package stats
+
+import (
+	"time"
+
+	"github.com/soloforge/backend/internal/miner"
+)
+
+// Effort is solo-mining progress: hashes done divided by the hashes
+// expected to find a block, each hash weighed at the network difficulty
+// in force when it was done. 100% is one block's worth of luck.
+type Effort struct {
+	LifetimePercent   float64 `json:"lifetime_percent"`
+	SessionPercent    float64 `json:"session_percent"`
+	NetworkDifficulty float64 `json:"network_difficulty"`
+}
+
+// SetNetworkDifficulty sets the block difficulty new hashes count against
+func (c *Collector) SetNetworkDifficulty(difficulty float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.networkDifficulty = difficulty
+}
+
+// recordEffortLocked adds the hashes done since the previous sample to
+// the effort at the current network difficulty. Caller must hold c.mu.
+func (c *Collector) recordEffortLocked(count uint64) {
+	lastCount := c.lastEffortCount
+	c.lastEffortCount = count
+
+	// Counter went backwards (workers removed) or no job seen yet
+	if count <= lastCount || c.networkDifficulty <= 0 {
+		return
+	}
+
+	delta := float64(count-lastCount) / (c.networkDifficulty * miner.HashesPerDifficulty)
+	c.effort += delta
+	c.hourBucketLocked(time.Now()).Effort += delta
+}
+
+// GetEffort returns the lifetime and current session effort
+func (c *Collector) GetEffort() Effort {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return Effort{
+		LifetimePercent:   c.effort * 100,
+		SessionPercent:    (c.effort - c.startEffort) * 100,
+		NetworkDifficulty: c.networkDifficulty,
+	}
+}
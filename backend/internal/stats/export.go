@@ -0,0 +1,14 @@
+package stats
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Export writes every statistic to w in the JSON format of the file
+// store, so an export doubles as a stats.json backup
+func (c *Collector) Export(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(c.snapshot())
+}
@@ -0,0 +1,127 @@
+package stats
+
+import "math"
+
+// The difficulty histogram has histogramPerDecade logarithmic buckets per
+// power of ten from 10^histogramMinExp, with anything lower in the first
+// bucket and anything higher in the last
+const (
+	histogramPerDecade = 10
+	histogramMinExp    = -8
+	histogramBuckets   = 26 * histogramPerDecade
+)
+
+// rareSharePercentile is the percentile from which a share is rare
+const rareSharePercentile = 99.9
+
+// DifficultyHistogram counts every share ever found by difficulty,
+// sampled or not
+type DifficultyHistogram struct {
+	Counts []uint64 `json:"counts"`
+	Best   float64  `json:"best"`
+}
+
+// ShareRank places a share among every share found so far, itself
+// included. Rare shares are in the top 0.1%.
+type ShareRank struct {
+	Difficulty       float64 `json:"difficulty"`
+	Percentile       float64 `json:"percentile"`
+	TotalShares      uint64  `json:"total_shares"`
+	SharesAtOrAbove  uint64  `json:"shares_at_or_above"`
+	OneIn            float64 `json:"one_in"`
+	MedianDifficulty float64 `json:"median_difficulty"`
+	TimesMedian      float64 `json:"times_median"`
+	PreviousBest     float64 `json:"previous_best"`
+	Record           bool    `json:"record"`
+	Rare             bool    `json:"rare"`
+}
+
+// histogramBucket returns the bucket counting difficulty
+func histogramBucket(difficulty float64) int {
+	if difficulty <= 0 {
+		return 0
+	}
+	i := int(math.Floor((math.Log10(difficulty) - histogramMinExp) * histogramPerDecade))
+	return min(max(i, 0), histogramBuckets-1)
+}
+
+// histogramMidpoint returns the geometric middle of a bucket
+func histogramMidpoint(i int) float64 {
+	return math.Pow(10, histogramMinExp+(float64(i)+0.5)/histogramPerDecade)
+}
+
+// add counts n shares of the given difficulty
+func (h *DifficultyHistogram) add(difficulty float64, n int) {
+	if len(h.Counts) != histogramBuckets {
+		h.Counts = make([]uint64, histogramBuckets)
+	}
+	h.Counts[histogramBucket(difficulty)] += uint64(n)
+	h.Best = max(h.Best, difficulty)
+}
+
+// rank places a difficulty already counted in the histogram. Shares in
+// the same bucket count half below it.
+func (h *DifficultyHistogram) rank(difficulty float64) ShareRank {
+	bucket := histogramBucket(difficulty)
+
+	var total, below uint64
+	for i, n := range h.Counts {
+		total += n
+		if i < bucket {
+			below += n
+		}
+	}
+	if total == 0 {
+		return ShareRank{Difficulty: difficulty}
+	}
+
+	atOrAbove := total - below
+	r := ShareRank{
+		Difficulty:      difficulty,
+		Percentile:      100 * (float64(below) + float64(h.Counts[bucket])/2) / float64(total),
+		TotalShares:     total,
+		SharesAtOrAbove: atOrAbove,
+		OneIn:           float64(total) / float64(atOrAbove),
+	}
+
+	var seen uint64
+	for i, n := range h.Counts {
+		seen += n
+		if seen*2 >= total {
+			r.MedianDifficulty = histogramMidpoint(i)
+			break
+		}
+	}
+	if r.MedianDifficulty > 0 {
+		r.TimesMedian = difficulty / r.MedianDifficulty
+	}
+	r.Rare = r.Percentile >= rareSharePercentile
+	return r
+}
+
+// rankShare counts a new share in the histogram and ranks it against the
+// shares before it
+func (c *Collector) rankShare(difficulty float64) ShareRank {
+	c.histMu.Lock()
+	defer c.histMu.Unlock()
+
+	previousBest := c.histogram.Best
+	c.histogram.add(difficulty, 1)
+
+	r := c.histogram.rank(difficulty)
+	r.PreviousBest = previousBest
+	r.Record = difficulty > previousBest
+	return r
+}
+
+// getHistogram copies the difficulty histogram
+func (c *Collector) getHistogram() DifficultyHistogram {
+	c.histMu.Lock()
+	defer c.histMu.Unlock()
+
+	h := DifficultyHistogram{Best: c.histogram.Best}
+	if c.histogram.Counts != nil {
+		h.Counts = append([]uint64(nil), c.histogram.Counts...)
+	}
+	return h
+}
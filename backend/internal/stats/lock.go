@@ -0,0 +1,64 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// lockFileName is the advisory lock held on a data directory while a
+// store writes to it
+const lockFileName = ".soloforge.lock"
+
+// ErrDataDirLocked is returned when another instance holds the data
+// directory's lock
+var ErrDataDirLocked = errors.New("data directory is in use by another instance")
+
+// ErrReadOnlyStore is returned when saving to a store opened read-only
+var ErrReadOnlyStore = errors.New("store is read-only, another instance owns the data directory")
+
+// dirLock is a held data directory lock, released when the process exits
+// even if it is never closed
+type dirLock struct {
+	file *os.File
+}
+
+// lockDir takes the data directory's lock, recording our PID in it
+func lockDir(dir string) (*dirLock, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(dir, lockFileName)
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	locked, err := tryLock(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if !locked {
+		holder, _ := os.ReadFile(path)
+		file.Close()
+		if pid := strings.TrimSpace(string(holder)); pid != "" {
+			return nil, fmt.Errorf("%w (%s held by pid %s)", ErrDataDirLocked, path, pid)
+		}
+		return nil, fmt.Errorf("%w (%s)", ErrDataDirLocked, path)
+	}
+
+	file.Truncate(0)
+	file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	return &dirLock{file: file}, nil
+}
+
+// release gives up the lock
+func (l *dirLock) release() error {
+	l.file.Truncate(0)
+	return l.file.Close()
+}
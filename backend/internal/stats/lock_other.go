@@ -0,0 +1,10 @@
+//go:build !unix
+
+package stats
+
+import "os"
+
+// tryLock always succeeds, advisory locking is only available on Unix
+func tryLock(file *os.File) (bool, error) {
+	return true, nil
+}
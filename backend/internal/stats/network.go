@@ -0,0 +1,58 @@
+package stats
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/soloforge/backend/internal/miner"
+)
+
+// blockIntervalSeconds is the target time between Bitcoin blocks
+const blockIntervalSeconds = 600.0
+
+// NetworkShare is a hashrate as a fraction of the whole network's
+type NetworkShare struct {
+	Hashrate          float64 `json:"hashrate"`
+	NetworkHashrate   float64 `json:"network_hashrate"`
+	NetworkDifficulty float64 `json:"network_difficulty"`
+	// Where the network hashrate came from
+	Source string `json:"source"`
+	// Fraction of the network hashrate, e.g. 1.2e-12
+	Fraction     float64 `json:"fraction"`
+	FractionText string  `json:"fraction_text"`
+	// Odds of finding any given block, as "1 in N"
+	OneIn     float64 `json:"one_in"`
+	OneInText string  `json:"one_in_text"`
+}
+
+// NetworkHashrate estimates the network hashrate in H/s from the block
+// difficulty, assuming blocks arrive on target
+func NetworkHashrate(difficulty float64) float64 {
+	return difficulty * miner.HashesPerDifficulty / blockIntervalSeconds
+}
+
+// EstimateNetworkShare returns the share of the network a hashrate holds
+// at the current network difficulty. Zero difficulty leaves it empty.
+func (c *Collector) EstimateNetworkShare(hashrate float64) NetworkShare {
+	c.mu.RLock()
+	difficulty := c.networkDifficulty
+	c.mu.RUnlock()
+
+	share := NetworkShare{
+		Hashrate:          hashrate,
+		NetworkDifficulty: difficulty,
+		Source:            "difficulty",
+	}
+	if difficulty <= 0 {
+		return share
+	}
+
+	share.NetworkHashrate = NetworkHashrate(difficulty)
+	share.Fraction = hashrate / share.NetworkHashrate
+	share.FractionText = strconv.FormatFloat(share.Fraction, 'e', 2, 64)
+	if share.Fraction > 0 {
+		share.OneIn = 1 / share.Fraction
+		share.OneInText = fmt.Sprintf("1 in %s", strconv.FormatFloat(share.OneIn, 'e', 2, 64))
+	}
+	return share
+}
@@ -0,0 +1,142 @@
+package stats
+
+import (
+	"sort"
+	"time"
+)
+
+// maxReportMonths bounds how many monthly reports are kept per pool
+const maxReportMonths = 24
+
+// PoolReport aggregates service quality for one pool over one month
+type PoolReport struct {
+	Pool                 string    `json:"pool"`
+	Month                string    `json:"month"`
+	Submitted            int       `json:"submitted"`
+	Accepted             int       `json:"accepted"`
+	Rejected             int       `json:"rejected"`
+	Stale                int       `json:"stale"`
+	AcceptanceRate       float64   `json:"acceptance_rate"`
+	TotalLatencyMs       float64   `json:"total_latency_ms"`
+	AvgLatencyMs         float64   `json:"avg_latency_ms"`
+	Disconnects          int       `json:"disconnects"`
+	JobGapIncidents      int       `json:"job_gap_incidents"`
+	LongestJobGapSeconds float64   `json:"longest_job_gap_seconds"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+// monthKey returns the report bucket for t
+func monthKey(t time.Time) string {
+	return t.Format("2006-01")
+}
+
+// poolReportLocked returns the current month's report for a pool,
+// creating it if needed. Caller must hold c.mu.
+func (c *Collector) poolReportLocked(pool string) *PoolReport {
+	now := time.Now()
+	month := monthKey(now)
+
+	for i := range c.poolReports {
+		r := &c.poolReports[i]
+		if r.Pool == pool && r.Month == month {
+			r.UpdatedAt = now
+			return r
+		}
+	}
+
+	c.prunePoolReportsLocked(pool, maxReportMonths-1)
+	c.poolReports = append(c.poolReports, PoolReport{
+		Pool:      pool,
+		Month:     month,
+		UpdatedAt: now,
+	})
+	return &c.poolReports[len(c.poolReports)-1]
+}
+
+// prunePoolReportsLocked keeps only the newest keep months for a pool
+func (c *Collector) prunePoolReportsLocked(pool string, keep int) {
+	var months []string
+	for _, r := range c.poolReports {
+		if r.Pool == pool {
+			months = append(months, r.Month)
+		}
+	}
+	if len(months) <= keep {
+		return
+	}
+
+	sort.Strings(months)
+	cutoff := months[len(months)-keep]
+
+	kept := c.poolReports[:0]
+	for _, r := range c.poolReports {
+		if r.Pool != pool || r.Month >= cutoff {
+			kept = append(kept, r)
+		}
+	}
+	c.poolReports = kept
+}
+
+// RecordPoolSubmit records a submit verdict and its round-trip latency
+func (c *Collector) RecordPoolSubmit(pool, status string, latency time.Duration) {
+	c.bufferPoolSubmit(poolSubmit{pool: pool, status: status, latency: latency})
+}
+
+// recordPoolSubmitLocked applies a buffered submit to the pool report.
+// Caller must hold c.mu.
+func (c *Collector) recordPoolSubmitLocked(pool, status string, latency time.Duration) {
+	r := c.poolReportLocked(pool)
+	r.Submitted++
+	switch status {
+	case ShareStatusAccepted:
+		r.Accepted++
+	case ShareStatusStale:
+		r.Stale++
+	default:
+		r.Rejected++
+	}
+
+	r.TotalLatencyMs += float64(latency) / float64(time.Millisecond)
+	r.AvgLatencyMs = r.TotalLatencyMs / float64(r.Submitted)
+	r.AcceptanceRate = float64(r.Accepted) / float64(r.Submitted)
+}
+
+// RecordPoolDisconnect counts a dropped connection to a pool
+func (c *Collector) RecordPoolDisconnect(pool string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.poolReportLocked(pool).Disconnects++
+}
+
+// RecordPoolJobGap counts a period without new jobs from a pool
+func (c *Collector) RecordPoolJobGap(pool string, gap time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r := c.poolReportLocked(pool)
+	r.JobGapIncidents++
+	if gap.Seconds() > r.LongestJobGapSeconds {
+		r.LongestJobGapSeconds = gap.Seconds()
+	}
+}
+
+// GetPoolReports returns the monthly reports for a pool, newest first
+func (c *Collector) GetPoolReports(pool string) []PoolReport {
+	c.flush()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]PoolReport, 0)
+	for _, r := range c.poolReports {
+		if r.Pool == pool {
+			result = append(result, r)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Month > result[j].Month
+	})
+	return result
+}
@@ -0,0 +1,320 @@
+package stats
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// postgresMigrations are applied in order, each exactly once
+var postgresMigrations = []string{
+	`CREATE TABLE stats_snapshots (
+		instance             TEXT PRIMARY KEY,
+		total_hashes         BIGINT NOT NULL,
+		total_shares         INTEGER NOT NULL,
+		accepted_shares      INTEGER NOT NULL,
+		rejected_shares      INTEGER NOT NULL,
+		stale_shares         INTEGER NOT NULL,
+		dropped_shares       INTEGER NOT NULL,
+		best_difficulty      DOUBLE PRECISION NOT NULL,
+		total_mining_seconds DOUBLE PRECISION NOT NULL,
+		data                 JSONB NOT NULL,
+		saved_at             TIMESTAMPTZ NOT NULL
+	)`,
+	`CREATE TABLE share_history (
+		instance    TEXT NOT NULL,
+		seq         INTEGER NOT NULL,
+		found_at    TIMESTAMPTZ NOT NULL,
+		worker_id   INTEGER NOT NULL,
+		worker_name TEXT NOT NULL,
+		job_id      TEXT NOT NULL,
+		nonce       TEXT NOT NULL,
+		difficulty  DOUBLE PRECISION NOT NULL,
+		status      TEXT NOT NULL,
+		reason      TEXT NOT NULL,
+		PRIMARY KEY (instance, seq)
+	)`,
+	`CREATE TABLE session_history (
+		instance        TEXT NOT NULL,
+		seq             INTEGER NOT NULL,
+		id              TEXT NOT NULL,
+		start_time      TIMESTAMPTZ NOT NULL,
+		end_time        TIMESTAMPTZ NOT NULL,
+		duration        TEXT NOT NULL,
+		total_hashes    BIGINT NOT NULL,
+		best_difficulty DOUBLE PRECISION NOT NULL,
+		PRIMARY KEY (instance, seq)
+	)`,
+	`ALTER TABLE share_history ADD COLUMN source TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE stats_snapshots ADD COLUMN effort DOUBLE PRECISION NOT NULL DEFAULT 0`,
+	`ALTER TABLE session_history ADD COLUMN effort_percent DOUBLE PRECISION NOT NULL DEFAULT 0`,
+	`ALTER TABLE share_history ADD COLUMN reject_category TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE share_history ADD COLUMN weight INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE share_history ADD COLUMN origin TEXT NOT NULL DEFAULT '', ADD COLUMN device TEXT NOT NULL DEFAULT ''`,
+}
+
+// postgresTimeout bounds every database round trip
+const postgresTimeout = 30 * time.Second
+
+// PostgresStore keeps collector data in PostgreSQL, one row set per
+// instance so a fleet can share a database
+type PostgresStore struct {
+	db       *sql.DB
+	instance string
+}
+
+// NewPostgresStore connects to dsn, configures the connection pool and
+// applies pending migrations
+func NewPostgresStore(dsn, instance string) (*PostgresStore, error) {
+	if instance == "" {
+		instance = "default"
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres: %w", err)
+	}
+
+	// Saves are periodic, a small pool is plenty per dashboard
+	db.SetMaxOpenConns(4)
+	db.SetMaxIdleConns(2)
+	db.SetConnMaxLifetime(30 * time.Minute)
+	db.SetConnMaxIdleTime(5 * time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), postgresTimeout)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+
+	s := &PostgresStore{db: db, instance: instance}
+	if err := s.migrate(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// migrate applies the migrations not yet recorded in schema_migrations
+func (s *PostgresStore) migrate(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	for i := current; i < len(postgresMigrations); i++ {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, postgresMigrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d failed: %w", i+1, err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, i+1); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d failed: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d failed: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// Load reads the instance's snapshot with its share and session history
+func (s *PostgresStore) Load() (*PersistentData, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), postgresTimeout)
+	defer cancel()
+
+	var data PersistentData
+	var raw []byte
+	var totalHashes int64
+	err := s.db.QueryRowContext(ctx, `SELECT total_hashes, total_shares, accepted_shares, rejected_shares,
+		stale_shares, dropped_shares, best_difficulty, total_mining_seconds, effort, data, saved_at
+		FROM stats_snapshots WHERE instance = $1`, s.instance).Scan(
+		&totalHashes, &data.TotalShares, &data.AcceptedShares, &data.RejectedShares,
+		&data.StaleShares, &data.DroppedShares, &data.BestDifficulty, &data.TotalMiningSeconds,
+		&data.Effort, &raw, &data.LastSaved)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	data.TotalHashes = uint64(totalHashes)
+
+	// The remaining history is kept as a document
+	var rest PersistentData
+	if err := json.Unmarshal(raw, &rest); err != nil {
+		return nil, err
+	}
+	data.RejectCategories = rest.RejectCategories
+	data.OriginShares = rest.OriginShares
+	data.BlockHistory = rest.BlockHistory
+	data.PoolReports = rest.PoolReports
+	data.DailyActivity = rest.DailyActivity
+	data.HourlyActivity = rest.HourlyActivity
+	data.Digests = rest.Digests
+	data.PoolMessages = rest.PoolMessages
+	data.AuditLog = rest.AuditLog
+	data.Candidates = rest.Candidates
+	data.DifficultyHistogram = rest.DifficultyHistogram
+
+	rows, err := s.db.QueryContext(ctx, `SELECT found_at, worker_id, worker_name, job_id, nonce, difficulty, status, reject_category, reason, source, weight, origin, device
+		FROM share_history WHERE instance = $1 ORDER BY seq`, s.instance)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e ShareEntry
+		if err := rows.Scan(&e.Timestamp, &e.WorkerID, &e.WorkerName, &e.JobID, &e.Nonce, &e.Difficulty, &e.Status, &e.RejectCategory, &e.Reason, &e.Source, &e.Weight, &e.Origin, &e.Device); err != nil {
+			return nil, err
+		}
+		e.Accepted = e.Status == ShareStatusAccepted
+		data.ShareHistory = append(data.ShareHistory, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.QueryContext(ctx, `SELECT id, start_time, end_time, duration, total_hashes, best_difficulty, effort_percent
+		FROM session_history WHERE instance = $1 ORDER BY seq`, s.instance)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sess Session
+		var hashes int64
+		if err := rows.Scan(&sess.ID, &sess.StartTime, &sess.EndTime, &sess.Duration, &hashes, &sess.BestDifficulty, &sess.EffortPercent); err != nil {
+			return nil, err
+		}
+		sess.TotalHashes = uint64(hashes)
+		data.SessionHistory = append(data.SessionHistory, sess)
+	}
+
+	return &data, rows.Err()
+}
+
+// Save replaces the instance's snapshot and history in one transaction
+func (s *PostgresStore) Save(data *PersistentData) error {
+	ctx, cancel := context.WithTimeout(context.Background(), postgresTimeout)
+	defer cancel()
+
+	raw, err := json.Marshal(PersistentData{
+		RejectCategories:    data.RejectCategories,
+		OriginShares:        data.OriginShares,
+		BlockHistory:        data.BlockHistory,
+		PoolReports:         data.PoolReports,
+		DailyActivity:       data.DailyActivity,
+		HourlyActivity:      data.HourlyActivity,
+		Digests:             data.Digests,
+		PoolMessages:        data.PoolMessages,
+		AuditLog:            data.AuditLog,
+		Candidates:          data.Candidates,
+		DifficultyHistogram: data.DifficultyHistogram,
+	})
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO stats_snapshots (instance, total_hashes, total_shares,
+		accepted_shares, rejected_shares, stale_shares, dropped_shares, best_difficulty,
+		total_mining_seconds, effort, data, saved_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (instance) DO UPDATE SET
+			total_hashes = EXCLUDED.total_hashes,
+			total_shares = EXCLUDED.total_shares,
+			accepted_shares = EXCLUDED.accepted_shares,
+			rejected_shares = EXCLUDED.rejected_shares,
+			stale_shares = EXCLUDED.stale_shares,
+			dropped_shares = EXCLUDED.dropped_shares,
+			best_difficulty = EXCLUDED.best_difficulty,
+			total_mining_seconds = EXCLUDED.total_mining_seconds,
+			effort = EXCLUDED.effort,
+			data = EXCLUDED.data,
+			saved_at = EXCLUDED.saved_at`,
+		s.instance, int64(data.TotalHashes), data.TotalShares, data.AcceptedShares,
+		data.RejectedShares, data.StaleShares, data.DroppedShares, data.BestDifficulty,
+		data.TotalMiningSeconds, data.Effort, raw, data.LastSaved); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM share_history WHERE instance = $1`, s.instance); err != nil {
+		return err
+	}
+	if err := copyRows(ctx, tx, "share_history", []string{"instance", "seq", "found_at", "worker_id",
+		"worker_name", "job_id", "nonce", "difficulty", "status", "reject_category", "reason", "source", "weight", "origin", "device"}, len(data.ShareHistory),
+		func(i int) []interface{} {
+			e := data.ShareHistory[i]
+			return []interface{}{s.instance, i, e.Timestamp, e.WorkerID, e.WorkerName, e.JobID, e.Nonce, e.Difficulty, e.Status, e.RejectCategory, e.Reason, e.Source, e.Weight, e.Origin, e.Device}
+		}); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM session_history WHERE instance = $1`, s.instance); err != nil {
+		return err
+	}
+	if err := copyRows(ctx, tx, "session_history", []string{"instance", "seq", "id", "start_time",
+		"end_time", "duration", "total_hashes", "best_difficulty", "effort_percent"}, len(data.SessionHistory),
+		func(i int) []interface{} {
+			sess := data.SessionHistory[i]
+			return []interface{}{s.instance, i, sess.ID, sess.StartTime, sess.EndTime, sess.Duration, int64(sess.TotalHashes), sess.BestDifficulty, sess.EffortPercent}
+		}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// copyRows bulk inserts n rows into table with COPY
+func copyRows(ctx context.Context, tx *sql.Tx, table string, columns []string, n int, row func(int) []interface{}) error {
+	if n == 0 {
+		return nil
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(table, columns...))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i := 0; i < n; i++ {
+		if _, err := stmt.ExecContext(ctx, row(i)...); err != nil {
+			return err
+		}
+	}
+
+	// An empty exec flushes the COPY buffer
+	_, err = stmt.ExecContext(ctx)
+	return err
+}
+
+// Close closes the connection pool
+func (s *PostgresStore) Close() error {
+	return s.db.Close()
+}
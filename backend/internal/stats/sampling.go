@@ -0,0 +1,116 @@
+package stats
+
+import (
+	"sync"
+	"time"
+)
+
+// ShareSampling describes share bookkeeping sampling under load. Counters
+// stay exact, only the history keeps 1 in OneIn shares, each weighted by
+// the shares it stands for.
+type ShareSampling struct {
+	Threshold float64 `json:"threshold"`
+	OneIn     int     `json:"one_in"`
+	Active    bool    `json:"active"`
+	Rate      float64 `json:"rate"`
+	Skipped   uint64  `json:"skipped"`
+}
+
+// shareSampler decides which shares make it into the history
+type shareSampler struct {
+	mu sync.Mutex
+
+	// Shares per second above which sampling starts, 0 disables it
+	threshold float64
+	oneIn     int
+
+	// Share rate over the last full second
+	windowStart time.Time
+	windowCount int
+	rate        float64
+
+	// Highest difficulty seen, new bests are always recorded
+	best float64
+
+	// Shares skipped since the last recorded one, and in total
+	pending int
+	skipped uint64
+}
+
+// sample reports whether a share is recorded and, if so, how many shares
+// its entry stands for
+func (s *shareSampler) sample(difficulty float64) (bool, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.windowStart.IsZero() {
+		s.windowStart = now
+	}
+	s.windowCount++
+	if elapsed := now.Sub(s.windowStart); elapsed >= time.Second {
+		s.rate = float64(s.windowCount) / elapsed.Seconds()
+		s.windowStart, s.windowCount = now, 0
+	}
+
+	if difficulty > s.best || !s.activeLocked() {
+		s.best = max(s.best, difficulty)
+		weight := s.pending + 1
+		s.pending = 0
+		return true, weight
+	}
+
+	// The oneIn-th low-difficulty share stands for the ones skipped
+	s.pending++
+	if s.pending < s.oneIn {
+		s.skipped++
+		return false, 0
+	}
+	weight := s.pending
+	s.pending = 0
+	return true, weight
+}
+
+// activeLocked reports whether the share rate is over the threshold,
+// counting the current second as soon as it alone exceeds it. Caller must
+// hold s.mu.
+func (s *shareSampler) activeLocked() bool {
+	if s.threshold <= 0 || s.oneIn <= 1 {
+		return false
+	}
+	return s.rate > s.threshold || float64(s.windowCount) > s.threshold
+}
+
+// SetShareSampling records only 1 in oneIn low-difficulty shares while
+// more than threshold shares per second arrive, 0 disables sampling
+func (c *Collector) SetShareSampling(threshold float64, oneIn int) {
+	c.sampler.mu.Lock()
+	defer c.sampler.mu.Unlock()
+	c.sampler.threshold = threshold
+	c.sampler.oneIn = oneIn
+}
+
+// GetShareSampling returns the sampling settings and current state
+func (c *Collector) GetShareSampling() ShareSampling {
+	c.sampler.mu.Lock()
+	defer c.sampler.mu.Unlock()
+
+	// No shares for a while means no load, whatever the last rate was
+	idle := time.Since(c.sampler.windowStart) > 2*time.Second
+	rate := c.sampler.rate
+	if idle {
+		rate = 0
+	}
+	return ShareSampling{
+		Threshold: c.sampler.threshold,
+		OneIn:     c.sampler.oneIn,
+		Active:    c.sampler.activeLocked() && !idle,
+		Rate:      rate,
+		Skipped:   c.sampler.skipped,
+	}
+}
+
+// IsSampling reports whether share bookkeeping is currently sampled
+func (c *Collector) IsSampling() bool {
+	return c.GetShareSampling().Active
+}
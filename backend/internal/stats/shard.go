@@ -0,0 +1,176 @@
+package stats
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// numShards spreads share-path updates so concurrent devices rarely
+// contend on the same lock
+const numShards = 16
+
+// shardFlushSize triggers an early aggregation when a shard fills up
+const shardFlushSize = 1024
+
+// aggregateInterval is how often shard buffers are merged
+const aggregateInterval = 250 * time.Millisecond
+
+// verdict is a pool answer, or a local drop, for a pending share
+type verdict struct {
+	jobID    string
+	nonce    string
+	status   string
+	category string
+	reason   string
+}
+
+// poolSubmit is a verdict counted towards a pool's monthly report
+type poolSubmit struct {
+	pool    string
+	status  string
+	latency time.Duration
+}
+
+// shard buffers share-path updates under its own lock until they are
+// merged into the collector
+type shard struct {
+	mu          sync.Mutex
+	shares      []ShareEntry
+	verdicts    []verdict
+	poolSubmits []poolSubmit
+}
+
+// shardFor returns the shard owning a worker or device. Proxied devices
+// share worker ID 0, so the name is part of the key.
+func (c *Collector) shardFor(workerID int, workerName string) *shard {
+	h := fnv.New32a()
+	h.Write([]byte(strconv.Itoa(workerID)))
+	h.Write([]byte(workerName))
+	return &c.shards[h.Sum32()%numShards]
+}
+
+// nextShard spreads updates that have no worker across shards
+func (c *Collector) nextShard() *shard {
+	return &c.shards[c.shardCursor.Add(1)%numShards]
+}
+
+// bufferShare queues a new share in its worker's shard
+func (c *Collector) bufferShare(entry ShareEntry) {
+	s := c.shardFor(entry.WorkerID, entry.WorkerName)
+	s.mu.Lock()
+	s.shares = append(s.shares, entry)
+	full := len(s.shares) >= shardFlushSize
+	s.mu.Unlock()
+
+	if full {
+		c.flush()
+	}
+}
+
+// bufferVerdict queues a share verdict
+func (c *Collector) bufferVerdict(v verdict) {
+	s := c.nextShard()
+	s.mu.Lock()
+	s.verdicts = append(s.verdicts, v)
+	full := len(s.verdicts) >= shardFlushSize
+	s.mu.Unlock()
+
+	if full {
+		c.flush()
+	}
+}
+
+// bufferPoolSubmit queues a verdict for the pool report
+func (c *Collector) bufferPoolSubmit(p poolSubmit) {
+	s := c.nextShard()
+	s.mu.Lock()
+	s.poolSubmits = append(s.poolSubmits, p)
+	full := len(s.poolSubmits) >= shardFlushSize
+	s.mu.Unlock()
+
+	if full {
+		c.flush()
+	}
+}
+
+// flush merges every shard's buffered updates into the collector. Must
+// not be called with c.mu held.
+func (c *Collector) flush() {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	// Verdicts are taken before shares: a verdict always follows its
+	// share, so its share is either taken below or already merged
+	var verdicts []verdict
+	var poolSubmits []poolSubmit
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		verdicts = append(verdicts, s.verdicts...)
+		poolSubmits = append(poolSubmits, s.poolSubmits...)
+		s.verdicts, s.poolSubmits = nil, nil
+		s.mu.Unlock()
+	}
+
+	var shares []ShareEntry
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		shares = append(shares, s.shares...)
+		s.shares = nil
+		s.mu.Unlock()
+	}
+
+	skipped := int(c.skippedShares.Swap(0))
+
+	if len(shares) == 0 && len(verdicts) == 0 && len(poolSubmits) == 0 && skipped == 0 {
+		return
+	}
+
+	// Shards interleave devices, restore the order shares were found in
+	sort.SliceStable(shares, func(i, j int) bool {
+		return shares[i].Timestamp.Before(shares[j].Timestamp)
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.totalShares += skipped
+	for _, entry := range shares {
+		c.shareHistory = append(c.shareHistory, entry)
+		c.totalShares++
+		c.originShares[entry.Origin] += entry.Count()
+		if entry.Difficulty > c.bestDifficulty {
+			c.bestDifficulty = entry.Difficulty
+		}
+	}
+	for _, v := range verdicts {
+		c.applyVerdictLocked(v)
+	}
+	for _, p := range poolSubmits {
+		c.recordPoolSubmitLocked(p.pool, p.status, p.latency)
+	}
+
+	// Trim after verdicts so pending shares in this batch get theirs
+	if len(c.shareHistory) > c.maxHistorySize {
+		c.shareHistory = c.shareHistory[len(c.shareHistory)-c.maxHistorySize:]
+	}
+}
+
+// aggregateLoop merges shard buffers periodically until stop is closed
+func (c *Collector) aggregateLoop(stop chan struct{}) {
+	ticker := time.NewTicker(aggregateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			c.flush()
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// Store persists collector data between runs
+type Store interface {
+	// Load returns the saved data, or nil if nothing was saved yet
+	Load() (*PersistentData, error)
+	// Save replaces the saved data
+	Save(data *PersistentData) error
+	// Close releases the store's resources
+	Close() error
+}
+
+// Storage drivers selectable from config
+const (
+	StoreDriverFile     = "file"
+	StoreDriverPostgres = "postgres"
+)
+
+// OpenStore opens the store for a configured driver. The file driver
+// treats dsn as the data directory and locks it against other instances,
+// opening it read-only instead of failing if readOnlyFallback is set;
+// postgres keys its rows by instance so several dashboards can share one
+// database.
+func OpenStore(driver, dsn, instance string, readOnlyFallback bool) (Store, error) {
+	switch driver {
+	case "", StoreDriverFile:
+		if dsn == "" {
+			dsn = "/app/data"
+		}
+		return OpenFileStore(dsn, "stats.json", readOnlyFallback)
+	case StoreDriverPostgres:
+		return NewPostgresStore(dsn, instance)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", driver)
+	}
+}
+
+// FileStore keeps collector data in a JSON file
+type FileStore struct {
+	dataDir  string
+	dataFile string
+
+	// Held data directory lock, nil for unlocked or read-only stores
+	lock     *dirLock
+	readOnly bool
+}
+
+// NewFileStore creates a store writing dataFile inside dataDir
+func NewFileStore(dataDir, dataFile string) *FileStore {
+	return &FileStore{
+		dataDir:  dataDir,
+		dataFile: dataFile,
+	}
+}
+
+// OpenFileStore creates a store writing dataFile inside dataDir, holding
+// the directory's lock until closed. If another instance holds it, the
+// store is opened read-only when readOnlyFallback is set and
+// ErrDataDirLocked is returned otherwise.
+func OpenFileStore(dataDir, dataFile string, readOnlyFallback bool) (*FileStore, error) {
+	s := NewFileStore(dataDir, dataFile)
+
+	lock, err := lockDir(dataDir)
+	if err != nil {
+		if !errors.Is(err, ErrDataDirLocked) || !readOnlyFallback {
+			return nil, err
+		}
+		log.Printf("Opening stats read-only: %v", err)
+		s.readOnly = true
+		return s, nil
+	}
+	s.lock = lock
+	return s, nil
+}
+
+// ReadOnly reports whether the store refuses to save
+func (s *FileStore) ReadOnly() bool {
+	return s.readOnly
+}
+
+// Load reads the JSON file
+func (s *FileStore) Load() (*PersistentData, error) {
+	file, err := os.Open(filepath.Join(s.dataDir, s.dataFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var data PersistentData
+	if err := json.NewDecoder(file).Decode(&data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// Save writes the JSON file
+func (s *FileStore) Save(data *PersistentData) error {
+	if s.readOnly {
+		return ErrReadOnlyStore
+	}
+
+	// Ensure data directory exists
+	if err := os.MkdirAll(s.dataDir, 0755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(filepath.Join(s.dataDir, s.dataFile))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
+
+// Close releases the data directory lock
+func (s *FileStore) Close() error {
+	if s.lock == nil {
+		return nil
+	}
+	err := s.lock.release()
+	s.lock = nil
+	return err
+}
@@ -2,10 +2,16 @@ package stratum
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"log"
 	"net"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -19,11 +25,36 @@ type Request struct {
 
 // Response represents a Stratum JSON-RPC response
 type Response struct {
-	ID     int             `json:"id"`
+	ID     MessageID       `json:"id"`
 	Result json.RawMessage `json:"result"`
 	Error  interface{}     `json:"error"`
 }
 
+// MessageID is a response ID. We send numbers, but some pools echo them
+// back as strings, so numeric strings are accepted too. Null or any other
+// ID is 0, which matches no request.
+type MessageID int
+
+// UnmarshalJSON accepts numbers, numeric strings and null
+func (id *MessageID) UnmarshalJSON(data []byte) error {
+	*id = 0
+
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case float64:
+		*id = MessageID(v)
+	case string:
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			*id = MessageID(n)
+		}
+	}
+	return nil
+}
+
 // Notification represents a Stratum notification (no ID)
 type Notification struct {
 	Method string          `json:"method"`
@@ -41,8 +72,30 @@ type Job struct {
 	NBits        string   `json:"nbits"`
 	NTime        string   `json:"ntime"`
 	CleanJobs    bool     `json:"clean_jobs"`
+	// When the mining.notify arrived, for job latency tracking
+	ReceivedAt time.Time `json:"-"`
+}
+
+// SubmitResult holds the pool's verdict on a submitted share
+type SubmitResult struct {
+	RequestID   int           `json:"request_id"`
+	Pool        string        `json:"pool"`
+	JobID       string        `json:"job_id"`
+	Extranonce2 string        `json:"extranonce2"`
+	NTime       string        `json:"ntime"`
+	Nonce       string        `json:"nonce"`
+	Accepted    bool          `json:"accepted"`
+	Stale       bool          `json:"stale"`
+	Category    string        `json:"category,omitempty"`
+	Reason      string        `json:"reason,omitempty"`
+	SubmittedAt time.Time     `json:"submitted_at"`
+	Latency     time.Duration `json:"latency"`
 }
 
+// ErrStaleSession is returned when a share was mined for an extranonce1
+// from an earlier session and would only be rejected by the pool
+var ErrStaleSession = errors.New("share belongs to a previous session")
+
 // Client manages the Stratum protocol connection to a mining pool
 type Client struct {
 	mu sync.RWMutex
@@ -52,6 +105,39 @@ type Client struct {
 
 	poolURL  string
 	poolPort int
+	proxyURL string
+
+	// Timeout, keepalive and socket options for pool connections
+	dialOptions DialOptions
+
+	// Last address that accepted a connection, per pool host
+	lastAddrs map[string]string
+
+	// Dead-connection detection, zero disables it
+	idleTimeout time.Duration
+
+	// No-job watchdog timeout, zero disables it
+	noJobTimeout time.Duration
+
+	// Failover state
+	pools             []Pool
+	poolIndex         int
+	dialFailures      int
+	failoverThreshold int
+	failbackInterval  time.Duration
+	failbackStop      chan struct{}
+	connectedPool     Pool
+
+	// Warm standby connection to the next pool, opt-in
+	standbyEnabled bool
+	standby        *Client
+	standbyStop    chan struct{}
+	standbyErr     string
+	handover       bool
+
+	// Credentials, reused to authorize the standby connection
+	wallet   string
+	password string
 
 	// Subscription data
 	extranonce1     string
@@ -59,8 +145,46 @@ type Client struct {
 	subscribed      bool
 	authorized      bool
 
-	// Current job
-	currentJob *Job
+	// Session ID from the last subscribe, offered again on reconnect to the
+	// same pool so it can restore our extranonce1
+	sessionID      string
+	sessionPool    Pool
+	sessionEpoch   uint64
+	sessionResumed bool
+
+	// Bumped whenever extranonce1 may change, shares carry the epoch
+	// they were mined in so stale ones can be dropped before submitting
+	epoch uint64
+
+	// Share difficulty set by the pool, zero until the first set_difficulty
+	difficulty float64
+
+	// Header version bits the pool lets us roll, zero if not negotiated
+	versionMask uint32
+
+	// Current job, expired after jobExpiry without a new one
+	currentJob    *Job
+	jobReceivedAt time.Time
+	jobExpiry     time.Duration
+
+	// When the current connection was made, and whether it sent a job
+	connectedAt time.Time
+	connJob     bool
+
+	// Fingerprint and epoch of the last job handed out, so identical
+	// re-broadcasts don't interrupt the workers
+	jobHash  uint64
+	jobEpoch uint64
+
+	// mining.submit frame prefix for the current job, see SubmitBlock
+	submitTemplate *submitTemplate
+
+	// Why the client closed its own connection, reported on disconnect
+	dropErr error
+
+	// Shares found while the pool was unreachable, see flushSubmitQueue
+	submitQueue []queuedSubmit
+	queueStats  SubmitQueueStats
 
 	// Callbacks
 	onJobReceived  func(*Job)
@@ -68,22 +192,60 @@ type Client struct {
 	onDisconnected func(error)
 	onSubscribed   func(string, int)
 	onAuthorized   func(bool)
+	onSubmitResult func(*SubmitResult)
+	onPoolSwitch   func(from, to Pool, reason string)
+	onExtranonce   func(string, int)
+	onReconnect    func(host string, port int, wait time.Duration)
+	onShowMessage  func(string)
+	onVersionMask  func(uint32)
+	onDifficulty   func(float64)
+	onQueuedSubmit func(jobID, nonce, outcome, reason string)
+	onNoJob        func(pool Pool, silent time.Duration)
+
+	// ID of the API request that started this session, tagged on frames
+	correlationID string
+
+	// Recent protocol frames for debugging
+	trace *frameTrace
+
+	// Traffic and reconnect counters
+	counters *connCounters
+
+	// Job quality metrics per pool, shared with the standby
+	jobStats *jobTracker
 
-	// State
+	// Callbacks from the read loop run on the queue's goroutine
+	events *eventQueue
+
+	// State. Each connection gets its own read loop, cancelled by cancel
+	// and closing loopDone when it exits.
 	requestID int
-	shutdown  chan struct{}
 	running   bool
+	cancel    context.CancelFunc
+	loopDone  chan struct{}
 
 	// Map to store pending requests and their response channels
-	pendingRequests sync.Map // map[int]chan Response
+	pendingRequests sync.Map // map[int]string request method
+
+	// Submitted shares awaiting a pool response, keyed by request ID
+	pendingSubmits map[int]*SubmitResult
 }
 
 // NewClient creates a new Stratum client
 func NewClient(poolURL string, poolPort int) *Client {
 	return &Client{
-		poolURL:  poolURL,
-		poolPort: poolPort,
-		shutdown: make(chan struct{}),
+		poolURL:           poolURL,
+		poolPort:          poolPort,
+		pools:             []Pool{{Name: "primary", URL: poolURL, Port: poolPort}},
+		failoverThreshold: 3,
+		failbackInterval:  time.Minute,
+		pendingSubmits:    make(map[int]*SubmitResult),
+		trace:             newFrameTrace(),
+		counters:          newConnCounters(),
+		jobStats:          newJobTracker(),
+		events:            &eventQueue{},
+		dialOptions:       DefaultDialOptions(),
+		lastAddrs:         make(map[string]string),
 	}
 }
 
@@ -122,26 +284,92 @@ func (c *Client) SetAuthorizedCallback(cb func(bool)) {
 	c.onAuthorized = cb
 }
 
-// Connect establishes a connection to the pool
+// SetExtranonceCallback sets the callback for mid-session extranonce changes
+func (c *Client) SetExtranonceCallback(cb func(string, int)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onExtranonce = cb
+}
+
+// SetReconnectCallback sets the callback for pool-requested reconnects
+func (c *Client) SetReconnectCallback(cb func(host string, port int, wait time.Duration)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onReconnect = cb
+}
+
+// SetShowMessageCallback sets the callback for client.show_message notices
+func (c *Client) SetShowMessageCallback(cb func(string)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onShowMessage = cb
+}
+
+// SetDifficultyCallback sets the callback for mining.set_difficulty
+func (c *Client) SetDifficultyCallback(cb func(float64)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onDifficulty = cb
+}
+
+// SetSubmitResultCallback sets the callback for share submission results
+func (c *Client) SetSubmitResultCallback(cb func(*SubmitResult)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onSubmitResult = cb
+}
+
+// SetCorrelationID tags subsequent protocol frames with the ID of the API
+// request that caused them. An empty ID removes the tag.
+func (c *Client) SetCorrelationID(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.correlationID = id
+}
+
+// Connect establishes a connection to the pool. A previous connection is
+// closed first, so connect, close and connect again is safe.
 func (c *Client) Connect() error {
-	addr := fmt.Sprintf("%s:%d", c.poolURL, c.poolPort)
-	dialer := net.Dialer{
-		Timeout:   30 * time.Second,
-		KeepAlive: 30 * time.Second,
-	}
-	conn, err := dialer.Dial("tcp", addr)
+	c.stopLoop()
+
+	c.mu.RLock()
+	addr := net.JoinHostPort(c.poolURL, strconv.Itoa(c.poolPort))
+	c.mu.RUnlock()
+
+	conn, err := c.dial(addr, 0)
 	if err != nil {
+		c.recordDialFailure()
 		return fmt.Errorf("failed to connect to pool: %w", err)
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+
 	c.mu.Lock()
 	c.conn = conn
 	c.reader = bufio.NewReader(conn)
 	c.running = true
-	c.shutdown = make(chan struct{}) // Reinitialize for reconnection
+	c.cancel = cancel
+	c.handover = false
+	c.subscribed = false
+	c.authorized = false
+	c.connectedAt = time.Now()
+	c.connJob = false
+	c.pendingSubmits = make(map[int]*SubmitResult)
+	c.dialFailures = 0
+	c.connectedPool = c.pools[c.poolIndex]
+	c.epoch++
+	c.versionMask = 0
+	c.difficulty = 0
+	c.dropErr = nil
+	c.loopDone = make(chan struct{})
+	done := c.loopDone
+	c.startFailbackLocked()
+	c.startStandbyLocked()
 	c.mu.Unlock()
 
-	go c.readLoop()
+	c.recordConnect()
+	go c.readLoop(ctx, done)
+	go c.watchdog(ctx, done)
 
 	if c.onConnected != nil {
 		c.onConnected()
@@ -155,8 +383,9 @@ func (c *Client) Subscribe() error {
 	req := Request{
 		ID:     c.nextID(),
 		Method: "mining.subscribe",
-		Params: []interface{}{},
+		Params: c.subscribeParams(),
 	}
+	c.pendingRequests.Store(req.ID, req.Method)
 
 	if err := c.send(req); err != nil {
 		return err
@@ -166,30 +395,121 @@ func (c *Client) Subscribe() error {
 	return nil
 }
 
+// ExtranonceSubscribe asks the pool to notify extranonce changes via
+// mining.set_extranonce instead of reconnecting
+func (c *Client) ExtranonceSubscribe() error {
+	req := Request{
+		ID:     c.nextID(),
+		Method: "mining.extranonce.subscribe",
+		Params: []interface{}{},
+	}
+	c.pendingRequests.Store(req.ID, req.Method)
+
+	return c.send(req)
+}
+
 // Authorize sends the mining.authorize message
 func (c *Client) Authorize(walletAddress, password string) error {
 	if password == "" {
 		password = "x"
 	}
 
+	c.mu.Lock()
+	c.wallet = walletAddress
+	c.password = password
+	c.mu.Unlock()
+
 	req := Request{
 		ID:     c.nextID(),
 		Method: "mining.authorize",
 		Params: []interface{}{walletAddress, password},
 	}
+	c.pendingRequests.Store(req.ID, req.Method)
 
 	return c.send(req)
 }
 
-// Submit submits a share to the pool
+// SuggestDifficulty asks the pool for a share difficulty, typically
+// lower than the pool default for low-hashrate miners
+func (c *Client) SuggestDifficulty(difficulty float64) error {
+	req := Request{
+		ID:     c.nextID(),
+		Method: "mining.suggest_difficulty",
+		Params: []interface{}{difficulty},
+	}
+	c.pendingRequests.Store(req.ID, req.Method)
+
+	return c.send(req)
+}
+
+// Submit submits a share for the current session to the pool
 func (c *Client) Submit(walletAddress, jobID, extranonce2, ntime, nonce string) error {
+	return c.SubmitForEpoch(c.SessionEpoch(), walletAddress, jobID, extranonce2, ntime, nonce, "")
+}
+
+// SubmitForEpoch submits a share mined in the given session epoch. Shares
+// from an earlier epoch are not sent and ErrStaleSession is returned.
+// A non-empty versionBits is sent as the BIP 310 rolled version field.
+// A share that can't be sent is queued for the next connection and
+// ErrSubmitQueued is returned.
+func (c *Client) SubmitForEpoch(epoch uint64, walletAddress, jobID, extranonce2, ntime, nonce, versionBits string) error {
+	params := []interface{}{walletAddress, jobID, extranonce2, ntime, nonce}
+	if versionBits != "" {
+		params = append(params, versionBits)
+	}
+
 	req := Request{
 		ID:     c.nextID(),
 		Method: "mining.submit",
-		Params: []interface{}{walletAddress, jobID, extranonce2, ntime, nonce},
+		Params: params,
 	}
 
-	return c.send(req)
+	// Register before sending so a fast response can't beat us
+	c.mu.Lock()
+	if epoch != c.epoch {
+		c.mu.Unlock()
+		return ErrStaleSession
+	}
+	if c.jobStaleLocked() {
+		c.mu.Unlock()
+		return ErrStaleJob
+	}
+	q := queuedSubmit{
+		epoch:       epoch,
+		wallet:      walletAddress,
+		jobID:       jobID,
+		extranonce2: extranonce2,
+		ntime:       ntime,
+		nonce:       nonce,
+		versionBits: versionBits,
+	}
+	// A socket the pool already dropped may still take a write
+	if !c.running || c.conn == nil {
+		c.queueSubmitLocked(q)
+		c.mu.Unlock()
+		return ErrSubmitQueued
+	}
+	c.pendingSubmits[req.ID] = &SubmitResult{
+		RequestID:   req.ID,
+		Pool:        c.connectedPool.Name,
+		JobID:       jobID,
+		Extranonce2: extranonce2,
+		NTime:       ntime,
+		Nonce:       nonce,
+		SubmittedAt: time.Now(),
+	}
+	c.mu.Unlock()
+
+	if err := c.send(req); err != nil {
+		c.mu.Lock()
+		delete(c.pendingSubmits, req.ID)
+		c.queueSubmitLocked(q)
+		c.mu.Unlock()
+		log.Printf("Share submit failed, queued: %v", err)
+		return ErrSubmitQueued
+	}
+
+	return nil
 }
 
 // GetExtranonce1 returns the extranonce1 value
@@ -199,6 +519,14 @@ func (c *Client) GetExtranonce1() string {
 	return c.extranonce1
 }
 
+// SessionEpoch returns the epoch of the current extranonce1, which changes
+// on every connection and on mining.set_extranonce
+func (c *Client) SessionEpoch() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.epoch
+}
+
 // GetExtranonce2Size returns the extranonce2 size
 func (c *Client) GetExtranonce2Size() int {
 	c.mu.RLock()
@@ -206,6 +534,13 @@ func (c *Client) GetExtranonce2Size() int {
 	return c.extranonce2Size
 }
 
+// GetDifficulty returns the share difficulty set by the pool
+func (c *Client) GetDifficulty() float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.difficulty
+}
+
 // GetCurrentJob returns the current mining job
 func (c *Client) GetCurrentJob() *Job {
 	c.mu.RLock()
@@ -227,18 +562,52 @@ func (c *Client) IsAuthorized() bool {
 	return c.authorized
 }
 
-// Close closes the connection
+// Close disconnects from the pool and stops the failback probe and the
+// standby. It may be called more than once, and Connect works again
+// afterwards. No disconnect callback is made for a deliberate close.
 func (c *Client) Close() error {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	if c.failbackStop != nil {
+		close(c.failbackStop)
+		c.failbackStop = nil
+	}
+	if c.standbyStop != nil {
+		close(c.standbyStop)
+		c.standbyStop = nil
+	}
+	sb := c.standby
+	c.standby = nil
+	c.mu.Unlock()
+
+	if sb != nil {
+		sb.Close()
+	}
+	return c.stopLoop()
+}
 
+// stopLoop closes the current connection and waits for its read loop to
+// exit, so nothing from it can touch the next connection
+func (c *Client) stopLoop() error {
+	c.mu.Lock()
+	cancel, conn, done := c.cancel, c.conn, c.loopDone
+	c.cancel = nil
+	c.conn = nil
 	c.running = false
-	close(c.shutdown)
+	c.subscribed = false
+	c.authorized = false
+	c.mu.Unlock()
 
-	if c.conn != nil {
-		return c.conn.Close()
+	if cancel != nil {
+		cancel()
 	}
-	return nil
+	var err error
+	if conn != nil {
+		err = conn.Close()
+	}
+	if done != nil {
+		<-done
+	}
+	return err
 }
 
 // send writes a request to the connection
@@ -258,8 +627,7 @@ func (c *Client) send(req Request) error {
 
 	data = append(data, '\n')
 
-	// LOG VERBOSE pour debug
-	log.Printf("📤 TX: %s", string(data))
+	c.traceFrame(FrameSent, data)
 
 	_, err = conn.Write(data)
 	return err
@@ -274,12 +642,16 @@ func (c *Client) nextID() int {
 }
 
 // readLoop continuously reads from the connection
-func (c *Client) readLoop() {
+func (c *Client) readLoop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	// A timed out read may return part of a line, kept until the rest arrives
+	var partial string
+	probed := false
+
 	for {
-		select {
-		case <-c.shutdown:
+		if ctx.Err() != nil {
 			return
-		default:
 		}
 
 		c.mu.RLock()
@@ -291,52 +663,149 @@ func (c *Client) readLoop() {
 			return
 		}
 
+		c.armReadDeadline()
+
 		line, err := reader.ReadString('\n')
 		if err != nil {
+			// Closed on purpose, the next Connect starts afresh
+			if ctx.Err() != nil {
+				return
+			}
+
+			c.mu.Lock()
+			handover := c.handover
+			if c.dropErr != nil {
+				err = c.dropErr
+				c.dropErr = nil
+			}
+			c.mu.Unlock()
+
+			// The connection is being adopted by another client
+			if handover {
+				return
+			}
+
+			// First silent period: probe the pool before giving up
+			if isTimeout(err) && !probed {
+				partial += line
+				probed = true
+				if perr := c.sendProbe(); perr == nil {
+					continue
+				}
+			}
+
+			if isTimeout(err) {
+				log.Printf("Pool silent for %s, dropping connection", c.GetIdleTimeout())
+				err = errIdleTimeout
+				c.mu.RLock()
+				conn := c.conn
+				c.mu.RUnlock()
+				if conn != nil {
+					conn.Close()
+				}
+			}
+
+			if c.promoteStandby() {
+				return
+			}
+
 			c.mu.Lock()
 			c.running = false
 			c.subscribed = false
 			c.authorized = false
+			// Responses to in-flight submits will never arrive
+			c.pendingSubmits = make(map[int]*SubmitResult)
 			c.mu.Unlock()
 
-			if c.onDisconnected != nil {
-				c.onDisconnected(err)
+			if cb := c.onDisconnected; cb != nil {
+				c.events.push("disconnected", deliverAlways, func() { cb(err) })
 			}
 			return
 		}
 
-		// LOG VERBOSE pour debug
-		log.Printf("📥 RX: %s", line)
+		line = partial + line
+		partial = ""
+		probed = false
+
+		c.traceFrame(FrameReceived, []byte(line))
 
 		c.handleMessage([]byte(line))
 	}
 }
 
-// handleMessage processes incoming messages
+// handleMessage processes an incoming line, which some pools pack with
+// several objects back to back or a batched array of them
 func (c *Client) handleMessage(data []byte) {
-	// Try to parse as response
-	var resp Response
-	if err := json.Unmarshal(data, &resp); err == nil && resp.ID != 0 {
-		c.handleResponse(&resp)
-		return
+	for _, msg := range splitMessages(data) {
+		c.handleObject(msg)
 	}
+}
+
+// splitMessages returns the JSON objects in a line, flattening batched
+// arrays. Anything after malformed JSON is dropped.
+func splitMessages(data []byte) []json.RawMessage {
+	var msgs []json.RawMessage
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return msgs
+		}
 
-	// Try to parse as notification
+		if raw[0] != '[' {
+			msgs = append(msgs, raw)
+			continue
+		}
+		var batch []json.RawMessage
+		if err := json.Unmarshal(raw, &batch); err == nil {
+			msgs = append(msgs, batch...)
+		}
+	}
+}
+
+// handleObject processes a single response or notification. Anything
+// with a method is a notification, whatever its ID.
+func (c *Client) handleObject(data []byte) {
 	var notif Notification
 	if err := json.Unmarshal(data, &notif); err == nil && notif.Method != "" {
 		c.handleNotification(&notif)
 		return
 	}
+
+	var resp Response
+	if err := json.Unmarshal(data, &resp); err == nil && resp.ID != 0 {
+		c.handleResponse(&resp)
+	}
 }
 
 // handleResponse processes response messages
 func (c *Client) handleResponse(resp *Response) {
+	// Handle submit responses, including rejections
+	c.mu.Lock()
+	pending, isSubmit := c.pendingSubmits[int(resp.ID)]
+	if isSubmit {
+		delete(c.pendingSubmits, int(resp.ID))
+	}
+	c.mu.Unlock()
+
+	if isSubmit {
+		c.handleSubmitResponse(pending, resp)
+		return
+	}
+
+	method, _ := c.pendingRequests.LoadAndDelete(int(resp.ID))
+
 	if resp.Error != nil {
 		return
 	}
 
+	// Handle configure response
+	if method == "mining.configure" {
+		c.handleConfigureResponse(resp.Result)
+	}
+
 	// Handle subscribe response
-	if resp.ID == 1 {
+	if method == "mining.subscribe" {
 		var result []json.RawMessage
 		if err := json.Unmarshal(resp.Result, &result); err == nil && len(result) >= 3 {
 			var extranonce1 string
@@ -344,31 +813,116 @@ func (c *Client) handleResponse(resp *Response) {
 			json.Unmarshal(result[1], &extranonce1)
 			json.Unmarshal(result[2], &extranonce2Size)
 
+			sessionID := parseSessionID(result[0])
+
 			c.mu.Lock()
-			c.extranonce1 = extranonce1
-			c.extranonce2Size = extranonce2Size
+			resumed := c.applySubscriptionLocked(sessionID, extranonce1, extranonce2Size)
 			c.subscribed = true
 			c.mu.Unlock()
 
-			if c.onSubscribed != nil {
-				c.onSubscribed(extranonce1, extranonce2Size)
+			if resumed {
+				log.Printf("Resumed pool session %s with extranonce1 %s", sessionID, extranonce1)
+			}
+
+			if cb := c.onSubscribed; cb != nil {
+				c.events.push("subscribed", deliverAlways, func() { cb(extranonce1, extranonce2Size) })
+			}
+
+			// Pools that don't support it just answer with an error
+			if err := c.ExtranonceSubscribe(); err != nil {
+				log.Printf("mining.extranonce.subscribe failed: %v", err)
 			}
 		}
 	}
 
 	// Handle authorize response
-	if resp.ID == 2 {
+	if method == "mining.authorize" {
 		var result bool
 		if err := json.Unmarshal(resp.Result, &result); err == nil {
 			c.mu.Lock()
 			c.authorized = result
+			startStandby := result && c.standbyEnabled && c.standbyStop != nil
 			c.mu.Unlock()
 
-			if c.onAuthorized != nil {
-				c.onAuthorized(result)
+			// Bring the standby up now rather than at the next check
+			if startStandby {
+				go c.maintainStandby()
+			}
+
+			if cb := c.onAuthorized; cb != nil {
+				c.events.push("authorized", deliverAlways, func() { cb(result) })
+			}
+			if result {
+				go c.flushSubmitQueue()
+			}
+		}
+	}
+}
+
+// handleSubmitResponse resolves a pending submit with the pool's verdict
+func (c *Client) handleSubmitResponse(result *SubmitResult, resp *Response) {
+	result.Latency = time.Since(result.SubmittedAt)
+
+	if resp.Error != nil {
+		code, message := parseError(resp.Error)
+		result.Reason = message
+		result.Category = categorizeReject(code, message)
+		result.Stale = result.Category == RejectStale
+	} else {
+		var accepted bool
+		if err := json.Unmarshal(resp.Result, &accepted); err == nil && accepted {
+			result.Accepted = true
+		} else {
+			result.Category = RejectOther
+			if result.Reason == "" {
+				result.Reason = "rejected by pool"
 			}
 		}
 	}
+
+	if cb := c.onSubmitResult; cb != nil {
+		c.events.push("submit_result", deliverAlways, func() { cb(result) })
+	}
+}
+
+// parseError extracts the code and message from a Stratum error, which
+// pools send either as [code, message, traceback] or as {code, message}
+func parseError(e interface{}) (int, string) {
+	switch v := e.(type) {
+	case []interface{}:
+		var code int
+		var message string
+		if len(v) > 0 {
+			code = toInt(v[0])
+		}
+		if len(v) > 1 {
+			message = fmt.Sprint(v[1])
+		}
+		return code, message
+	case map[string]interface{}:
+		var message string
+		if m, ok := v["message"]; ok {
+			message = fmt.Sprint(m)
+		}
+		return toInt(v["code"]), message
+	case string:
+		return 0, v
+	default:
+		return 0, fmt.Sprint(v)
+	}
+}
+
+// toInt converts a JSON number or numeric string to an int
+func toInt(v interface{}) int {
+	switch n := v.(type) {
+	case float64:
+		return int(n)
+	case string:
+		i, _ := strconv.Atoi(n)
+		return i
+	default:
+		return 0
+	}
 }
 
 // handleNotification processes notification messages
@@ -376,8 +930,143 @@ func (c *Client) handleNotification(notif *Notification) {
 	switch notif.Method {
 	case "mining.notify":
 		c.handleMiningNotify(notif.Params)
+	case "client.show_message":
+		c.handleShowMessage(notif.Params)
+	case "client.reconnect":
+		c.handleClientReconnect(notif.Params)
+	case "mining.set_extranonce":
+		c.handleSetExtranonce(notif.Params)
+	case "mining.set_version_mask":
+		c.handleSetVersionMask(notif.Params)
 	case "mining.set_difficulty":
-		// Handle difficulty changes if needed
+		c.handleSetDifficulty(notif.Params)
+	}
+}
+
+// handleSetDifficulty processes mining.set_difficulty notifications
+func (c *Client) handleSetDifficulty(params json.RawMessage) {
+	var p []float64
+	if err := json.Unmarshal(params, &p); err != nil || len(p) == 0 || p[0] <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	c.difficulty = p[0]
+	cb := c.onDifficulty
+	c.mu.Unlock()
+
+	if cb != nil {
+		c.events.push("difficulty", deliverLatest, func() { cb(p[0]) })
+	}
+}
+
+// knownHostLocked reports whether host is the current pool host or one
+// of the configured pools. Caller must hold c.mu.
+func (c *Client) knownHostLocked(host string) bool {
+	if strings.EqualFold(host, c.poolURL) {
+		return true
+	}
+	for _, p := range c.pools {
+		if strings.EqualFold(host, p.URL) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleShowMessage processes client.show_message notifications
+func (c *Client) handleShowMessage(params json.RawMessage) {
+	var p []interface{}
+	if err := json.Unmarshal(params, &p); err != nil || len(p) == 0 {
+		return
+	}
+
+	message, ok := p[0].(string)
+	if !ok || message == "" {
+		return
+	}
+
+	if cb := c.onShowMessage; cb != nil {
+		c.events.push("show_message", deliverIfRoom, func() { cb(message) })
+	}
+}
+
+// handleClientReconnect processes client.reconnect notifications. The
+// instructed endpoint replaces the current pool address until the next
+// pool switch, and the socket is closed so the reconnect path dials it.
+// Redirects to a host that is neither the current one nor a configured
+// pool are refused and the current pool is redialled instead, so a
+// hijacked or spoofed pool can't send the miner's work elsewhere.
+func (c *Client) handleClientReconnect(params json.RawMessage) {
+	var p []interface{}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return
+	}
+
+	var host string
+	var port int
+	var wait time.Duration
+	if len(p) > 0 {
+		host, _ = p[0].(string)
+	}
+	if len(p) > 1 {
+		port = toInt(p[1])
+	}
+	if len(p) > 2 {
+		wait = time.Duration(toInt(p[2])) * time.Second
+	}
+
+	c.mu.Lock()
+	if host != "" && !c.knownHostLocked(host) {
+		log.Printf("Refused pool redirect to %s:%d, reconnecting to %s:%d", host, port, c.poolURL, c.poolPort)
+		host, port = "", 0
+	}
+	if host != "" {
+		c.poolURL = host
+	}
+	if port > 0 {
+		c.poolPort = port
+	}
+	host, port = c.poolURL, c.poolPort
+	conn := c.conn
+	c.mu.Unlock()
+
+	log.Printf("Pool requested reconnect to %s:%d in %s", host, port, wait)
+
+	if cb := c.onReconnect; cb != nil {
+		c.events.push("reconnect", deliverAlways, func() { cb(host, port, wait) })
+	}
+
+	if conn != nil {
+		conn.Close()
+	}
+}
+
+// handleSetExtranonce processes mining.set_extranonce notifications
+func (c *Client) handleSetExtranonce(params json.RawMessage) {
+	var p []json.RawMessage
+	if err := json.Unmarshal(params, &p); err != nil || len(p) < 2 {
+		return
+	}
+
+	var extranonce1 string
+	var extranonce2Size int
+	if err := json.Unmarshal(p[0], &extranonce1); err != nil {
+		return
+	}
+	if err := json.Unmarshal(p[1], &extranonce2Size); err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	c.extranonce1 = extranonce1
+	c.extranonce2Size = extranonce2Size
+	c.epoch++
+	c.sessionEpoch = c.epoch
+	c.mu.Unlock()
+
+	if cb := c.onExtranonce; cb != nil {
+		c.events.push("extranonce", deliverLatest, func() { cb(extranonce1, extranonce2Size) })
 	}
 }
 
@@ -399,12 +1088,47 @@ func (c *Client) handleMiningNotify(params json.RawMessage) {
 	json.Unmarshal(p[6], &job.NBits)
 	json.Unmarshal(p[7], &job.NTime)
 	json.Unmarshal(p[8], &job.CleanJobs)
+	job.ReceivedAt = time.Now()
+
+	hash := jobFingerprint(job)
 
 	c.mu.Lock()
-	c.currentJob = job
+	// A pool repeating the current job only confirms it is still valid.
+	// Clean jobs and anything after an extranonce change go through.
+	duplicate := !job.CleanJobs && c.currentJob != nil && hash == c.jobHash && c.epoch == c.jobEpoch
+	pool, epoch := c.connectedPool.Name, c.epoch
+	c.jobReceivedAt = time.Now()
+	c.connJob = true
+	if !duplicate {
+		c.currentJob = job
+		c.jobHash = hash
+		c.jobEpoch = c.epoch
+		if c.wallet != "" {
+			c.submitTemplate = newSubmitTemplate(c.wallet, job.ID)
+		}
+	}
 	c.mu.Unlock()
 
-	if c.onJobReceived != nil {
-		c.onJobReceived(job)
+	if duplicate {
+		return
+	}
+	c.jobStats.record(pool, epoch, job)
+	if cb := c.onJobReceived; cb != nil {
+		c.events.pushJob(cb, job)
+	}
+	go c.flushSubmitQueue()
+}
+
+// jobFingerprint hashes the fields that make up a job's work
+func jobFingerprint(job *Job) uint64 {
+	h := fnv.New64a()
+	for _, field := range []string{job.ID, job.PrevHash, job.Coinbase1, job.Coinbase2, job.Version, job.NBits, job.NTime} {
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+	for _, branch := range job.MerkleBranch {
+		h.Write([]byte(branch))
+		h.Write([]byte{0})
 	}
+	return h.Sum64()
 }
@@ -0,0 +1,106 @@
+package stratum
+
+import (
+	"sync"
+	"time"
+)
+
+// ConnectionStats describes the traffic and lifetime of the pool
+// connection since the client was created
+type ConnectionStats struct {
+	Pool             string            `json:"pool"`
+	Connected        bool              `json:"connected"`
+	BytesSent        uint64            `json:"bytes_sent"`
+	BytesReceived    uint64            `json:"bytes_received"`
+	MessagesSent     map[string]uint64 `json:"messages_sent"`
+	MessagesReceived map[string]uint64 `json:"messages_received"`
+	Connects         int               `json:"connects"`
+	Reconnects       int               `json:"reconnects"`
+	LastConnect      time.Time         `json:"last_connect"`
+	UptimeSeconds    float64           `json:"uptime_seconds"`
+	Events           EventQueueStats   `json:"events"`
+	SubmitQueue      SubmitQueueStats  `json:"submit_queue"`
+}
+
+// connCounters accumulates connection statistics under its own lock, so
+// counting every frame doesn't contend with c.mu
+type connCounters struct {
+	mu               sync.Mutex
+	bytesSent        uint64
+	bytesReceived    uint64
+	messagesSent     map[string]uint64
+	messagesReceived map[string]uint64
+	connects         int
+	lastConnect      time.Time
+}
+
+// newConnCounters creates empty counters
+func newConnCounters() *connCounters {
+	return &connCounters{
+		messagesSent:     make(map[string]uint64),
+		messagesReceived: make(map[string]uint64),
+	}
+}
+
+// recordConnect counts a new connection, dialed or promoted from standby
+func (c *Client) recordConnect() {
+	c.counters.mu.Lock()
+	defer c.counters.mu.Unlock()
+	c.counters.connects++
+	c.counters.lastConnect = time.Now()
+}
+
+// recordFrame counts a sent or received message. Responses, which carry
+// no method, are counted under their request's method.
+func (c *Client) recordFrame(direction, method string, size int) {
+	if method == "" {
+		method = "unknown"
+	}
+
+	c.counters.mu.Lock()
+	defer c.counters.mu.Unlock()
+	if direction == FrameSent {
+		c.counters.bytesSent += uint64(size)
+		c.counters.messagesSent[method]++
+	} else {
+		c.counters.bytesReceived += uint64(size)
+		c.counters.messagesReceived[method]++
+	}
+}
+
+// GetConnectionStats returns traffic counters, reconnects and the uptime
+// of the current connection
+func (c *Client) GetConnectionStats() ConnectionStats {
+	c.mu.RLock()
+	pool := c.connectedPool.Name
+	connected := c.running
+	c.mu.RUnlock()
+	queue := c.GetSubmitQueueStats()
+
+	c.counters.mu.Lock()
+	defer c.counters.mu.Unlock()
+
+	stats := ConnectionStats{
+		Pool:             pool,
+		Connected:        connected,
+		BytesSent:        c.counters.bytesSent,
+		BytesReceived:    c.counters.bytesReceived,
+		MessagesSent:     make(map[string]uint64, len(c.counters.messagesSent)),
+		MessagesReceived: make(map[string]uint64, len(c.counters.messagesReceived)),
+		Connects:         c.counters.connects,
+		Reconnects:       max(c.counters.connects-1, 0),
+		LastConnect:      c.counters.lastConnect,
+		Events:           c.events.stats(),
+		SubmitQueue:      queue,
+	}
+	for method, n := range c.counters.messagesSent {
+		stats.MessagesSent[method] = n
+	}
+	for method, n := range c.counters.messagesReceived {
+		stats.MessagesReceived[method] = n
+	}
+	if connected && !stats.LastConnect.IsZero() {
+		stats.UptimeSeconds = time.Since(stats.LastConnect).Seconds()
+	}
+	return stats
+}
@@ -0,0 +1,283 @@
+package stratum
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// DialOptions tune the TCP connection to the pool
+type DialOptions struct {
+	// Bound on connecting, including any SOCKS handshake
+	Timeout time.Duration
+	// TCP keepalive probe period, zero disables keepalive
+	KeepAlive time.Duration
+	// Disables Nagle's algorithm so shares leave without batching delay
+	NoDelay bool
+	// Interface name or local IP to dial from on multi-homed hosts,
+	// empty lets the OS choose
+	SourceInterface string
+}
+
+// DefaultDialOptions returns the options used unless configured otherwise
+func DefaultDialOptions() DialOptions {
+	return DialOptions{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		NoDelay:   true,
+	}
+}
+
+// SetDialOptions sets how pool connections are dialed. A zero timeout
+// keeps the default.
+func (c *Client) SetDialOptions(opts DialOptions) error {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultDialOptions().Timeout
+	}
+	if opts.SourceInterface != "" {
+		if _, err := sourceAddr(opts.SourceInterface, false); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dialOptions = opts
+	return nil
+}
+
+// GetDialOptions returns the configured dial options
+func (c *Client) GetDialOptions() DialOptions {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.dialOptions
+}
+
+// SetProxy routes pool connections through a SOCKS5 proxy, e.g.
+// socks5://127.0.0.1:9050 for Tor. An empty URL dials directly.
+func (c *Client) SetProxy(proxyURL string) error {
+	if proxyURL != "" {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		if u.Scheme != "socks5" && u.Scheme != "socks5h" {
+			return fmt.Errorf("unsupported proxy scheme %q, use socks5", u.Scheme)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.proxyURL = proxyURL
+	return nil
+}
+
+// GetProxy returns the configured proxy URL
+func (c *Client) GetProxy() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.proxyURL
+}
+
+// dial opens a TCP connection to addr, through the proxy if one is set.
+// Hostnames are resolved by the proxy so DNS doesn't leak around Tor.
+// A zero timeout uses the configured dial timeout.
+func (c *Client) dial(addr string, timeout time.Duration) (net.Conn, error) {
+	c.mu.RLock()
+	proxyURL := c.proxyURL
+	opts := c.dialOptions
+	c.mu.RUnlock()
+
+	if timeout <= 0 {
+		timeout = opts.Timeout
+	}
+
+	direct := &tcpDialer{
+		Dialer: net.Dialer{
+			Timeout:   timeout,
+			KeepAlive: opts.KeepAlive,
+		},
+		noDelay: opts.NoDelay,
+		source:  opts.SourceInterface,
+	}
+	// A negative period turns keepalive off, zero would mean the default
+	if opts.KeepAlive <= 0 {
+		direct.KeepAlive = -1
+	}
+
+	if proxyURL == "" {
+		return c.dialRotating(direct, addr, timeout)
+	}
+
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+
+	dialer, err := proxy.FromURL(u, direct)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create proxy dialer: %w", err)
+	}
+
+	// Bound the whole SOCKS handshake, not just the TCP connect
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if cd, ok := dialer.(proxy.ContextDialer); ok {
+		return cd.DialContext(ctx, "tcp", addr)
+	}
+	return dialer.Dial("tcp", addr)
+}
+
+// dialRotating resolves every A and AAAA record of the pool host and tries
+// them in turn, starting with the address that worked last, so one dead
+// pool IP doesn't strand the miner
+func (c *Client) dialRotating(d *tcpDialer, addr string, timeout time.Duration) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if net.ParseIP(host) != nil {
+		return d.Dial("tcp", addr)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	resolved, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	last := c.lastAddrs[host]
+	c.mu.RUnlock()
+
+	ips := make([]string, 0, len(resolved))
+	for _, ip := range resolved {
+		if s := ip.String(); s == last {
+			ips = append([]string{s}, ips...)
+		} else {
+			ips = append(ips, s)
+		}
+	}
+
+	deadline, _ := ctx.Deadline()
+	var lastErr error
+	for i, ip := range ips {
+		// Share what's left of the timeout among the remaining addresses
+		remaining := time.Until(deadline)
+		attempt := max(remaining/time.Duration(len(ips)-i), minAttemptTimeout)
+		if attempt > remaining {
+			attempt = remaining
+		}
+		if attempt <= 0 {
+			break
+		}
+
+		attemptCtx, cancelAttempt := context.WithTimeout(ctx, attempt)
+		conn, err := d.DialContext(attemptCtx, "tcp", net.JoinHostPort(ip, port))
+		cancelAttempt()
+		if err == nil {
+			c.mu.Lock()
+			c.lastAddrs[host] = ip
+			c.mu.Unlock()
+			return conn, nil
+		}
+
+		lastErr = err
+		if i < len(ips)-1 {
+			log.Printf("Pool address %s of %s failed: %v, trying next", ip, host, err)
+		}
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no address of %s could be tried in time", host)
+	}
+	return nil, lastErr
+}
+
+// minAttemptTimeout keeps a long address list from starving each attempt
+const minAttemptTimeout = 2 * time.Second
+
+// tcpDialer applies socket options to every connection it opens, including
+// the one to a SOCKS proxy
+type tcpDialer struct {
+	net.Dialer
+	noDelay bool
+	// Interface name or local IP to bind, resolved per target so IPv6
+	// pools get an IPv6 source address
+	source string
+}
+
+// Dial connects to addr
+func (d *tcpDialer) Dial(network, addr string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, addr)
+}
+
+// DialContext connects to addr from the source address and sets
+// TCP_NODELAY
+func (d *tcpDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := d.Dialer
+	if d.source != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		ip := net.ParseIP(host)
+		local, err := sourceAddr(d.source, ip != nil && ip.To4() == nil)
+		if err != nil {
+			return nil, err
+		}
+		dialer.LocalAddr = local
+	}
+
+	conn, err := dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		if err := tcp.SetNoDelay(d.noDelay); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to set TCP_NODELAY: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// sourceAddr resolves an interface name or local IP to the address to
+// dial from. Interfaces prefer an address of the target's family and fall
+// back to any other.
+func sourceAddr(source string, ipv6 bool) (*net.TCPAddr, error) {
+	if ip := net.ParseIP(source); ip != nil {
+		return &net.TCPAddr{IP: ip}, nil
+	}
+
+	iface, err := net.InterfaceByName(source)
+	if err != nil {
+		return nil, fmt.Errorf("unknown source interface %q: %w", source, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read addresses of %q: %w", source, err)
+	}
+
+	var fallback net.IP
+	for _, a := range addrs {
+		ipNet, ok := a.(*net.IPNet)
+		if !ok || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if (ipNet.IP.To4() == nil) == ipv6 {
+			return &net.TCPAddr{IP: ipNet.IP}, nil
+		}
+		if fallback == nil {
+			fallback = ipNet.IP
+		}
+	}
+	if fallback == nil {
+		return nil, fmt.Errorf("source interface %q has no usable address", source)
+	}
+	return &net.TCPAddr{IP: fallback}, nil
+}
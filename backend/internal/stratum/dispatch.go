@@ -0,0 +1,142 @@
+package stratum
+
+import (
+	"sync"
+	"time"
+)
+
+// eventQueueSize is the backlog beyond which droppable events are dropped
+const eventQueueSize = 256
+
+// Delivery policies for queued callbacks
+const (
+	// deliverAlways queues the event whatever the backlog
+	deliverAlways = iota
+	// deliverLatest replaces a pending event of the same kind, as only
+	// the newest state matters
+	deliverLatest
+	// deliverIfRoom drops the event while the backlog is full
+	deliverIfRoom
+)
+
+// EventQueueStats describes the callback queue between the read loop and
+// the consumers
+type EventQueueStats struct {
+	Pending    int     `json:"pending"`
+	Delivered  uint64  `json:"delivered"`
+	Merged     uint64  `json:"merged"`
+	Dropped    uint64  `json:"dropped"`
+	MaxDelayMs float64 `json:"max_delay_ms"`
+}
+
+// event is a queued callback invocation
+type event struct {
+	kind     string
+	job      *Job
+	queuedAt time.Time
+	fn       func()
+}
+
+// eventQueue runs callbacks in order on a dispatcher goroutine, so a slow
+// consumer never stalls protocol processing. The goroutine exits when the
+// queue drains and is restarted by the next event.
+type eventQueue struct {
+	mu sync.Mutex
+
+	pending []event
+	running bool
+
+	delivered uint64
+	merged    uint64
+	dropped   uint64
+	maxDelay  time.Duration
+}
+
+// push queues fn under the given policy
+func (q *eventQueue) push(kind string, policy int, fn func()) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	switch policy {
+	case deliverLatest:
+		q.removeLocked(kind)
+	case deliverIfRoom:
+		if len(q.pending) >= eventQueueSize {
+			q.dropped++
+			return
+		}
+	}
+	q.appendLocked(event{kind: kind, fn: fn})
+}
+
+// pushJob queues a job for cb, replacing a job still waiting. The clean
+// flag of a replaced job carries over, so miners still drop work on the
+// previous block.
+func (q *eventQueue) pushJob(cb func(*Job), job *Job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if prev := q.removeLocked("job"); prev != nil && prev.job.CleanJobs && !job.CleanJobs {
+		clean := *job
+		clean.CleanJobs = true
+		job = &clean
+	}
+	q.appendLocked(event{kind: "job", job: job, fn: func() { cb(job) }})
+}
+
+// removeLocked drops the pending event of a kind, returning it. Caller
+// must hold q.mu.
+func (q *eventQueue) removeLocked(kind string) *event {
+	for i := range q.pending {
+		if q.pending[i].kind == kind {
+			e := q.pending[i]
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			q.merged++
+			return &e
+		}
+	}
+	return nil
+}
+
+// appendLocked queues an event, starting the dispatcher if it is idle.
+// Caller must hold q.mu.
+func (q *eventQueue) appendLocked(e event) {
+	e.queuedAt = time.Now()
+	q.pending = append(q.pending, e)
+	if !q.running {
+		q.running = true
+		go q.run()
+	}
+}
+
+// run delivers events until the queue is empty
+func (q *eventQueue) run() {
+	for {
+		q.mu.Lock()
+		if len(q.pending) == 0 {
+			q.running = false
+			q.mu.Unlock()
+			return
+		}
+		e := q.pending[0]
+		q.pending = q.pending[1:]
+		q.delivered++
+		q.maxDelay = max(q.maxDelay, time.Since(e.queuedAt))
+		q.mu.Unlock()
+
+		e.fn()
+	}
+}
+
+// stats returns the queue counters
+func (q *eventQueue) stats() EventQueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return EventQueueStats{
+		Pending:    len(q.pending),
+		Delivered:  q.delivered,
+		Merged:     q.merged,
+		Dropped:    q.dropped,
+		MaxDelayMs: float64(q.maxDelay) / float64(time.Millisecond),
+	}
+}
@@ -0,0 +1,302 @@
+package stratum
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Pool describes a pool endpoint the client can connect to
+type Pool struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	Port int    `json:"port"`
+}
+
+// addr returns the dialable host:port of the pool
+func (p Pool) addr() string {
+	return net.JoinHostPort(p.URL, strconv.Itoa(p.Port))
+}
+
+// errPoolSwitch is reported when the connection was dropped by SwitchPool
+var errPoolSwitch = errors.New("switching pool on request")
+
+// SetPoolSwitchCallback sets the callback for failover and failback switches
+func (c *Client) SetPoolSwitchCallback(cb func(from, to Pool, reason string)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onPoolSwitch = cb
+}
+
+// SetPools replaces the ordered pool list, primary first. The current pool
+// is kept if it is still in the list, otherwise the client moves to the
+// primary on the next Connect.
+func (c *Client) SetPools(pools []Pool) {
+	if len(pools) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current := Pool{URL: c.poolURL, Port: c.poolPort}
+	index := 0
+	for i, p := range pools {
+		if p.URL == current.URL && p.Port == current.Port {
+			index = i
+			break
+		}
+	}
+
+	c.pools = append([]Pool(nil), pools...)
+	c.setPoolLocked(index)
+}
+
+// SetFailoverPolicy configures how many consecutive connection failures
+// trigger a failover and how often the primary is probed for failback
+func (c *Client) SetFailoverPolicy(threshold int, failbackInterval time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if threshold > 0 {
+		c.failoverThreshold = threshold
+	}
+	if failbackInterval > 0 {
+		c.failbackInterval = failbackInterval
+	}
+}
+
+// GetPools returns the configured pool list
+func (c *Client) GetPools() []Pool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]Pool(nil), c.pools...)
+}
+
+// CurrentPool returns the pool the client is using
+func (c *Client) CurrentPool() Pool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.pools[c.poolIndex]
+}
+
+// ConnectedPool returns the pool of the current or last connection, which
+// differs from CurrentPool while a switch is pending
+func (c *Client) ConnectedPool() Pool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.connectedPool
+}
+
+// setPoolLocked points the client at pools[index]. Caller must hold c.mu.
+func (c *Client) setPoolLocked(index int) {
+	c.poolIndex = index
+	c.poolURL = c.pools[index].URL
+	c.poolPort = c.pools[index].Port
+	c.dialFailures = 0
+}
+
+// recordDialFailure counts a failed connection attempt and fails over to
+// the next pool once the threshold is reached
+func (c *Client) recordDialFailure() {
+	c.mu.Lock()
+	c.dialFailures++
+	if c.dialFailures < c.failoverThreshold || len(c.pools) < 2 {
+		c.mu.Unlock()
+		return
+	}
+
+	from := c.pools[c.poolIndex]
+	c.setPoolLocked((c.poolIndex + 1) % len(c.pools))
+	to := c.pools[c.poolIndex]
+	cb := c.onPoolSwitch
+	c.mu.Unlock()
+
+	log.Printf("Pool %s unreachable, failing over to %s", from.Name, to.Name)
+	if cb != nil {
+		c.events.push("pool_switch", deliverAlways, func() { cb(from, to, "failover") })
+	}
+}
+
+// startFailbackLocked starts probing the primary while on a backup pool.
+// Caller must hold c.mu.
+func (c *Client) startFailbackLocked() {
+	if c.poolIndex == 0 || c.failbackStop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	c.failbackStop = stop
+	go c.failbackLoop(stop, c.failbackInterval)
+}
+
+// failbackLoop waits for the primary to become reachable again, then
+// drops the backup connection so the reconnect path lands on the primary
+func (c *Client) failbackLoop(stop chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		c.mu.RLock()
+		onPrimary := c.poolIndex == 0
+		primary := c.pools[0]
+		c.mu.RUnlock()
+
+		if !onPrimary {
+			probe, err := c.dial(primary.addr(), 10*time.Second)
+			if err != nil {
+				continue
+			}
+			probe.Close()
+		}
+
+		c.mu.Lock()
+		if c.failbackStop == stop {
+			c.failbackStop = nil
+		}
+		if onPrimary {
+			c.mu.Unlock()
+			return
+		}
+		from := c.pools[c.poolIndex]
+		c.setPoolLocked(0)
+		conn := c.conn
+		cb := c.onPoolSwitch
+		c.mu.Unlock()
+
+		log.Printf("Primary pool %s is back, failing back from %s", primary.Name, from.Name)
+		if cb != nil {
+			c.events.push("pool_switch", deliverAlways, func() { cb(from, primary, "failback") })
+		}
+
+		// Closing the socket makes readLoop report a disconnect
+		if conn != nil {
+			conn.Close()
+		}
+		return
+	}
+}
+
+// SwitchPool deliberately moves to the named pool, or with an empty name
+// to the next pool, or back to the primary when on a backup. The
+// connection is dropped so the regular failover path takes over: the
+// standby is promoted if it points there, otherwise the disconnect
+// handler reconnects.
+func (c *Client) SwitchPool(name string) (from, to Pool, err error) {
+	c.mu.Lock()
+	index, err := c.switchTargetLocked(name)
+	if err != nil {
+		c.mu.Unlock()
+		return Pool{}, Pool{}, err
+	}
+
+	from = c.pools[c.poolIndex]
+	c.setPoolLocked(index)
+	to = c.pools[index]
+
+	// A standby elsewhere would win the race to the new pool
+	sb := c.standby
+	if sb != nil && sb.ConnectedPool() != to {
+		c.standby = nil
+	} else {
+		sb = nil
+	}
+
+	c.dropErr = errPoolSwitch
+	conn := c.conn
+	cb := c.onPoolSwitch
+	c.mu.Unlock()
+
+	if sb != nil {
+		sb.Close()
+	}
+
+	log.Printf("Switching from pool %s to %s on request", from.Name, to.Name)
+	if cb != nil {
+		c.events.push("pool_switch", deliverAlways, func() { cb(from, to, "manual") })
+	}
+
+	conn.Close()
+	return from, to, nil
+}
+
+// CheckPoolSwitch returns the pools SwitchPool(name) would move between,
+// or why it would fail, without switching
+func (c *Client) CheckPoolSwitch(name string) (from, to Pool, err error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	index, err := c.switchTargetLocked(name)
+	if err != nil {
+		return Pool{}, Pool{}, err
+	}
+	return c.pools[c.poolIndex], c.pools[index], nil
+}
+
+// switchTargetLocked resolves the pool index SwitchPool(name) moves to.
+// Caller must hold c.mu.
+func (c *Client) switchTargetLocked(name string) (int, error) {
+	if !c.running || c.conn == nil {
+		return 0, fmt.Errorf("not connected")
+	}
+	if len(c.pools) < 2 {
+		return 0, fmt.Errorf("no other pool configured")
+	}
+
+	index := 0
+	switch {
+	case name != "":
+		index = -1
+		for i, p := range c.pools {
+			if p.Name == name {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return 0, fmt.Errorf("unknown pool %q", name)
+		}
+	case c.poolIndex == 0:
+		index = 1
+	}
+	if index == c.poolIndex {
+		return 0, fmt.Errorf("already on pool %s", c.pools[index].Name)
+	}
+	return index, nil
+}
+
+// PendingSubmits returns how many submitted shares await the pool's verdict
+func (c *Client) PendingSubmits() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.pendingSubmits)
+}
+
+// DrainSubmits waits up to timeout for in-flight submits to resolve,
+// calling progress whenever the count changes, and returns how many are
+// still pending
+func (c *Client) DrainSubmits(timeout time.Duration, progress func(pending int)) int {
+	deadline := time.Now().Add(timeout)
+	last := -1
+	for {
+		pending := c.PendingSubmits()
+		if pending != last && progress != nil {
+			progress(pending)
+		}
+		last = pending
+
+		if pending == 0 || !c.IsConnected() || time.Now().After(deadline) {
+			return pending
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,103 @@
+package stratum
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// submitTemplate is a mining.submit frame serialized up to the
+// extranonce2, built once per job so a block solution is written without
+// marshaling anything
+type submitTemplate struct {
+	wallet string
+	jobID  string
+	prefix []byte
+}
+
+// newSubmitTemplate serializes the parameters that are fixed for a job
+func newSubmitTemplate(wallet, jobID string) *submitTemplate {
+	w, _ := json.Marshal(wallet)
+	j, _ := json.Marshal(jobID)
+
+	prefix := make([]byte, 0, 64+len(w)+len(j))
+	prefix = append(prefix, `,"method":"mining.submit","params":[`...)
+	prefix = append(prefix, w...)
+	prefix = append(prefix, ',')
+	prefix = append(prefix, j...)
+	prefix = append(prefix, `,"`...)
+	return &submitTemplate{wallet: wallet, jobID: jobID, prefix: prefix}
+}
+
+// frame completes the template into a newline-terminated request. The
+// remaining parameters are hex, so they need no escaping.
+func (t *submitTemplate) frame(id int, extranonce2, ntime, nonce, versionBits string) []byte {
+	data := make([]byte, 0, len(t.prefix)+64)
+	data = append(data, `{"id":`...)
+	data = strconv.AppendInt(data, int64(id), 10)
+	data = append(data, t.prefix...)
+	data = append(data, extranonce2...)
+	data = append(data, `","`...)
+	data = append(data, ntime...)
+	data = append(data, `","`...)
+	data = append(data, nonce...)
+	data = append(data, '"')
+	if versionBits != "" {
+		data = append(data, `,"`...)
+		data = append(data, versionBits...)
+		data = append(data, '"')
+	}
+	data = append(data, "]}\n"...)
+	return data
+}
+
+// SubmitBlock is the fast path for a solution meeting the network target.
+// The frame is written straight to the socket before any tracing, and
+// unlike SubmitForEpoch it is sent even if the job outlived the job
+// expiry, as only the pool can tell whether the block is still valid.
+func (c *Client) SubmitBlock(epoch uint64, jobID, extranonce2, ntime, nonce, versionBits string) error {
+	c.mu.Lock()
+	if c.conn == nil {
+		c.mu.Unlock()
+		return fmt.Errorf("not connected")
+	}
+	if epoch != c.epoch {
+		c.mu.Unlock()
+		return ErrStaleSession
+	}
+	if c.wallet == "" {
+		c.mu.Unlock()
+		return fmt.Errorf("not authorized")
+	}
+
+	tmpl := c.submitTemplate
+	if tmpl == nil || tmpl.jobID != jobID || tmpl.wallet != c.wallet {
+		tmpl = newSubmitTemplate(c.wallet, jobID)
+	}
+
+	c.requestID++
+	id := c.requestID
+	c.pendingSubmits[id] = &SubmitResult{
+		RequestID:   id,
+		Pool:        c.connectedPool.Name,
+		JobID:       jobID,
+		Extranonce2: extranonce2,
+		NTime:       ntime,
+		Nonce:       nonce,
+		SubmittedAt: time.Now(),
+	}
+	conn := c.conn
+	c.mu.Unlock()
+
+	data := tmpl.frame(id, extranonce2, ntime, nonce, versionBits)
+	if _, err := conn.Write(data); err != nil {
+		c.mu.Lock()
+		delete(c.pendingSubmits, id)
+		c.mu.Unlock()
+		return err
+	}
+
+	c.traceFrame(FrameSent, data)
+	return nil
+}
@@ -0,0 +1,74 @@
+package stratum
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrStaleJob is returned when no mining.notify arrived within the job
+// expiry, so the current job is likely outdated and would be rejected
+var ErrStaleJob = errors.New("current job expired without a new mining.notify")
+
+// errJobExpired is reported to the disconnect callback when the
+// connection was dropped to get fresh work
+var errJobExpired = errors.New("no new job from pool within job expiry")
+
+// SetJobExpiry sets how long a job stays valid without a new
+// mining.notify. Zero disables expiry.
+func (c *Client) SetJobExpiry(expiry time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.jobExpiry = expiry
+}
+
+// GetJobExpiry returns the configured job expiry
+func (c *Client) GetJobExpiry() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.jobExpiry
+}
+
+// GetJobAge returns how long ago the current job arrived, zero if there
+// is none
+func (c *Client) GetJobAge() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.currentJob == nil {
+		return 0
+	}
+	return time.Since(c.jobReceivedAt)
+}
+
+// IsJobStale returns whether the current job outlived the job expiry
+func (c *Client) IsJobStale() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.jobStaleLocked()
+}
+
+// jobStaleLocked reports whether the current job expired. Caller must
+// hold c.mu.
+func (c *Client) jobStaleLocked() bool {
+	if c.jobExpiry <= 0 || c.currentJob == nil {
+		return false
+	}
+	return time.Since(c.jobReceivedAt) > c.jobExpiry
+}
+
+// DropStaleConnection closes the connection if the current job expired,
+// so the disconnect path fails over or reconnects for fresh work. It
+// returns whether the connection was dropped.
+func (c *Client) DropStaleConnection() bool {
+	c.mu.Lock()
+	if !c.running || c.conn == nil || !c.jobStaleLocked() {
+		c.mu.Unlock()
+		return false
+	}
+	c.dropErr = errJobExpired
+	conn := c.conn
+	c.mu.Unlock()
+
+	conn.Close()
+	return true
+}
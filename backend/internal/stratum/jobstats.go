@@ -0,0 +1,162 @@
+package stratum
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxTrackedBlocks bounds the previous hashes remembered for block lag
+const maxTrackedBlocks = 16
+
+// JobStats summarizes the jobs a pool sent since the client was created.
+// Block lag is how long after the first connection to announce a new
+// block this pool did; it needs a standby or a failover to another pool
+// to compare against, and is zero for the fastest pool.
+type JobStats struct {
+	Pool               string    `json:"pool"`
+	Jobs               int       `json:"jobs"`
+	CleanJobs          int       `json:"clean_jobs"`
+	CleanRatio         float64   `json:"clean_ratio"`
+	AvgIntervalSeconds float64   `json:"avg_interval_seconds"`
+	MaxIntervalSeconds float64   `json:"max_interval_seconds"`
+	AvgMerkleDepth     float64   `json:"avg_merkle_depth"`
+	MaxMerkleDepth     int       `json:"max_merkle_depth"`
+	NewBlocks          int       `json:"new_blocks"`
+	AvgBlockLagMs      float64   `json:"avg_block_lag_ms"`
+	MaxBlockLagMs      float64   `json:"max_block_lag_ms"`
+	LastJob            time.Time `json:"last_job"`
+}
+
+// poolJobs accumulates one pool's job metadata
+type poolJobs struct {
+	jobs      int
+	cleanJobs int
+
+	// Intervals only count between jobs on the same connection
+	lastEpoch     uint64
+	lastJob       time.Time
+	lastPrevHash  string
+	intervals     int
+	intervalSum   time.Duration
+	intervalMax   time.Duration
+	merkleSum     int
+	merkleMax     int
+	newBlocks     int
+	blockLagSum   time.Duration
+	blockLagMax   time.Duration
+	blockLagCount int
+}
+
+// jobTracker records job metadata per pool. The primary and standby
+// clients share one, so pools can be compared on new blocks.
+type jobTracker struct {
+	mu sync.Mutex
+
+	pools map[string]*poolJobs
+
+	// When each recent previous hash was first announced by any pool
+	firstSeen map[string]time.Time
+	seenOrder []string
+}
+
+// newJobTracker creates an empty tracker
+func newJobTracker() *jobTracker {
+	return &jobTracker{
+		pools:     make(map[string]*poolJobs),
+		firstSeen: make(map[string]time.Time),
+	}
+}
+
+// record counts a new job from pool on the connection identified by epoch
+func (t *jobTracker) record(pool string, epoch uint64, job *Job) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	at := job.ReceivedAt
+	first, seen := t.firstSeen[job.PrevHash]
+	if !seen {
+		first = at
+		t.firstSeen[job.PrevHash] = at
+		t.seenOrder = append(t.seenOrder, job.PrevHash)
+		if len(t.seenOrder) > maxTrackedBlocks {
+			delete(t.firstSeen, t.seenOrder[0])
+			t.seenOrder = t.seenOrder[1:]
+		}
+	}
+
+	p := t.pools[pool]
+	if p == nil {
+		p = &poolJobs{}
+		t.pools[pool] = p
+	}
+
+	p.jobs++
+	if job.CleanJobs {
+		p.cleanJobs++
+	}
+	depth := len(job.MerkleBranch)
+	p.merkleSum += depth
+	p.merkleMax = max(p.merkleMax, depth)
+
+	sameConn := p.jobs > 1 && p.lastEpoch == epoch
+	if sameConn {
+		interval := at.Sub(p.lastJob)
+		p.intervals++
+		p.intervalSum += interval
+		p.intervalMax = max(p.intervalMax, interval)
+
+		// A previous hash change on a live connection is a new block
+		if job.PrevHash != p.lastPrevHash {
+			lag := at.Sub(first)
+			p.newBlocks++
+			p.blockLagCount++
+			p.blockLagSum += lag
+			p.blockLagMax = max(p.blockLagMax, lag)
+		}
+	}
+
+	p.lastEpoch = epoch
+	p.lastJob = at
+	p.lastPrevHash = job.PrevHash
+}
+
+// snapshot returns the stats of every pool, by pool name
+func (t *jobTracker) snapshot() []JobStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := make([]JobStats, 0, len(t.pools))
+	for name, p := range t.pools {
+		s := JobStats{
+			Pool:           name,
+			Jobs:           p.jobs,
+			CleanJobs:      p.cleanJobs,
+			MaxMerkleDepth: p.merkleMax,
+			NewBlocks:      p.newBlocks,
+			LastJob:        p.lastJob,
+		}
+		if p.jobs > 0 {
+			s.CleanRatio = float64(p.cleanJobs) / float64(p.jobs)
+			s.AvgMerkleDepth = float64(p.merkleSum) / float64(p.jobs)
+		}
+		if p.intervals > 0 {
+			s.AvgIntervalSeconds = (p.intervalSum / time.Duration(p.intervals)).Seconds()
+			s.MaxIntervalSeconds = p.intervalMax.Seconds()
+		}
+		if p.blockLagCount > 0 {
+			s.AvgBlockLagMs = float64(p.blockLagSum/time.Duration(p.blockLagCount)) / float64(time.Millisecond)
+			s.MaxBlockLagMs = float64(p.blockLagMax) / float64(time.Millisecond)
+		}
+		result = append(result, s)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Pool < result[j].Pool })
+	return result
+}
+
+// GetJobStats returns job quality metrics for every pool jobs came from,
+// including the standby connection
+func (c *Client) GetJobStats() []JobStats {
+	return c.jobStats.snapshot()
+}
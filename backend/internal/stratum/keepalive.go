@@ -0,0 +1,64 @@
+package stratum
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+// errIdleTimeout is reported to the disconnect callback when the pool
+// stayed silent for longer than the idle timeout
+var errIdleTimeout = errors.New("no message from pool within idle timeout")
+
+// SetIdleTimeout sets how long the pool may stay silent before the
+// connection is treated as dead. Halfway through, a probe is sent to
+// provoke a reply. Zero disables the check.
+func (c *Client) SetIdleTimeout(timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.idleTimeout = timeout
+}
+
+// GetIdleTimeout returns the configured idle timeout
+func (c *Client) GetIdleTimeout() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.idleTimeout
+}
+
+// armReadDeadline sets the read deadline for the next wait on the pool.
+// It is skipped during a handover, whose deadline must not be overwritten.
+func (c *Client) armReadDeadline() {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.handover || c.conn == nil {
+		return
+	}
+	if c.idleTimeout <= 0 {
+		c.conn.SetReadDeadline(time.Time{})
+		return
+	}
+
+	// Wait half the timeout, probe, then wait the other half
+	c.conn.SetReadDeadline(time.Now().Add(c.idleTimeout / 2))
+}
+
+// sendProbe asks the pool for a reply; any answer, even an error for an
+// unknown method, proves the connection is alive
+func (c *Client) sendProbe() error {
+	req := Request{
+		ID:     c.nextID(),
+		Method: "mining.ping",
+		Params: []interface{}{},
+	}
+	c.pendingRequests.Store(req.ID, req.Method)
+
+	return c.send(req)
+}
+
+// isTimeout reports whether err is a read deadline expiry
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
@@ -0,0 +1,334 @@
+package mockpool
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/soloforge/backend/internal/miner"
+	"github.com/soloforge/backend/internal/stratum"
+)
+
+// Stratum error codes, as sent by ckpool
+const (
+	errCodeOther         = 20
+	errCodeJobNotFound   = 21
+	errCodeDuplicate     = 22
+	errCodeLowDifficulty = 23
+	errCodeUnauthorized  = 24
+	errCodeNotSubscribed = 25
+)
+
+// request is a JSON-RPC request from a miner
+type request struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// response is a JSON-RPC response to a miner
+type response struct {
+	ID     json.RawMessage `json:"id"`
+	Result interface{}     `json:"result"`
+	Error  interface{}     `json:"error"`
+}
+
+// notification is a JSON-RPC notification to a miner
+type notification struct {
+	ID     interface{}   `json:"id"`
+	Method string        `json:"method"`
+	Params []interface{} `json:"params"`
+}
+
+// conn is one miner connection
+type conn struct {
+	mu sync.Mutex
+
+	pool    *Pool
+	id      int
+	nc      net.Conn
+	writeMu sync.Mutex
+
+	extranonce1 string
+	versionMask uint32
+	subscribed  bool
+	authorized  bool
+}
+
+// newConn creates the state for a miner connection
+func newConn(pool *Pool, id int, nc net.Conn) *conn {
+	return &conn{pool: pool, id: id, nc: nc}
+}
+
+// serve handles requests until the miner disconnects
+func (c *conn) serve() {
+	defer func() {
+		c.pool.unregister(c.id)
+		c.nc.Close()
+	}()
+
+	reader := bufio.NewReader(c.nc)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			return
+		}
+
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil || req.Method == "" {
+			continue
+		}
+		c.handle(&req)
+	}
+}
+
+// close disconnects the miner
+func (c *conn) close() {
+	c.nc.Close()
+}
+
+// handle dispatches a miner request
+func (c *conn) handle(req *request) {
+	switch req.Method {
+	case "mining.configure":
+		c.handleConfigure(req)
+	case "mining.subscribe":
+		c.handleSubscribe(req)
+	case "mining.extranonce.subscribe", "mining.suggest_difficulty":
+		c.reply(req.ID, true, nil)
+	case "mining.authorize":
+		c.mu.Lock()
+		c.authorized = true
+		c.mu.Unlock()
+		c.reply(req.ID, true, nil)
+	case "mining.submit":
+		c.handleSubmit(req)
+	default:
+		c.reply(req.ID, nil, []interface{}{errCodeOther, "Unsupported method", nil})
+	}
+}
+
+// handleConfigure grants version rolling within the pool's mask
+func (c *conn) handleConfigure(req *request) {
+	mask := c.pool.opts.VersionMask
+	result := map[string]interface{}{
+		"version-rolling": mask != 0,
+	}
+	if mask != 0 {
+		result["version-rolling.mask"] = fmt.Sprintf("%08x", mask)
+	}
+
+	c.mu.Lock()
+	c.versionMask = mask
+	c.mu.Unlock()
+
+	c.reply(req.ID, result, nil)
+}
+
+// handleSubscribe assigns an extranonce1, resuming the session if the
+// miner offers the ID of a previous one
+func (c *conn) handleSubscribe(req *request) {
+	var p []string
+	json.Unmarshal(req.Params, &p)
+
+	extranonce1 := ""
+	size := c.pool.opts.Extranonce1Size
+	if len(p) > 1 && len(p[1]) == size*2 {
+		if _, err := hex.DecodeString(p[1]); err == nil {
+			extranonce1 = p[1]
+		}
+	}
+	if extranonce1 == "" {
+		extranonce1 = randomHex(size)
+	}
+
+	c.mu.Lock()
+	c.extranonce1 = extranonce1
+	c.subscribed = true
+	c.mu.Unlock()
+
+	c.reply(req.ID, []interface{}{
+		[]interface{}{
+			[]interface{}{"mining.set_difficulty", extranonce1},
+			[]interface{}{"mining.notify", extranonce1},
+		},
+		extranonce1,
+		c.pool.opts.Extranonce2Size,
+	}, nil)
+
+	if d := c.pool.opts.Difficulty; d > 0 {
+		c.notify("mining.set_difficulty", []interface{}{d})
+	}
+	if job := c.pool.currentJob(); job != nil {
+		clean := *job
+		clean.CleanJobs = true
+		c.sendJob(&clean)
+	}
+}
+
+// handleSubmit checks a share against its job and the share difficulty
+func (c *conn) handleSubmit(req *request) {
+	var p []string
+	if err := json.Unmarshal(req.Params, &p); err != nil || len(p) < 5 {
+		c.reply(req.ID, nil, []interface{}{errCodeOther, "Invalid params", nil})
+		return
+	}
+	jobID, extranonce2, ntime, nonce := p[1], p[2], p[3], p[4]
+	versionBits := ""
+	if len(p) > 5 {
+		versionBits = p[5]
+	}
+
+	c.mu.Lock()
+	subscribed, authorized := c.subscribed, c.authorized
+	extranonce1, mask := c.extranonce1, c.versionMask
+	c.mu.Unlock()
+
+	switch {
+	case !subscribed:
+		c.reject(req.ID, errCodeNotSubscribed, "Not subscribed")
+		return
+	case !authorized:
+		c.reject(req.ID, errCodeUnauthorized, "Unauthorized worker")
+		return
+	case len(extranonce2) != c.pool.opts.Extranonce2Size*2:
+		c.reject(req.ID, errCodeOther, "Invalid extranonce2 size")
+		return
+	}
+
+	job, duplicate := c.pool.claimShare(jobID, extranonce1+"/"+extranonce2+"/"+ntime+"/"+nonce+"/"+versionBits)
+	if job == nil {
+		c.reject(req.ID, errCodeJobNotFound, "Stale job")
+		return
+	}
+	if duplicate {
+		c.reject(req.ID, errCodeDuplicate, "Duplicate share")
+		return
+	}
+
+	version, err := rolledVersion(job.Version, versionBits, mask)
+	if err != nil {
+		c.reject(req.ID, errCodeOther, err.Error())
+		return
+	}
+	diff, err := miner.ShareDifficulty(job, extranonce1, extranonce2, ntime, nonce, version)
+	if err != nil {
+		c.reject(req.ID, errCodeOther, err.Error())
+		return
+	}
+	if diff < c.pool.opts.Difficulty {
+		c.reject(req.ID, errCodeLowDifficulty, "Low difficulty share")
+		return
+	}
+
+	c.pool.recordShare(true, diff >= miner.NetworkDifficulty(job.NBits))
+	c.reply(req.ID, true, nil)
+}
+
+// reject answers a submit with an error and counts it
+func (c *conn) reject(id json.RawMessage, code int, message string) {
+	c.pool.recordShare(false, false)
+	c.reply(id, nil, []interface{}{code, message, nil})
+}
+
+// sendJob sends a job if the miner is subscribed
+func (c *conn) sendJob(job *stratum.Job) {
+	c.mu.Lock()
+	subscribed := c.subscribed
+	c.mu.Unlock()
+	if !subscribed {
+		return
+	}
+
+	c.notify("mining.notify", []interface{}{
+		job.ID, job.PrevHash, job.Coinbase1, job.Coinbase2, job.MerkleBranch,
+		job.Version, job.NBits, job.NTime, job.CleanJobs,
+	})
+}
+
+// reply sends a response
+func (c *conn) reply(id json.RawMessage, result, errVal interface{}) {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	c.write(response{ID: id, Result: result, Error: errVal})
+}
+
+// notify sends a notification
+func (c *conn) notify(method string, params []interface{}) {
+	c.write(notification{ID: nil, Method: method, Params: params})
+}
+
+// write sends one JSON line
+func (c *conn) write(v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.nc.Write(data)
+}
+
+// claimShare returns the share's job, and whether the share was already
+// submitted
+func (p *Pool) claimShare(jobID, key string) (*stratum.Job, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	job := p.jobs[jobID]
+	if job == nil {
+		return nil, false
+	}
+	if p.seen[jobID][key] {
+		return job, true
+	}
+	p.seen[jobID][key] = true
+	return job, false
+}
+
+// recordShare counts a share verdict
+func (p *Pool) recordShare(accepted, block bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if accepted {
+		p.stats.Accepted++
+	} else {
+		p.stats.Rejected++
+	}
+	if block {
+		p.stats.Blocks++
+	}
+}
+
+// rolledVersion applies BIP 310 version bits to the job version
+func rolledVersion(jobVersion, versionBits string, mask uint32) (string, error) {
+	if versionBits == "" {
+		return "", nil
+	}
+
+	bits, err := strconv.ParseUint(versionBits, 16, 32)
+	if err != nil {
+		return "", fmt.Errorf("invalid version bits")
+	}
+	if uint32(bits)&^mask != 0 {
+		return "", fmt.Errorf("version bits outside mask")
+	}
+
+	base, err := hex.DecodeString(jobVersion)
+	if err != nil || len(base) != 4 {
+		return "", fmt.Errorf("invalid job version")
+	}
+
+	rolled := make([]byte, 4)
+	binary.BigEndian.PutUint32(rolled, binary.BigEndian.Uint32(base)&^mask|uint32(bits)&mask)
+	return hex.EncodeToString(rolled), nil
+}
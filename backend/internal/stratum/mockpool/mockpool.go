@@ -0,0 +1,303 @@
+// Package mockpool is a lightweight Stratum V1 pool that hands out
+// synthetic jobs and checks submitted shares, so soloforge can be run
+// and tested without a real pool.
+package mockpool
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/soloforge/backend/internal/stratum"
+)
+
+// maxJobs bounds the jobs shares may still be submitted for
+const maxJobs = 8
+
+// Options tune the synthetic work
+type Options struct {
+	// How often a new job is broadcast, 0 disables periodic jobs
+	JobInterval time.Duration
+	// Share difficulty sent with mining.set_difficulty, 0 accepts any
+	// share and sends none
+	Difficulty float64
+	// Network target of the jobs. The default is met by about 1 in 2^20
+	// hashes, so CPU workers find solutions every few seconds.
+	NBits string
+	// Extranonce sizes handed out on subscribe
+	Extranonce1Size int
+	Extranonce2Size int
+	// Version bits miners may roll, 0 refuses mining.configure
+	VersionMask uint32
+}
+
+// DefaultOptions returns options suited to local development
+func DefaultOptions() Options {
+	return Options{
+		JobInterval:     30 * time.Second,
+		NBits:           "1e0fffff",
+		Extranonce1Size: 4,
+		Extranonce2Size: 4,
+		VersionMask:     0x1fffe000,
+	}
+}
+
+// Stats counts what the pool has seen
+type Stats struct {
+	Connections int `json:"connections"`
+	Jobs        int `json:"jobs"`
+	Accepted    int `json:"accepted"`
+	Rejected    int `json:"rejected"`
+	Blocks      int `json:"blocks"`
+}
+
+// Pool is a running mock pool
+type Pool struct {
+	mu sync.Mutex
+
+	opts     Options
+	listener net.Listener
+	addr     string
+	stop     chan struct{}
+
+	conns  map[int]*conn
+	nextID int
+
+	// Recent jobs by ID, oldest first in jobOrder
+	jobs     map[string]*stratum.Job
+	jobOrder []string
+	jobSeq   int
+	prevHash string
+
+	// Submitted shares per job, to catch duplicates
+	seen map[string]map[string]bool
+
+	stats Stats
+}
+
+// New creates a mock pool, unset options take their defaults
+func New(opts Options) *Pool {
+	defaults := DefaultOptions()
+	if opts.NBits == "" {
+		opts.NBits = defaults.NBits
+	}
+	if opts.Extranonce1Size <= 0 {
+		opts.Extranonce1Size = defaults.Extranonce1Size
+	}
+	if opts.Extranonce2Size <= 0 {
+		opts.Extranonce2Size = defaults.Extranonce2Size
+	}
+
+	return &Pool{
+		opts:  opts,
+		conns: make(map[int]*conn),
+		jobs:  make(map[string]*stratum.Job),
+		seen:  make(map[string]map[string]bool),
+	}
+}
+
+// Start listens on addr, e.g. "127.0.0.1:0" for any free port. Starting
+// a running pool is a no-op.
+func (p *Pool) Start(addr string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.listener != nil {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	p.listener = listener
+	p.addr = listener.Addr().String()
+	p.stop = make(chan struct{})
+	p.newBlockLocked()
+
+	go p.acceptLoop(listener)
+	if p.opts.JobInterval > 0 {
+		go p.jobLoop(p.stop, p.opts.JobInterval)
+	}
+
+	log.Printf("Mock pool listening on %s", p.addr)
+	return nil
+}
+
+// Stop closes the listener and every connection
+func (p *Pool) Stop() {
+	p.mu.Lock()
+	listener := p.listener
+	p.listener = nil
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+	}
+	conns := make([]*conn, 0, len(p.conns))
+	for _, c := range p.conns {
+		conns = append(conns, c)
+	}
+	p.mu.Unlock()
+
+	if listener != nil {
+		listener.Close()
+	}
+	for _, c := range conns {
+		c.close()
+	}
+}
+
+// Addr returns the address the pool listens on
+func (p *Pool) Addr() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.addr
+}
+
+// Port returns the port the pool listens on, 0 if not running
+func (p *Pool) Port() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.listener == nil {
+		return 0
+	}
+	return p.listener.Addr().(*net.TCPAddr).Port
+}
+
+// GetStats returns the pool counters
+func (p *Pool) GetStats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := p.stats
+	stats.Connections = len(p.conns)
+	return stats
+}
+
+// NewBlock simulates the network finding a block: a clean job on a new
+// previous hash, so shares for older jobs become stale
+func (p *Pool) NewBlock() {
+	p.mu.Lock()
+	job := p.newBlockLocked()
+	conns := p.connsLocked()
+	p.mu.Unlock()
+
+	for _, c := range conns {
+		c.sendJob(job)
+	}
+}
+
+// acceptLoop accepts connections until the listener is closed
+func (p *Pool) acceptLoop(listener net.Listener) {
+	for {
+		nc, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		p.mu.Lock()
+		p.nextID++
+		c := newConn(p, p.nextID, nc)
+		p.conns[c.id] = c
+		p.mu.Unlock()
+
+		go c.serve()
+	}
+}
+
+// jobLoop broadcasts a fresh job on the current block every interval
+func (p *Pool) jobLoop(stop chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			job := p.newJobLocked(false)
+			conns := p.connsLocked()
+			p.mu.Unlock()
+
+			for _, c := range conns {
+				c.sendJob(job)
+			}
+		}
+	}
+}
+
+// unregister forgets a closed connection
+func (p *Pool) unregister(id int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.conns, id)
+}
+
+// connsLocked returns the open connections. Caller must hold p.mu.
+func (p *Pool) connsLocked() []*conn {
+	conns := make([]*conn, 0, len(p.conns))
+	for _, c := range p.conns {
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+// newBlockLocked moves to a new previous hash and drops every job.
+// Caller must hold p.mu.
+func (p *Pool) newBlockLocked() *stratum.Job {
+	p.prevHash = randomHex(32)
+	p.jobs = make(map[string]*stratum.Job)
+	p.jobOrder = nil
+	p.seen = make(map[string]map[string]bool)
+	return p.newJobLocked(true)
+}
+
+// newJobLocked creates a job on the current block. Caller must hold p.mu.
+func (p *Pool) newJobLocked(clean bool) *stratum.Job {
+	p.jobSeq++
+	job := &stratum.Job{
+		ID:       fmt.Sprintf("%x", p.jobSeq),
+		PrevHash: p.prevHash,
+		// Coinbase split around the extranonces, with a random tag so
+		// every job hashes differently
+		Coinbase1:    "01000000010000000000000000000000000000000000000000000000000000000000000000ffffffff20" + randomHex(4),
+		Coinbase2:    "ffffffff0100f2052a01000000016a00000000",
+		MerkleBranch: []string{},
+		Version:      "20000000",
+		NBits:        p.opts.NBits,
+		NTime:        fmt.Sprintf("%08x", time.Now().Unix()),
+		CleanJobs:    clean,
+	}
+
+	p.jobs[job.ID] = job
+	p.seen[job.ID] = make(map[string]bool)
+	p.jobOrder = append(p.jobOrder, job.ID)
+	if len(p.jobOrder) > maxJobs {
+		delete(p.jobs, p.jobOrder[0])
+		delete(p.seen, p.jobOrder[0])
+		p.jobOrder = p.jobOrder[1:]
+	}
+	p.stats.Jobs++
+	return job
+}
+
+// currentJob returns the newest job
+func (p *Pool) currentJob() *stratum.Job {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.jobOrder) == 0 {
+		return nil
+	}
+	return p.jobs[p.jobOrder[len(p.jobOrder)-1]]
+}
+
+// randomHex returns n random bytes as hex
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,116 @@
+package stratum
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PoolBenchmark is the latency measured against one pool. Subscribe is the
+// mining.subscribe round trip, first job the time from subscribing to the
+// first mining.notify, which includes authorizing.
+type PoolBenchmark struct {
+	Pool        Pool    `json:"pool"`
+	ConnectMs   float64 `json:"connect_ms"`
+	SubscribeMs float64 `json:"subscribe_ms"`
+	FirstJobMs  float64 `json:"first_job_ms"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// BenchmarkPools connects to each pool in parallel, with the client's
+// proxy and dial options, and measures how quickly it becomes usable. Each
+// pool gets up to timeout after connecting. Results are ordered fastest
+// first job first, failed pools last.
+func (c *Client) BenchmarkPools(pools []Pool, username string, timeout time.Duration) []PoolBenchmark {
+	c.mu.RLock()
+	proxyURL := c.proxyURL
+	dialOptions := c.dialOptions
+	c.mu.RUnlock()
+
+	results := make([]PoolBenchmark, len(pools))
+	var wg sync.WaitGroup
+	for i, pool := range pools {
+		wg.Add(1)
+		go func(i int, pool Pool) {
+			defer wg.Done()
+			results[i] = benchmarkPool(pool, username, proxyURL, dialOptions, timeout)
+		}(i, pool)
+	}
+	wg.Wait()
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if (results[i].Error == "") != (results[j].Error == "") {
+			return results[i].Error == ""
+		}
+		return results[i].FirstJobMs < results[j].FirstJobMs
+	})
+	return results
+}
+
+// benchmarkPool measures a single pool on a throwaway connection
+func benchmarkPool(pool Pool, username, proxyURL string, dialOptions DialOptions, timeout time.Duration) PoolBenchmark {
+	result := PoolBenchmark{Pool: pool}
+
+	bc := NewClient(pool.URL, pool.Port)
+	bc.pools[0] = pool
+	bc.SetProxy(proxyURL)
+	bc.SetDialOptions(dialOptions)
+
+	subscribed := make(chan struct{}, 1)
+	firstJob := make(chan struct{}, 1)
+	bc.SetSubscribedCallback(func(string, int) {
+		select {
+		case subscribed <- struct{}{}:
+		default:
+		}
+	})
+	bc.SetJobCallback(func(*Job) {
+		select {
+		case firstJob <- struct{}{}:
+		default:
+		}
+	})
+
+	start := time.Now()
+	if err := bc.Connect(); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer bc.Close()
+	result.ConnectMs = msSince(start)
+
+	deadline := time.After(timeout)
+	subscribeStart := time.Now()
+	if err := bc.Subscribe(); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	select {
+	case <-subscribed:
+		result.SubscribeMs = msSince(subscribeStart)
+	case <-deadline:
+		result.Error = fmt.Sprintf("no subscribe response within %s", timeout)
+		return result
+	}
+
+	// Most pools only send work to authorized miners
+	if err := bc.Authorize(username, "x"); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	select {
+	case <-firstJob:
+		result.FirstJobMs = msSince(subscribeStart)
+	case <-deadline:
+		result.Error = fmt.Sprintf("no job within %s", timeout)
+	}
+	return result
+}
+
+// msSince returns the milliseconds elapsed since t
+func msSince(t time.Time) float64 {
+	return float64(time.Since(t)) / float64(time.Millisecond)
+}
@@ -0,0 +1,50 @@
+package stratum
+
+import "strings"
+
+// Reject categories for shares the pool refused
+const (
+	RejectStale         = "stale"
+	RejectDuplicate     = "duplicate"
+	RejectLowDifficulty = "low_difficulty"
+	RejectUnauthorized  = "unauthorized"
+	RejectOther         = "other"
+)
+
+// Stratum error codes pools send with rejected shares
+const (
+	errCodeJobNotFound   = 21
+	errCodeDuplicate     = 22
+	errCodeLowDifficulty = 23
+	errCodeUnauthorized  = 24
+	errCodeNotSubscribed = 25
+)
+
+// categorizeReject maps a submit error to a reject category. Codes are
+// checked first, then the message, since many pools send code 20 or none
+// with only a descriptive message.
+func categorizeReject(code int, message string) string {
+	switch code {
+	case errCodeJobNotFound:
+		return RejectStale
+	case errCodeDuplicate:
+		return RejectDuplicate
+	case errCodeLowDifficulty:
+		return RejectLowDifficulty
+	case errCodeUnauthorized, errCodeNotSubscribed:
+		return RejectUnauthorized
+	}
+
+	msg := strings.ToLower(message)
+	switch {
+	case strings.Contains(msg, "stale"), strings.Contains(msg, "job not found"):
+		return RejectStale
+	case strings.Contains(msg, "duplicate"):
+		return RejectDuplicate
+	case strings.Contains(msg, "low difficulty"), strings.Contains(msg, "above target"), strings.Contains(msg, "high-hash"):
+		return RejectLowDifficulty
+	case strings.Contains(msg, "unauthorized"), strings.Contains(msg, "not authorized"), strings.Contains(msg, "not subscribed"):
+		return RejectUnauthorized
+	}
+	return RejectOther
+}
@@ -0,0 +1,91 @@
+package stratum
+
+import (
+	"encoding/json"
+
+	"github.com/soloforge/backend/internal/version"
+)
+
+// UserAgent is sent as the first mining.subscribe parameter
+func UserAgent() string {
+	return "soloforge/" + version.Version
+}
+
+// SessionInfo describes the pool session and whether it was resumed
+type SessionInfo struct {
+	ID      string `json:"id,omitempty"`
+	Resumed bool   `json:"resumed"`
+}
+
+// subscribeParams returns the mining.subscribe parameters, asking to resume
+// the previous session when reconnecting to the pool that issued it
+func (c *Client) subscribeParams() []interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	params := []interface{}{UserAgent()}
+	if c.sessionID != "" && c.sessionPool == c.connectedPool {
+		params = append(params, c.sessionID)
+	}
+	return params
+}
+
+// applySubscriptionLocked stores the subscribe result and reports whether
+// the pool resumed the previous session. A resumed session keeps its
+// extranonce1, so the epoch is restored and shares mined before the
+// reconnect stay valid.
+// Caller must hold c.mu.
+func (c *Client) applySubscriptionLocked(sessionID, extranonce1 string, extranonce2Size int) bool {
+	resumed := c.sessionID != "" &&
+		c.sessionPool == c.connectedPool &&
+		c.extranonce1 == extranonce1 &&
+		c.extranonce2Size == extranonce2Size
+
+	if resumed {
+		c.epoch = c.sessionEpoch
+	} else {
+		c.sessionEpoch = c.epoch
+	}
+
+	c.extranonce1 = extranonce1
+	c.extranonce2Size = extranonce2Size
+	c.sessionID = sessionID
+	c.sessionPool = c.connectedPool
+	c.sessionResumed = resumed
+	return resumed
+}
+
+// parseSessionID extracts the session ID from the subscriptions in the first
+// element of a mining.subscribe result. Pools send either a list of
+// [method, id] pairs or a single pair; the mining.notify ID is preferred.
+func parseSessionID(raw json.RawMessage) string {
+	var pairs [][]string
+	if err := json.Unmarshal(raw, &pairs); err != nil {
+		var pair []string
+		if err := json.Unmarshal(raw, &pair); err != nil {
+			return ""
+		}
+		pairs = [][]string{pair}
+	}
+
+	id := ""
+	for _, pair := range pairs {
+		if len(pair) < 2 {
+			continue
+		}
+		if pair[0] == "mining.notify" {
+			return pair[1]
+		}
+		if id == "" {
+			id = pair[1]
+		}
+	}
+	return id
+}
+
+// GetSession returns the current pool session
+func (c *Client) GetSession() SessionInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return SessionInfo{ID: c.sessionID, Resumed: c.sessionResumed}
+}
@@ -0,0 +1,299 @@
+package stratum
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// standbyCheckInterval is how often the standby connection is verified
+const standbyCheckInterval = 15 * time.Second
+
+// StandbyStatus describes the health of the warm standby connection
+type StandbyStatus struct {
+	Enabled    bool   `json:"enabled"`
+	Pool       *Pool  `json:"pool,omitempty"`
+	Connected  bool   `json:"connected"`
+	Authorized bool   `json:"authorized"`
+	JobID      string `json:"job_id,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// SetStandbyEnabled opts in to keeping a subscribed but idle connection to
+// the next pool, so failover only has to start submitting there
+func (c *Client) SetStandbyEnabled(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.standbyEnabled = enabled
+	if enabled {
+		if c.running {
+			c.startStandbyLocked()
+		}
+		return
+	}
+
+	if c.standbyStop != nil {
+		close(c.standbyStop)
+		c.standbyStop = nil
+	}
+	if c.standby != nil {
+		c.standby.Close()
+		c.standby = nil
+	}
+}
+
+// GetStandbyStatus returns the state of the standby connection
+func (c *Client) GetStandbyStatus() StandbyStatus {
+	c.mu.RLock()
+	status := StandbyStatus{
+		Enabled: c.standbyEnabled,
+		Error:   c.standbyErr,
+	}
+	sb := c.standby
+	c.mu.RUnlock()
+
+	if sb == nil {
+		return status
+	}
+
+	pool := sb.ConnectedPool()
+	status.Pool = &pool
+	status.Connected = sb.IsConnected()
+	status.Authorized = sb.IsAuthorized()
+	if job := sb.GetCurrentJob(); job != nil {
+		status.JobID = job.ID
+	}
+	return status
+}
+
+// startStandbyLocked starts maintaining the standby. Caller must hold c.mu.
+func (c *Client) startStandbyLocked() {
+	if !c.standbyEnabled || c.standbyStop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	c.standbyStop = stop
+	go c.standbyLoop(stop)
+}
+
+// standbyLoop keeps the standby connected to the right pool
+func (c *Client) standbyLoop(stop chan struct{}) {
+	for {
+		c.maintainStandby()
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(standbyCheckInterval):
+		}
+	}
+}
+
+// standbyTargetLocked returns the first pool other than the current one.
+// Caller must hold c.mu.
+func (c *Client) standbyTargetLocked() (Pool, bool) {
+	for i, p := range c.pools {
+		if i != c.poolIndex {
+			return p, true
+		}
+	}
+	return Pool{}, false
+}
+
+// maintainStandby (re)connects the standby when it is missing, dead or
+// pointed at the wrong pool
+func (c *Client) maintainStandby() {
+	c.mu.RLock()
+	running := c.running
+	wallet, password := c.wallet, c.password
+	proxyURL := c.proxyURL
+	dialOptions := c.dialOptions
+	idleTimeout := c.idleTimeout
+	sb := c.standby
+	target, ok := c.standbyTargetLocked()
+	c.mu.RUnlock()
+
+	// Wait until the primary session is authorized
+	if !running || !ok || wallet == "" {
+		return
+	}
+
+	if sb != nil {
+		if sb.IsConnected() && sb.ConnectedPool() == target {
+			return
+		}
+
+		c.mu.Lock()
+		if c.standby == sb {
+			c.standby = nil
+		}
+		c.mu.Unlock()
+		sb.Close()
+	}
+
+	standby := NewClient(target.URL, target.Port)
+	standby.pools[0] = target
+	standby.trace = c.trace
+	standby.jobStats = c.jobStats
+	standby.SetProxy(proxyURL)
+	standby.SetDialOptions(dialOptions)
+	standby.SetIdleTimeout(idleTimeout)
+
+	err := standby.Connect()
+	if err == nil {
+		err = standby.Configure()
+	}
+	if err == nil {
+		err = standby.Subscribe()
+	}
+	if err == nil {
+		// Give the pool time to answer before authorizing
+		time.Sleep(500 * time.Millisecond)
+		err = standby.Authorize(wallet, password)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err != nil {
+		c.standbyErr = err.Error()
+		if standby.IsConnected() {
+			standby.Close()
+		}
+		return
+	}
+	c.standbyErr = ""
+
+	// Mining stopped or another standby won the race meanwhile
+	if !c.running || c.standby != nil || c.standbyStop == nil {
+		standby.Close()
+		return
+	}
+	c.standby = standby
+}
+
+// detach stops the client's read loop without closing the socket so
+// another client can adopt the connection
+func (c *Client) detach() bool {
+	c.mu.Lock()
+	if !c.running || !c.authorized || c.conn == nil {
+		c.mu.Unlock()
+		return false
+	}
+	c.handover = true
+	conn := c.conn
+	done := c.loopDone
+	c.mu.Unlock()
+
+	// Unblock the pending read, then wait for the loop to exit
+	conn.SetReadDeadline(time.Now())
+	<-done
+	conn.SetReadDeadline(time.Time{})
+	return true
+}
+
+// promoteStandby adopts the standby connection after the active one was
+// lost, returning false if no healthy standby was available
+func (c *Client) promoteStandby() bool {
+	c.mu.Lock()
+	sb := c.standby
+	if sb == nil || !c.running {
+		c.mu.Unlock()
+		return false
+	}
+	c.standby = nil
+	c.mu.Unlock()
+
+	if !sb.detach() {
+		sb.Close()
+		return false
+	}
+
+	sb.mu.RLock()
+	conn, reader := sb.conn, sb.reader
+	extranonce1, extranonce2Size := sb.extranonce1, sb.extranonce2Size
+	sessionID := sb.sessionID
+	versionMask := sb.versionMask
+	difficulty := sb.difficulty
+	job, jobReceivedAt := sb.currentJob, sb.jobReceivedAt
+	requestID := sb.requestID
+	target := sb.connectedPool
+	sb.mu.RUnlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c.mu.Lock()
+	old := c.conn
+	from := c.connectedPool
+	if c.cancel != nil {
+		// Only the loop that lost the connection uses it
+		c.cancel()
+	}
+	c.cancel = cancel
+
+	index := c.poolIndex
+	for i, p := range c.pools {
+		if p == target {
+			index = i
+			break
+		}
+	}
+	c.setPoolLocked(index)
+	c.connectedPool = target
+
+	c.conn = conn
+	c.reader = reader
+	c.extranonce1 = extranonce1
+	c.extranonce2Size = extranonce2Size
+	c.epoch++
+	c.sessionID = sessionID
+	c.sessionPool = target
+	c.sessionEpoch = c.epoch
+	c.sessionResumed = false
+	c.versionMask = versionMask
+	c.difficulty = difficulty
+	c.currentJob = job
+	c.jobReceivedAt = jobReceivedAt
+	c.subscribed = true
+	c.authorized = true
+	c.connectedAt = time.Now()
+	c.connJob = job != nil
+	if requestID > c.requestID {
+		c.requestID = requestID
+	}
+	c.pendingSubmits = make(map[int]*SubmitResult)
+
+	c.loopDone = make(chan struct{})
+	done := c.loopDone
+	c.startFailbackLocked()
+	c.mu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+	c.recordConnect()
+
+	log.Printf("Promoted standby connection to %s", target.Name)
+
+	// Queued ahead of anything the new read loop reports
+	if cb := c.onPoolSwitch; cb != nil {
+		c.events.push("pool_switch", deliverAlways, func() { cb(from, target, "standby") })
+	}
+	if cb := c.onExtranonce; cb != nil {
+		c.events.push("extranonce", deliverLatest, func() { cb(extranonce1, extranonce2Size) })
+	}
+	if cb := c.onVersionMask; cb != nil {
+		c.events.push("version_mask", deliverLatest, func() { cb(versionMask) })
+	}
+	if cb := c.onJobReceived; job != nil && cb != nil {
+		c.events.pushJob(cb, job)
+	}
+
+	go c.readLoop(ctx, done)
+	go c.watchdog(ctx, done)
+	go c.flushSubmitQueue()
+
+	return true
+}
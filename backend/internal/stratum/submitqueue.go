@@ -0,0 +1,150 @@
+package stratum
+
+import (
+	"errors"
+	"log"
+	"time"
+)
+
+// maxQueuedSubmits bounds the shares held while the pool is unreachable,
+// the oldest is dropped beyond it
+const maxQueuedSubmits = 64
+
+// maxQueuedSubmitAge is how long a queued share may wait for a reconnect
+const maxQueuedSubmitAge = 2 * time.Minute
+
+// ErrSubmitQueued is returned when a share couldn't be sent and was queued
+// for the next connection
+var ErrSubmitQueued = errors.New("pool unreachable, share queued for resubmission")
+
+// Outcomes of a queued share
+const (
+	QueuedResubmitted = "resubmitted"
+	QueuedStale       = "stale"
+	QueuedDropped     = "dropped"
+)
+
+// SubmitQueueStats counts shares found while the pool was unreachable
+type SubmitQueueStats struct {
+	Pending     int    `json:"pending"`
+	Queued      uint64 `json:"queued"`
+	Resubmitted uint64 `json:"resubmitted"`
+	Stale       uint64 `json:"stale"`
+	Dropped     uint64 `json:"dropped"`
+}
+
+// queuedSubmit is a share waiting for the pool to come back
+type queuedSubmit struct {
+	epoch       uint64
+	pool        string
+	prevHash    string
+	wallet      string
+	jobID       string
+	extranonce2 string
+	ntime       string
+	nonce       string
+	versionBits string
+	queuedAt    time.Time
+}
+
+// SetQueuedSubmitCallback sets the callback for the outcome of a queued
+// share. Resubmitted shares get their verdict through the submit result
+// callback as usual.
+func (c *Client) SetQueuedSubmitCallback(cb func(jobID, nonce, outcome, reason string)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onQueuedSubmit = cb
+}
+
+// GetSubmitQueueStats returns the submit queue counters
+func (c *Client) GetSubmitQueueStats() SubmitQueueStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	st := c.queueStats
+	st.Pending = len(c.submitQueue)
+	return st
+}
+
+// queueSubmitLocked holds a share that couldn't be sent until the next
+// connection. Caller must hold c.mu.
+func (c *Client) queueSubmitLocked(q queuedSubmit) {
+	if c.currentJob != nil {
+		q.prevHash = c.currentJob.PrevHash
+	}
+	q.pool = c.connectedPool.Name
+	q.queuedAt = time.Now()
+
+	if len(c.submitQueue) >= maxQueuedSubmits {
+		oldest := c.submitQueue[0]
+		c.submitQueue = c.submitQueue[1:]
+		c.reportQueuedLocked(oldest, QueuedDropped, "submit queue full")
+	}
+	c.submitQueue = append(c.submitQueue, q)
+	c.queueStats.Queued++
+}
+
+// reportQueuedLocked counts a queued share's outcome and queues the
+// callback. Caller must hold c.mu.
+func (c *Client) reportQueuedLocked(q queuedSubmit, outcome, reason string) {
+	switch outcome {
+	case QueuedResubmitted:
+		c.queueStats.Resubmitted++
+	case QueuedStale:
+		c.queueStats.Stale++
+	default:
+		c.queueStats.Dropped++
+	}
+
+	if cb := c.onQueuedSubmit; cb != nil {
+		c.events.push("queued_submit", deliverAlways, func() { cb(q.jobID, q.nonce, outcome, reason) })
+	}
+}
+
+// flushSubmitQueue resubmits the queued shares still valid for the
+// current connection, once it is authorized and has a job to check them
+// against
+func (c *Client) flushSubmitQueue() {
+	c.mu.Lock()
+	if len(c.submitQueue) == 0 || !c.authorized || !c.connJob || c.currentJob == nil {
+		c.mu.Unlock()
+		return
+	}
+	queue := c.submitQueue
+	c.submitQueue = nil
+
+	var resubmit []queuedSubmit
+	for _, q := range queue {
+		switch {
+		case time.Since(q.queuedAt) > maxQueuedSubmitAge:
+			c.reportQueuedLocked(q, QueuedStale, "queued too long")
+		case q.pool != c.connectedPool.Name:
+			c.reportQueuedLocked(q, QueuedStale, "different pool")
+		case q.epoch != c.epoch:
+			// Session not resumed, the pool doesn't know the extranonce1
+			c.reportQueuedLocked(q, QueuedDropped, "previous session")
+		case q.prevHash != c.currentJob.PrevHash:
+			c.reportQueuedLocked(q, QueuedStale, "new block while disconnected")
+		default:
+			resubmit = append(resubmit, q)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, q := range resubmit {
+		err := c.SubmitForEpoch(q.epoch, q.wallet, q.jobID, q.extranonce2, q.ntime, q.nonce, q.versionBits)
+		c.mu.Lock()
+		switch {
+		case err == nil:
+			c.reportQueuedLocked(q, QueuedResubmitted, "")
+		case errors.Is(err, ErrSubmitQueued):
+			// Lost the connection again, queued anew
+		default:
+			c.reportQueuedLocked(q, QueuedStale, err.Error())
+		}
+		c.mu.Unlock()
+	}
+
+	if len(resubmit) > 0 {
+		log.Printf("Resubmitted %d shares found while disconnected", len(resubmit))
+	}
+}
@@ -0,0 +1,111 @@
+package stratum
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxTraceFrames bounds the captured protocol frames
+const maxTraceFrames = 500
+
+// Frame directions
+const (
+	FrameSent     = "tx"
+	FrameReceived = "rx"
+)
+
+// Frame is a captured Stratum protocol message
+type Frame struct {
+	Timestamp     time.Time `json:"timestamp"`
+	Direction     string    `json:"direction"`
+	Pool          string    `json:"pool"`
+	Method        string    `json:"method,omitempty"`
+	ID            int       `json:"id,omitempty"`
+	CorrelationID string    `json:"correlation_id,omitempty"`
+	Raw           string    `json:"raw"`
+}
+
+// frameTrace holds the most recent frames. The standby connection shares
+// its client's trace so failovers show up in one timeline.
+type frameTrace struct {
+	mu     sync.Mutex
+	frames []Frame
+}
+
+// newFrameTrace creates an empty trace
+func newFrameTrace() *frameTrace {
+	return &frameTrace{frames: make([]Frame, 0)}
+}
+
+// add appends a frame, dropping the oldest beyond the limit
+func (t *frameTrace) add(f Frame) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.frames = append(t.frames, f)
+	if len(t.frames) > maxTraceFrames {
+		t.frames = t.frames[1:]
+	}
+}
+
+// recent returns up to limit frames, newest first
+func (t *frameTrace) recent(limit int) []Frame {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if limit <= 0 || limit > len(t.frames) {
+		limit = len(t.frames)
+	}
+
+	result := make([]Frame, 0, limit)
+	for i := len(t.frames) - 1; i >= 0 && len(result) < limit; i-- {
+		result = append(result, t.frames[i])
+	}
+	return result
+}
+
+// GetTrace returns the most recent protocol frames, newest first
+func (c *Client) GetTrace(limit int) []Frame {
+	return c.trace.recent(limit)
+}
+
+// traceFrame captures a sent or received line and counts it in the
+// connection stats
+func (c *Client) traceFrame(direction string, data []byte) {
+	var msg struct {
+		ID     MessageID `json:"id"`
+		Method string    `json:"method"`
+	}
+	json.Unmarshal(data, &msg)
+	id := int(msg.ID)
+
+	c.mu.RLock()
+	pool := c.connectedPool.Name
+	correlationID := c.correlationID
+	_, isSubmit := c.pendingSubmits[id]
+	c.mu.RUnlock()
+
+	// Responses carry no method, name them after their request
+	method := msg.Method
+	if method == "" && id != 0 {
+		if isSubmit {
+			method = "mining.submit"
+		} else if m, ok := c.pendingRequests.Load(id); ok {
+			method, _ = m.(string)
+		}
+	}
+
+	c.recordFrame(direction, method, len(data))
+
+	c.trace.add(Frame{
+		Timestamp:     time.Now(),
+		Direction:     direction,
+		Pool:          pool,
+		Method:        method,
+		ID:            id,
+		CorrelationID: correlationID,
+		Raw:           strings.TrimSpace(string(data)),
+	})
+}
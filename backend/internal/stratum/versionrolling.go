@@ -0,0 +1,96 @@
+package stratum
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+// defaultVersionMask is the BIP 320 range of header version bits we ask
+// the pool to let us roll
+const defaultVersionMask uint32 = 0x1fffe000
+
+// SetVersionMaskCallback sets the callback for version-rolling mask changes
+func (c *Client) SetVersionMaskCallback(cb func(uint32)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onVersionMask = cb
+}
+
+// Configure sends mining.configure to negotiate version-rolling (BIP 310).
+// It must be sent before mining.subscribe; pools that don't support the
+// extension answer with an error and the mask stays zero.
+func (c *Client) Configure() error {
+	req := Request{
+		ID:     c.nextID(),
+		Method: "mining.configure",
+		Params: []interface{}{
+			[]string{"version-rolling"},
+			map[string]interface{}{
+				"version-rolling.mask":          fmt.Sprintf("%08x", defaultVersionMask),
+				"version-rolling.min-bit-count": 2,
+			},
+		},
+	}
+	c.pendingRequests.Store(req.ID, req.Method)
+
+	return c.send(req)
+}
+
+// GetVersionMask returns the negotiated version-rolling mask, zero when
+// version rolling is not allowed
+func (c *Client) GetVersionMask() uint32 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.versionMask
+}
+
+// handleConfigureResponse stores the mask granted by the pool
+func (c *Client) handleConfigureResponse(result json.RawMessage) {
+	var p map[string]interface{}
+	if err := json.Unmarshal(result, &p); err != nil {
+		return
+	}
+
+	var mask uint32
+	if enabled, _ := p["version-rolling"].(bool); enabled {
+		granted, _ := p["version-rolling.mask"].(string)
+		parsed, err := strconv.ParseUint(granted, 16, 32)
+		if err != nil {
+			log.Printf("Invalid version-rolling mask %q: %v", granted, err)
+			return
+		}
+		// Never roll bits we didn't ask for
+		mask = uint32(parsed) & defaultVersionMask
+	}
+
+	c.setVersionMask(mask)
+}
+
+// handleSetVersionMask processes mining.set_version_mask notifications
+func (c *Client) handleSetVersionMask(params json.RawMessage) {
+	var p []string
+	if err := json.Unmarshal(params, &p); err != nil || len(p) == 0 {
+		return
+	}
+
+	parsed, err := strconv.ParseUint(p[0], 16, 32)
+	if err != nil {
+		return
+	}
+
+	c.setVersionMask(uint32(parsed) & defaultVersionMask)
+}
+
+// setVersionMask stores the mask and notifies the callback
+func (c *Client) setVersionMask(mask uint32) {
+	c.mu.Lock()
+	c.versionMask = mask
+	cb := c.onVersionMask
+	c.mu.Unlock()
+
+	if cb != nil {
+		c.events.push("version_mask", deliverLatest, func() { cb(mask) })
+	}
+}
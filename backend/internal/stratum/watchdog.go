@@ -0,0 +1,79 @@
+package stratum
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// watchdogInterval is how often the no-job watchdog checks the connection
+const watchdogInterval = 5 * time.Second
+
+// errNoJob is reported to the disconnect callback when the watchdog tore
+// down a connection that stopped sending jobs
+var errNoJob = errors.New("no job from pool within no-job timeout")
+
+// SetNoJobTimeout sets how long a connection may go without any
+// mining.notify, counted from the last one or from connecting, before
+// the watchdog drops it. Zero disables the watchdog.
+func (c *Client) SetNoJobTimeout(timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.noJobTimeout = timeout
+}
+
+// GetNoJobTimeout returns the configured no-job timeout
+func (c *Client) GetNoJobTimeout() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.noJobTimeout
+}
+
+// SetNoJobCallback sets the callback invoked when the watchdog drops a
+// connection, before the disconnect callback
+func (c *Client) SetNoJobCallback(cb func(pool Pool, silent time.Duration)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onNoJob = cb
+}
+
+// watchdog drops the connection when the pool keeps it alive but stops
+// sending work, a failure the idle timeout can't see. It runs until the
+// connection's context is cancelled or its read loop exits.
+func (c *Client) watchdog(ctx context.Context, done chan struct{}) {
+	ticker := time.NewTicker(watchdogInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		c.mu.Lock()
+		since := c.connectedAt
+		if c.connJob {
+			since = c.jobReceivedAt
+		}
+		silent := time.Since(since)
+		if !c.running || c.conn == nil || c.noJobTimeout <= 0 || silent < c.noJobTimeout {
+			c.mu.Unlock()
+			continue
+		}
+		c.dropErr = errNoJob
+		conn, pool := c.conn, c.connectedPool
+		cb := c.onNoJob
+		c.mu.Unlock()
+
+		log.Printf("No job from %s for %s, reconnecting", pool.Name, silent.Round(time.Second))
+		if cb != nil {
+			c.events.push("no_job", deliverAlways, func() { cb(pool, silent) })
+		}
+		conn.Close()
+		return
+	}
+}
@@ -0,0 +1,265 @@
+package tariff
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/soloforge/backend/internal/clockwindow"
+)
+
+// Actions the scheduler can ask the miner to take
+const (
+	ActionMine     = "mine"
+	ActionThrottle = "throttle"
+	ActionPause    = "pause"
+)
+
+// Price sources
+const (
+	SourceNone     = "none"
+	SourceSchedule = "schedule"
+	SourceAPI      = "api"
+	SourceDefault  = "default"
+)
+
+// Window is a time-of-use price period. Windows may span midnight
+// (22:00-07:00); Days limits the window to the days it starts on.
+type Window struct {
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+	Price float64  `json:"price"`
+	Days  []string `json:"days,omitempty"`
+}
+
+// Policy describes the tariff and the price thresholds for each action
+type Policy struct {
+	Windows       []Window `json:"windows"`
+	DefaultPrice  float64  `json:"default_price"`
+	PriceURL      string   `json:"price_url"`
+	ThrottleAbove float64  `json:"throttle_above"`
+	PauseAbove    float64  `json:"pause_above"`
+}
+
+// Decision is the result of evaluating the policy at a point in time
+type Decision struct {
+	Price    float64   `json:"price"`
+	Source   string    `json:"source"`
+	Action   string    `json:"action"`
+	Override bool      `json:"override"`
+	Error    string    `json:"error,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// Scheduler periodically evaluates the tariff and reports action changes
+type Scheduler struct {
+	mu sync.RWMutex
+
+	policy   Policy
+	override bool
+	last     Decision
+
+	httpClient *http.Client
+	interval   time.Duration
+
+	onDecision func(Decision)
+
+	running  bool
+	shutdown chan struct{}
+}
+
+// NewScheduler creates a tariff scheduler evaluating once a minute
+func NewScheduler() *Scheduler {
+	return &Scheduler{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		interval:   time.Minute,
+		last:       Decision{Action: ActionMine, Source: SourceNone},
+	}
+}
+
+// SetDecisionCallback sets the callback invoked when the action changes
+func (s *Scheduler) SetDecisionCallback(cb func(Decision)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onDecision = cb
+}
+
+// SetPolicy replaces the tariff policy
+func (s *Scheduler) SetPolicy(p Policy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policy = p
+}
+
+// GetPolicy returns the tariff policy
+func (s *Scheduler) GetPolicy() Policy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.policy
+}
+
+// SetOverride forces mining regardless of price when enabled
+func (s *Scheduler) SetOverride(enabled bool) {
+	s.mu.Lock()
+	s.override = enabled
+	s.mu.Unlock()
+
+	s.Evaluate(time.Now())
+}
+
+// IsOverridden returns whether the price override is active
+func (s *Scheduler) IsOverridden() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.override
+}
+
+// Current returns the most recent decision
+func (s *Scheduler) Current() Decision {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.last
+}
+
+// Start begins periodic evaluation
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.shutdown = make(chan struct{})
+	shutdown := s.shutdown
+	interval := s.interval
+	s.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		s.Evaluate(time.Now())
+		for {
+			select {
+			case <-shutdown:
+				return
+			case now := <-ticker.C:
+				s.Evaluate(now)
+			}
+		}
+	}()
+}
+
+// Stop halts periodic evaluation
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.running {
+		return
+	}
+	s.running = false
+	close(s.shutdown)
+}
+
+// Evaluate computes the decision for now and fires the callback if the
+// action changed
+func (s *Scheduler) Evaluate(now time.Time) Decision {
+	s.mu.RLock()
+	policy := s.policy
+	override := s.override
+	s.mu.RUnlock()
+
+	d := Decision{At: now, Override: override}
+	d.Price, d.Source = PriceAt(policy.Windows, now)
+
+	if policy.PriceURL != "" {
+		price, err := s.fetchPrice(policy.PriceURL)
+		if err != nil {
+			d.Error = err.Error()
+		} else {
+			d.Price, d.Source = price, SourceAPI
+		}
+	}
+
+	if d.Source == SourceNone && policy.DefaultPrice > 0 {
+		d.Price, d.Source = policy.DefaultPrice, SourceDefault
+	}
+
+	d.Action = ActionMine
+	if !override && d.Source != SourceNone {
+		switch {
+		case policy.PauseAbove > 0 && d.Price >= policy.PauseAbove:
+			d.Action = ActionPause
+		case policy.ThrottleAbove > 0 && d.Price >= policy.ThrottleAbove:
+			d.Action = ActionThrottle
+		}
+	}
+
+	s.mu.Lock()
+	changed := d.Action != s.last.Action || s.last.At.IsZero()
+	s.last = d
+	cb := s.onDecision
+	s.mu.Unlock()
+
+	if changed && cb != nil {
+		cb(d)
+	}
+
+	return d
+}
+
+// fetchPrice queries a dynamic price API returning {"price": <number>}
+func (s *Scheduler) fetchPrice(url string) (float64, error) {
+	resp, err := s.httpClient.Get(url)
+	if err != nil {
+		return 0, fmt.Errorf("price API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("price API returned %s", resp.Status)
+	}
+
+	var body struct {
+		Price *float64 `json:"price"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("invalid price API response: %w", err)
+	}
+	if body.Price == nil {
+		return 0, fmt.Errorf("price API response has no price")
+	}
+
+	return *body.Price, nil
+}
+
+// PriceAt returns the scheduled price in force at t
+func PriceAt(windows []Window, t time.Time) (float64, string) {
+	for _, w := range windows {
+		matched, err := clockwindow.Contains(w.Start, w.End, t, func(day time.Weekday) bool {
+			return onDay(w.Days, strings.ToLower(day.String()[:3]))
+		})
+		if err == nil && matched {
+			return w.Price, SourceSchedule
+		}
+	}
+
+	return 0, SourceNone
+}
+
+// onDay reports whether day is in days, an empty list matching every day
+func onDay(days []string, day string) bool {
+	if len(days) == 0 {
+		return true
+	}
+	for _, d := range days {
+		if strings.HasPrefix(strings.ToLower(d), day) {
+			return true
+		}
+	}
+	return false
+}
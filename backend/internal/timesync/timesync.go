@@ -0,0 +1,213 @@
+// Package timesync measures the local clock's offset from an NTP server,
+// so rolled block times can be corrected on machines with broken clocks.
+package timesync
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// ntpEpochOffset is the number of seconds from 1900, the NTP epoch, to 1970
+const ntpEpochOffset = 2208988800
+
+// queryTimeout bounds a single NTP exchange
+const queryTimeout = 5 * time.Second
+
+// Status is the result of the latest clock check
+type Status struct {
+	Server    string    `json:"server"`
+	OffsetMs  float64   `json:"offset_ms"`
+	RTTMs     float64   `json:"rtt_ms"`
+	Synced    bool      `json:"synced"`
+	CheckedAt time.Time `json:"checked_at"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Offset returns how far the NTP clock is ahead of the local clock
+func (s Status) Offset() time.Duration {
+	return time.Duration(s.OffsetMs * float64(time.Millisecond))
+}
+
+// Query asks an NTP server (host or host:port) for the local clock offset
+// and round trip time, using a single SNTP exchange (RFC 4330)
+func Query(server string, timeout time.Duration) (offset, rtt time.Duration, err error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "123")
+	}
+
+	conn, err := net.DialTimeout("udp", server, timeout)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	// LI 0, version 4, client mode, our transmit time to match the reply
+	req := make([]byte, 48)
+	req[0] = 0x23
+	sent := time.Now()
+	binary.BigEndian.PutUint64(req[40:], toNTP(sent))
+
+	if _, err := conn.Write(req); err != nil {
+		return 0, 0, err
+	}
+
+	resp := make([]byte, 48)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return 0, 0, err
+	}
+	received := sent.Add(time.Since(sent))
+
+	switch {
+	case n < 48:
+		return 0, 0, fmt.Errorf("short NTP reply from %s", server)
+	case resp[0]&0x07 != 4:
+		return 0, 0, fmt.Errorf("unexpected NTP mode %d from %s", resp[0]&0x07, server)
+	case resp[1] == 0:
+		return 0, 0, fmt.Errorf("NTP server %s refused the query", server)
+	case binary.BigEndian.Uint64(resp[24:]) != binary.BigEndian.Uint64(req[40:]):
+		return 0, 0, fmt.Errorf("NTP reply from %s does not match the query", server)
+	}
+
+	serverReceived := fromNTP(binary.BigEndian.Uint64(resp[32:]))
+	serverSent := fromNTP(binary.BigEndian.Uint64(resp[40:]))
+
+	offset = (serverReceived.Sub(sent) + serverSent.Sub(received)) / 2
+	rtt = received.Sub(sent) - serverSent.Sub(serverReceived)
+	return offset, rtt, nil
+}
+
+// toNTP converts a time to an NTP timestamp
+func toNTP(t time.Time) uint64 {
+	secs := uint64(t.Unix() + ntpEpochOffset)
+	frac := uint64(t.Nanosecond()) << 32 / uint64(time.Second)
+	return secs<<32 | frac
+}
+
+// fromNTP converts an NTP timestamp to a time
+func fromNTP(ts uint64) time.Time {
+	secs := int64(ts>>32) - ntpEpochOffset
+	nanos := int64((ts & 0xffffffff) * uint64(time.Second) >> 32)
+	return time.Unix(secs, nanos)
+}
+
+// Checker periodically measures the clock offset
+type Checker struct {
+	mu sync.RWMutex
+
+	server   string
+	interval time.Duration
+	last     Status
+
+	onResult func(Status)
+
+	running  bool
+	shutdown chan struct{}
+}
+
+// NewChecker creates a checker querying every 15 minutes
+func NewChecker() *Checker {
+	return &Checker{interval: 15 * time.Minute}
+}
+
+// SetServer sets the NTP server, empty disables checks
+func (c *Checker) SetServer(server string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.server = server
+}
+
+// SetResultCallback sets the callback invoked after every check
+func (c *Checker) SetResultCallback(cb func(Status)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onResult = cb
+}
+
+// GetStatus returns the latest check
+func (c *Checker) GetStatus() Status {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.last
+}
+
+// Start begins periodic checks
+func (c *Checker) Start() {
+	c.mu.Lock()
+	if c.running {
+		c.mu.Unlock()
+		return
+	}
+	c.running = true
+	c.shutdown = make(chan struct{})
+	shutdown := c.shutdown
+	interval := c.interval
+	c.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		c.Check()
+		for {
+			select {
+			case <-shutdown:
+				return
+			case <-ticker.C:
+				c.Check()
+			}
+		}
+	}()
+}
+
+// Stop halts periodic checks
+func (c *Checker) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.running {
+		return
+	}
+	c.running = false
+	close(c.shutdown)
+}
+
+// Check queries the NTP server now. A failed check keeps the last
+// measured offset, as the clock rarely jumps between checks.
+func (c *Checker) Check() Status {
+	c.mu.RLock()
+	server := c.server
+	prev := c.last
+	c.mu.RUnlock()
+
+	if server == "" {
+		return prev
+	}
+
+	st := Status{Server: server, CheckedAt: time.Now()}
+	offset, rtt, err := Query(server, queryTimeout)
+	if err != nil {
+		st.Error = err.Error()
+		if prev.Server == server {
+			st.OffsetMs, st.RTTMs, st.Synced = prev.OffsetMs, prev.RTTMs, prev.Synced
+		}
+	} else {
+		st.OffsetMs = float64(offset) / float64(time.Millisecond)
+		st.RTTMs = float64(rtt) / float64(time.Millisecond)
+		st.Synced = true
+	}
+
+	c.mu.Lock()
+	c.last = st
+	cb := c.onResult
+	c.mu.Unlock()
+
+	if cb != nil {
+		cb(st)
+	}
+	return st
+}
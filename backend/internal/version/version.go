@@ -0,0 +1,14 @@
+package version
+
+import "runtime"
+
+// Build metadata, overridden at link time with -ldflags "-X ..."
+var (
+	Version = "dev"
+	Commit  = "unknown"
+)
+
+// GoVersion returns the Go toolchain used to build the binary
+func GoVersion() string {
+	return runtime.Version()
+}
@@ -403,8 +403,11 @@ function HistoryPanel({ history, sessions, t }) {
                                         <td>{share.worker_name}</td>
                                         <td className="mono text-gold">{share.difficulty.toFixed(4)}</td>
                                         <td>
-                                            <span className={`status ${share.accepted ? 'status--online' : 'status--offline'}`}>
-                                                {share.accepted ? 'OK' : 'Rejected'}
+                                            <span
+                                                className={`status ${share.accepted ? 'status--online' : share.status === 'pending' ? '' : 'status--offline'}`}
+                                                title={share.reason || ''}
+                                            >
+                                                {share.accepted ? 'OK' : share.status === 'pending' ? 'Pending' : share.status === 'stale' ? 'Stale' : 'Rejected'}
                                             </span>
                                         </td>
                                     </tr>
@@ -671,7 +674,8 @@ function App() {
     };
 
     const bestDiff = stats?.best_difficulty || 0;
-    const networkDifficulty = 75e12;
+    const networkShare = stats?.network_share;
+    const networkDifficulty = networkShare?.network_difficulty || 75e12;
     const diffProgress = bestDiff > 0 ? Math.log10(bestDiff + 1) / Math.log10(networkDifficulty) * 100 : 0;
 
     // Helper to get theme-specific asset
@@ -741,7 +745,8 @@ function App() {
                                 label={t('hashrate')}
                                 value={formatHashrate(stats?.hashrate)}
                                 variant="gold"
-                                subtitle={t('updatesEverySecond')}
+                                subtitle={networkShare?.one_in_text ? `${networkShare.one_in_text} (${networkShare.fraction_text})` : t('updatesEverySecond')}
+                                tooltip={t('networkShareTooltip')}
                             />
                             <StatCard
                                 icon={getAsset('icon-block')}
@@ -29,6 +29,7 @@ export const translations = {
         accepted: 'accepted',
         searching: 'Searching...',
         ofNetwork: '% of network',
+        networkShareTooltip: 'Your share of the network hashrate, estimated from the current block difficulty. "1 in N" is your chance of finding any given block.',
 
         // Workers
         workersTitle: '⛏️ Mining Workers',
@@ -125,6 +126,7 @@ export const translations = {
         accepted: 'acceptées',
         searching: 'Recherche...',
         ofNetwork: '% du réseau',
+        networkShareTooltip: 'Votre part du hashrate du réseau, estimée à partir de la difficulté de bloc actuelle. « 1 sur N » est votre chance de trouver un bloc donné.',
 
         // Workers
         workersTitle: '⛏️ Workers de Mining',